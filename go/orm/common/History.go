@@ -0,0 +1,46 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+const (
+	// ValidFromColumn holds the Unix time from which a row version is valid.
+	// Live tables of history types have it too; NULL there means since ever.
+	ValidFromColumn = "L8ValidFrom"
+
+	// ValidToColumn holds the Unix time at which a history row version was
+	// replaced or deleted.
+	ValidToColumn = "L8ValidTo"
+
+	// HistorySuffix is appended to a table name to name its history table.
+	HistorySuffix = "_history"
+)
+
+// IHistory is implemented by plugins that keep temporal history. For a root
+// type in history mode, every write and delete first copies the current rows
+// of the affected elements, root and child tables alike, into the
+// <table>_history tables with their valid-from/valid-to times. A query marked
+// with AsOf then reads the elements as they stood at that time.
+type IHistory interface {
+	// EnableHistory turns on history mode for a root type.
+	EnableHistory(typeName string)
+
+	// IsHistory reports whether a root type is in history mode.
+	IsHistory(typeName string) bool
+}
+
+// HistoryTable returns the name of the history table of a table.
+func HistoryTable(tableName string) string {
+	return tableName + HistorySuffix
+}
@@ -19,6 +19,8 @@ limitations under the License.
 package common
 
 import (
+	"context"
+
 	"github.com/saichler/l8orm/go/types/l8orms"
 	"github.com/saichler/l8types/go/ifs"
 	"github.com/saichler/l8types/go/types/l8api"
@@ -28,18 +30,30 @@ import (
 // IORM defines the primary interface for Object-Relational Mapping operations.
 // Implementations of this interface handle the conversion between Go objects
 // and database records, providing CRUD operations at the object level.
+// The Context variants bound the database calls by the given context; a
+// cancelled or expired context aborts the call and rolls back its transaction.
+// The plain methods run with context.Background().
 type IORM interface {
 	// Read executes a query and returns the matching elements as Go objects.
 	// The query specifies the criteria, projections, and pagination parameters.
 	Read(ifs.IQuery, ifs.IResources) ifs.IElements
 
+	// ReadContext is Read bounded by the given context.
+	ReadContext(context.Context, ifs.IQuery, ifs.IResources) ifs.IElements
+
 	// Write persists elements to the database based on the specified action.
 	// Action can be POST (insert), PUT (replace), or PATCH (partial update).
 	Write(ifs.Action, ifs.IElements, ifs.IResources) error
 
+	// WriteContext is Write bounded by the given context.
+	WriteContext(context.Context, ifs.Action, ifs.IElements, ifs.IResources) error
+
 	// Delete removes records matching the query criteria from the database.
 	Delete(ifs.IQuery, ifs.IResources) error
 
+	// DeleteContext is Delete bounded by the given context.
+	DeleteContext(context.Context, ifs.IQuery, ifs.IResources) error
+
 	// Close releases database connections and cleans up resources.
 	Close() error
 }
@@ -61,6 +75,27 @@ type ITSDB interface {
 	// start and end are Unix timestamps (seconds).
 	GetTSDB(propertyId string, start, end int64) ([]*l8api.L8TimeSeriesPoint, error)
 
+	// GetTSDBBuckets aggregates the data points of a property within a time
+	// range into one point per bucket of the given seconds, aligned on the Unix
+	// epoch and stamped with the bucket start. function is one of TsdbAvg,
+	// TsdbMin, TsdbMax, TsdbLast or TsdbCount. The range is widened to the whole
+	// buckets holding start and end. Buckets without points are omitted.
+	GetTSDBBuckets(propertyId string, start, end, bucket int64, function string) ([]*l8api.L8TimeSeriesPoint, error)
+
+	// GetTSDBLatestBatch retrieves the most recent limit data points of many
+	// properties in one round trip, ordered chronologically and grouped by
+	// property ID. A property ID containing TsdbWildcard is a pattern selecting
+	// all the matching properties, e.g. "networkdevice<*>.cpu". Properties
+	// without points are absent from the result.
+	GetTSDBLatestBatch(propertyIds []string, limit int) (map[string][]*l8api.L8TimeSeriesPoint, error)
+
+	// GetTSDBBatch retrieves the data points of many properties, or of the
+	// properties matching patterns, within a time range in one round trip,
+	// grouped by property ID. With a bucket or a function, the points of each
+	// property are aggregated like GetTSDBBuckets does; with neither, the raw
+	// points are returned. Properties without points are absent from the result.
+	GetTSDBBatch(propertyIds []string, start, end, bucket int64, function string) (map[string][]*l8api.L8TimeSeriesPoint, error)
+
 	// Close releases database connections and cleans up resources.
 	Close() error
 }
@@ -71,11 +106,18 @@ type ITSDB interface {
 type IORMRelational interface {
 	IORM
 
-	// ReadRelational executes a query and returns raw relational data.
-	// This is useful for advanced scenarios where the relational structure is needed.
-	ReadRelational(ifs.IQuery) (*l8orms.L8OrmRData, error)
+	// ReadRelational executes a query and returns raw relational data along with
+	// the query metadata (record counts). This is useful for advanced scenarios
+	// where the relational structure is needed.
+	ReadRelational(ifs.IQuery) (*l8orms.L8OrmRData, *l8api.L8MetaData, error)
+
+	// ReadRelationalContext is ReadRelational bounded by the given context.
+	ReadRelationalContext(context.Context, ifs.IQuery) (*l8orms.L8OrmRData, *l8api.L8MetaData, error)
 
 	// WriteRelational persists relational data directly to the database.
 	// The action determines whether to insert, replace, or patch the data.
 	WriteRelational(ifs.Action, *l8orms.L8OrmRData) error
+
+	// WriteRelationalContext is WriteRelational bounded by the given context.
+	WriteRelationalContext(context.Context, ifs.Action, *l8orms.L8OrmRData) error
 }
@@ -0,0 +1,76 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"strings"
+
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// NestedPath returns the field names leading from a root type down to the
+// child table holding an attribute, one per nesting level, ending with the
+// field of the attribute's own table. It reports false when the attribute is
+// a column of the root table or is not stored under it.
+//
+// A criteria on a nested attribute filters the root elements that have at
+// least one child row, at that path, satisfying it.
+func NestedPath(attribute *l8reflect.L8Node, rootType string) ([]string, bool) {
+	if attribute == nil {
+		return nil, false
+	}
+	path := make([]string, 0)
+	for node := attribute.Parent; node != nil; node = node.Parent {
+		if node.Parent == nil {
+			if node.TypeName != rootType || len(path) == 0 {
+				return nil, false
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path, true
+		}
+		path = append(path, node.FieldName)
+	}
+	return nil, false
+}
+
+// UnderNestedPath reports whether a child row with the given keys is stored
+// under the root key at the given path. A child row's ParentKey is the root
+// key followed by the "Field[key]" RecKeys of the levels above it.
+func UnderNestedPath(parentKey, recKey, rootKey string, path []string) bool {
+	if !strings.HasPrefix(recKey, path[len(path)-1]+"[") || !strings.HasPrefix(parentKey, rootKey) {
+		return false
+	}
+	return matchLevels(parentKey[len(rootKey):], path[:len(path)-1])
+}
+
+// matchLevels reports whether key is a sequence of "Field[key]" RecKeys for
+// the given fields. Keys may contain brackets, so every closing bracket is tried.
+func matchLevels(key string, fields []string) bool {
+	if len(fields) == 0 {
+		return key == ""
+	}
+	if !strings.HasPrefix(key, fields[0]+"[") {
+		return false
+	}
+	rest := key[len(fields[0])+1:]
+	for i := 0; i < len(rest); i++ {
+		if rest[i] == ']' && matchLevels(rest[i+1:], fields[1:]) {
+			return true
+		}
+	}
+	return false
+}
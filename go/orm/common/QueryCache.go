@@ -0,0 +1,25 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+// IQueryCache is implemented by plugins that cache query results in memory,
+// so a service can bring them up to date with the writes made by other
+// replicas of the service over the same database.
+type IQueryCache interface {
+	// RefreshQueries brings the cached queries of a root type up to date with
+	// the stored root rows with the given RecKeys, or drops all of them when
+	// recKeys is nil.
+	RefreshQueries(typeName string, recKeys []string)
+}
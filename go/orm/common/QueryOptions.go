@@ -0,0 +1,91 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// optionsQuery carries plugin read options that are not part of the query text.
+type optionsQuery struct {
+	ifs.IQuery
+	softDelete     bool      // The root type is in soft delete mode
+	includeDeleted bool      // Tombstoned rows are included
+	asOf           int64     // Unix time to read the data as of, 0 for the current data
+	sortKeys       []SortKey // Sort order replacing the query's sort-by column
+}
+
+// optionsOf returns a copy of the query's options wrapping the plain query, so
+// that setting an option never changes a query shared with the caller.
+func optionsOf(query ifs.IQuery) *optionsQuery {
+	if oq, ok := query.(*optionsQuery); ok {
+		c := *oq
+		return &c
+	}
+	return &optionsQuery{IQuery: query}
+}
+
+// SoftDeleteQuery marks a query as reading a root type in soft delete mode, so
+// the statement builders exclude tombstoned rows. Plugins call it when a query
+// enters them; a query already marked is returned as is.
+func SoftDeleteQuery(query ifs.IQuery) ifs.IQuery {
+	if oq, ok := query.(*optionsQuery); ok && oq.softDelete {
+		return query
+	}
+	oq := optionsOf(query)
+	oq.softDelete = true
+	return oq
+}
+
+// WithDeleted returns the query marked to include tombstoned rows.
+func WithDeleted(query ifs.IQuery) ifs.IQuery {
+	oq := optionsOf(query)
+	oq.softDelete = true
+	oq.includeDeleted = true
+	return oq
+}
+
+// IncludesDeleted reports whether the query is marked to include tombstoned rows.
+func IncludesDeleted(query ifs.IQuery) bool {
+	oq, ok := query.(*optionsQuery)
+	return ok && oq.includeDeleted
+}
+
+// ExcludesDeleted reports whether tombstoned rows must be filtered out of the
+// query's results.
+func ExcludesDeleted(query ifs.IQuery) bool {
+	oq, ok := query.(*optionsQuery)
+	return ok && oq.softDelete && !oq.includeDeleted
+}
+
+// AsOf returns the query marked to read the data as it stood at the given
+// time, from the history tables of a root type in history mode.
+func AsOf(query ifs.IQuery, at time.Time) ifs.IQuery {
+	oq := optionsOf(query)
+	oq.asOf = at.Unix()
+	return oq
+}
+
+// AsOfTime returns the Unix time an AsOf query reads the data at, and false
+// for queries reading the current data.
+func AsOfTime(query ifs.IQuery) (int64, bool) {
+	oq, ok := query.(*optionsQuery)
+	if !ok || oq.asOf == 0 {
+		return 0, false
+	}
+	return oq.asOf, true
+}
@@ -0,0 +1,45 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// DeletedAtColumn is the root table column holding the Unix time at which a
+// row was soft deleted. It is NULL for live rows.
+const DeletedAtColumn = "L8DeletedAt"
+
+// ISoftDelete is implemented by plugins that support soft delete. For a root
+// type in soft delete mode, Delete stamps the matching rows with the deletion
+// time instead of removing them, and reads skip stamped rows (tombstones)
+// unless the query is marked with WithDeleted. Writing an element again
+// clears its tombstone.
+type ISoftDelete interface {
+	// EnableSoftDelete turns on soft delete mode for a root type.
+	EnableSoftDelete(typeName string)
+
+	// IsSoftDelete reports whether a root type is in soft delete mode.
+	IsSoftDelete(typeName string) bool
+
+	// Restore clears the tombstones of the deleted rows matching the query.
+	Restore(ifs.IQuery) error
+
+	// Purge physically removes the tombstoned rows of a root type, and their
+	// child rows, that were deleted age or more ago.
+	Purge(typeName string, age time.Duration) error
+}
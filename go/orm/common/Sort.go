@@ -0,0 +1,116 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"strings"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// SortKey is one key of a query's sort order. Field is an attribute of the
+// root type, or a dotted path to an attribute of a single-valued nested
+// struct such as "mysingle.mystring".
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// Asc returns an ascending sort key.
+func Asc(field string) SortKey {
+	return SortKey{Field: field}
+}
+
+// Desc returns a descending sort key.
+func Desc(field string) SortKey {
+	return SortKey{Field: field, Descending: true}
+}
+
+// String returns the key as "field asc" or "field desc".
+func (this SortKey) String() string {
+	if this.Descending {
+		return this.Field + " desc"
+	}
+	return this.Field + " asc"
+}
+
+// OrderBy returns the query marked to sort by the given keys, in order, in
+// place of its own sort-by column. Later keys order the rows that are equal
+// on all the keys before them.
+func OrderBy(query ifs.IQuery, keys ...SortKey) ifs.IQuery {
+	oq := optionsOf(query)
+	oq.sortKeys = append([]SortKey{}, keys...)
+	return oq
+}
+
+// IsOrderedBy reports whether the query's sort keys were set by OrderBy.
+func IsOrderedBy(query ifs.IQuery) bool {
+	oq, ok := query.(*optionsQuery)
+	return ok && len(oq.sortKeys) > 0
+}
+
+// SortKeys returns the sort keys of a query: the keys set by OrderBy, else
+// its sort-by column, else nil for an unsorted query.
+func SortKeys(query ifs.IQuery) []SortKey {
+	if oq, ok := query.(*optionsQuery); ok && len(oq.sortKeys) > 0 {
+		return oq.sortKeys
+	}
+	if query.SortBy() == "" {
+		return nil
+	}
+	return []SortKey{{Field: query.SortBy(), Descending: query.Descending()}}
+}
+
+// SortAttribute resolves a sort key field against a root type node. It returns
+// the attribute and the field path from the root to the attribute's table, in
+// the form of NestedPath, which is empty for a root attribute. It reports false
+// when a level is missing, or when a nested level is a slice or a map and so
+// has no single value to sort by.
+func SortAttribute(root *l8reflect.L8Node, field string) (*l8reflect.L8Node, []string, bool) {
+	names := strings.Split(field, ".")
+	if len(names) > 1 && strings.EqualFold(names[0], root.TypeName) {
+		names = names[1:]
+	}
+	path := make([]string, 0)
+	node := root
+	for i, name := range names {
+		node = attributeOf(node, name)
+		if node == nil {
+			return nil, nil, false
+		}
+		if i == len(names)-1 {
+			return node, path, !node.IsStruct
+		}
+		if !node.IsStruct || node.IsSlice || node.IsMap {
+			return nil, nil, false
+		}
+		path = append(path, node.FieldName)
+	}
+	return nil, nil, false
+}
+
+// attributeOf returns the attribute of a node with the given name, ignoring case.
+func attributeOf(node *l8reflect.L8Node, name string) *l8reflect.L8Node {
+	if attr, ok := node.Attributes[name]; ok {
+		return attr
+	}
+	for attrName, attr := range node.Attributes {
+		if strings.EqualFold(attrName, name) {
+			return attr
+		}
+	}
+	return nil
+}
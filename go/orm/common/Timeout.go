@@ -0,0 +1,59 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// timeoutPrefix starts the message of every TimeoutError, so a timeout can
+// still be recognized after the error crossed the network as text.
+const timeoutPrefix = "request timeout"
+
+// TimeoutError is returned when an ORM operation does not complete within
+// its deadline. The database call is cancelled and its transaction rolled back.
+type TimeoutError struct {
+	Operation string        // Operation that timed out, e.g. "Read" or "Write"
+	TypeName  string        // Root type of the operation
+	Timeout   time.Duration // Deadline the operation was given
+}
+
+// Error describes the operation and the deadline it exceeded.
+func (this *TimeoutError) Error() string {
+	return timeoutPrefix + ": " + this.Operation + " of " + this.TypeName +
+		" did not complete within " + this.Timeout.String()
+}
+
+// Unwrap returns context.DeadlineExceeded, so errors.Is recognizes a timeout.
+func (this *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// IsTimeout reports whether the error is a timeout, either as a TimeoutError
+// or an expired context, or as the text of one returned as an error element
+// or by a remote service.
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	return strings.HasPrefix(err.Error(), timeoutPrefix) ||
+		strings.Contains(err.Error(), context.DeadlineExceeded.Error())
+}
@@ -0,0 +1,44 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"strings"
+)
+
+// TsdbWildcard matches any characters in a property ID pattern of a batch
+// TSDB query, for example "networkdevice<*>.cpu".
+const TsdbWildcard = "*"
+
+// MatchTsdbProperty reports whether a property ID is the given one, or matches
+// it when it is a pattern with wildcards.
+func MatchTsdbProperty(pattern, propertyId string) bool {
+	parts := strings.Split(pattern, TsdbWildcard)
+	if len(parts) == 1 {
+		return pattern == propertyId
+	}
+	if !strings.HasPrefix(propertyId, parts[0]) {
+		return false
+	}
+	rest := propertyId[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+	return strings.HasSuffix(rest, parts[len(parts)-1])
+}
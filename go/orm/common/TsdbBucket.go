@@ -0,0 +1,115 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/saichler/l8types/go/types/l8api"
+)
+
+// Aggregate functions of a bucketed time series query.
+const (
+	TsdbAvg   = "avg"   // Average of the values in the bucket
+	TsdbMin   = "min"   // Smallest value in the bucket
+	TsdbMax   = "max"   // Largest value in the bucket
+	TsdbLast  = "last"  // Value with the latest stamp in the bucket
+	TsdbCount = "count" // Number of points in the bucket
+)
+
+// TsdbBucketArgs checks the arguments of a bucketed time series query. It
+// returns the aggregate function in lower case, TsdbAvg when empty, or an
+// error when the bucket is not a positive number of seconds or the function
+// is not one of the supported ones.
+func TsdbBucketArgs(bucket int64, function string) (string, error) {
+	if bucket <= 0 {
+		return "", errors.New("TSDB bucket must be a positive number of seconds")
+	}
+	function = strings.ToLower(strings.TrimSpace(function))
+	switch function {
+	case "":
+		return TsdbAvg, nil
+	case TsdbAvg, TsdbMin, TsdbMax, TsdbLast, TsdbCount:
+		return function, nil
+	}
+	return "", errors.New("unsupported TSDB function " + function + ", expected avg, min, max, last or count")
+}
+
+// BucketStart returns the start of the bucket holding the stamp, buckets
+// being aligned on the Unix epoch.
+func BucketStart(stamp, bucket int64) int64 {
+	start := stamp - stamp%bucket
+	if stamp < 0 && stamp%bucket != 0 {
+		start -= bucket
+	}
+	return start
+}
+
+// BucketRange widens a time range to the whole buckets holding its start and
+// its end, so a bucketed query aggregates complete buckets whether it reads
+// the raw points or an aggregate of them.
+func BucketRange(start, end, bucket int64) (int64, int64) {
+	return BucketStart(start, bucket), BucketStart(end, bucket) + bucket - 1
+}
+
+// BucketPoints aggregates points ordered by stamp into one point per bucket
+// of the given seconds, stamped with the start of the bucket. Buckets without
+// points are omitted. function must be one returned by TsdbBucketArgs.
+func BucketPoints(points []*l8api.L8TimeSeriesPoint, bucket int64, function string) []*l8api.L8TimeSeriesPoint {
+	var result []*l8api.L8TimeSeriesPoint
+	var current *l8api.L8TimeSeriesPoint
+	var sum float64
+	var count int
+	flush := func() {
+		if current == nil {
+			return
+		}
+		switch function {
+		case TsdbAvg:
+			current.Value = sum / float64(count)
+		case TsdbCount:
+			current.Value = float64(count)
+		}
+		result = append(result, current)
+	}
+	for _, p := range points {
+		if p == nil {
+			continue
+		}
+		start := BucketStart(p.Stamp, bucket)
+		if current == nil || current.Stamp != start {
+			flush()
+			current = &l8api.L8TimeSeriesPoint{Stamp: start, Value: p.Value}
+			sum, count = 0, 0
+		}
+		sum += p.Value
+		count++
+		switch function {
+		case TsdbMin:
+			if p.Value < current.Value {
+				current.Value = p.Value
+			}
+		case TsdbMax:
+			if p.Value > current.Value {
+				current.Value = p.Value
+			}
+		case TsdbLast:
+			current.Value = p.Value
+		}
+	}
+	flush()
+	return result
+}
@@ -0,0 +1,64 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// versionConflictPrefix starts the message of every VersionConflictError, so a
+// conflict can still be recognized after the error crossed the network as text.
+const versionConflictPrefix = "version conflict"
+
+// IVersioned is implemented by plugins that support optimistic concurrency.
+// Root types that have an int64 attribute with the version field name carry
+// their row version in it; every write increments the stored version, and a
+// write carrying a stale version fails with a VersionConflictError.
+type IVersioned interface {
+	// VersionField returns the name of the version attribute, or "" when
+	// versioning is disabled.
+	VersionField() string
+}
+
+// VersionConflictError is returned when a write carries a version that does
+// not match the stored version of the row.
+type VersionConflictError struct {
+	TypeName string // Root type of the row
+	Key      string // ParentKey + RecKey of the row
+	Version  int64  // Version carried by the write
+	Stored   int64  // Version stored in the database
+}
+
+// Error describes the conflicting row and versions.
+func (this *VersionConflictError) Error() string {
+	return versionConflictPrefix + " on " + this.TypeName + " " + this.Key +
+		": write carries version " + strconv.FormatInt(this.Version, 10) +
+		", stored version is " + strconv.FormatInt(this.Stored, 10)
+}
+
+// IsVersionConflict reports whether the error is a version conflict, either as
+// a VersionConflictError or as the text of one returned by a remote service.
+func IsVersionConflict(err error) bool {
+	if err == nil {
+		return false
+	}
+	var conflict *VersionConflictError
+	if errors.As(err, &conflict) {
+		return true
+	}
+	return strings.HasPrefix(err.Error(), versionConflictPrefix)
+}
@@ -35,6 +35,9 @@ import (
 // It flattens the object hierarchy into tables, with each struct type becoming a table
 // and nested structs stored in separate tables linked by parent keys.
 // The action parameter (POST/PATCH) affects how zero values are handled.
+// For PATCH, elements implementing IFieldMask limit the conversion to the
+// listed fields, which are kept even when they hold zero values, and the
+// field mask is set on the relational data.
 func ConvertTo(action ifs.Action, objects ifs.IElements, res ifs.IResources) ifs.IElements {
 	if objects == nil {
 		return nil
@@ -58,11 +61,19 @@ func ConvertTo(action ifs.Action, objects ifs.IElements, res ifs.IResources) ifs
 		node = n
 	}
 
+	var mask *fieldMask
+	if action == ifs.PATCH {
+		mask = newFieldMask(FieldMaskOf(objects), node)
+		if mask != nil {
+			data.FieldMask = FieldMaskOf(objects)
+		}
+	}
+
 	elements := objects.Elements()
 	keys := objects.Keys()
 
 	if len(elements) == 1 {
-		err := convertTo(action, v, "", "", node, data, res)
+		err := convertTo(action, v, "", "", node, data, mask, res)
 		if err != nil {
 			return object.NewError(err.Error())
 		}
@@ -75,7 +86,7 @@ func ConvertTo(action ifs.Action, objects ifs.IElements, res ifs.IResources) ifs
 			str := strings.New()
 			key = str.ToString(reflect.ValueOf(keys[i]))
 		}
-		err := convertTo(action, reflect.ValueOf(element), "", key, node, data, res)
+		err := convertTo(action, reflect.ValueOf(element), "", key, node, data, mask, res)
 		if err != nil {
 			return object.NewError(err.Error())
 		}
@@ -105,8 +116,9 @@ func TypeOf(v reflect.Value) (string, error) {
 // convertTo recursively converts a single Go value into relational table rows.
 // It handles struct fields by storing simple values in columns and recursively
 // processing nested structs, slices, and maps into their respective tables.
-// For PATCH actions, zero values are skipped to enable partial updates.
-func convertTo(action ifs.Action, value reflect.Value, parentKey, myKey string, node *l8reflect.L8Node, data *l8orms.L8OrmRData, res ifs.IResources) error {
+// For PATCH actions, zero values are skipped to enable partial updates. When a
+// field mask is given, only the listed fields are converted, zero or not.
+func convertTo(action ifs.Action, value reflect.Value, parentKey, myKey string, node *l8reflect.L8Node, data *l8orms.L8OrmRData, mask *fieldMask, res ifs.IResources) error {
 	if value.Kind() == reflect.Ptr {
 		value = value.Elem()
 	}
@@ -130,13 +142,20 @@ func convertTo(action ifs.Action, value reflect.Value, parentKey, myKey string,
 				extractTsData(value, node, attrName, data, res)
 				continue
 			}
+			if mask != nil && mask.child(attrName) == nil {
+				continue
+			}
 			subTableAttributes[attrName] = attrNode
 			continue
 		}
 		fieldValue := value.FieldByName(attrName)
 		if fieldValue.IsValid() {
-			// For PATCH, skip zero/default values
-			if action == ifs.PATCH && fieldValue.IsZero() {
+			// For PATCH, write the listed fields, or skip zero/default values
+			if mask != nil {
+				if mask.child(attrName) == nil {
+					continue
+				}
+			} else if action == ifs.PATCH && isEmpty(fieldValue) {
 				continue
 			}
 			col := table.Columns[attrName]
@@ -148,6 +167,10 @@ func convertTo(action ifs.Action, value reflect.Value, parentKey, myKey string,
 	}
 
 	for attrName, attrNode := range subTableAttributes {
+		var attrMask *fieldMask
+		if mask != nil {
+			attrMask = mask.child(attrName)
+		}
 		fieldValue := value.FieldByName(attrName)
 		if fieldValue.IsValid() {
 			if attrNode.IsMap {
@@ -156,7 +179,7 @@ func convertTo(action ifs.Action, value reflect.Value, parentKey, myKey string,
 					mapValue := fieldValue.MapIndex(mapKey)
 					mapValueStr := strings.New()
 					mapValueStr.TypesPrefix = true
-					err := convertTo(action, mapValue, KeyForRow(row), mapValueStr.ToString(mapKey), attrNode, data, res)
+					err := convertTo(action, mapValue, KeyForRow(row), mapValueStr.ToString(mapKey), attrNode, data, attrMask, res)
 					if err != nil {
 						return err
 					}
@@ -164,13 +187,13 @@ func convertTo(action ifs.Action, value reflect.Value, parentKey, myKey string,
 			} else if attrNode.IsSlice {
 				for i := 0; i < fieldValue.Len(); i++ {
 					sliceValue := fieldValue.Index(i)
-					err := convertTo(action, sliceValue, KeyForRow(row), strconv.Itoa(i), attrNode, data, res)
+					err := convertTo(action, sliceValue, KeyForRow(row), strconv.Itoa(i), attrNode, data, attrMask, res)
 					if err != nil {
 						return err
 					}
 				}
 			} else {
-				err := convertTo(action, fieldValue, KeyForRow(row), "", attrNode, data, res)
+				err := convertTo(action, fieldValue, KeyForRow(row), "", attrNode, data, attrMask, res)
 				if err != nil {
 					return err
 				}
@@ -182,6 +205,16 @@ func convertTo(action ifs.Action, value reflect.Value, parentKey, myKey string,
 	return nil
 }
 
+// isEmpty reports whether a PATCH field holds no value, a zero value or an
+// empty slice or map.
+func isEmpty(fieldValue reflect.Value) bool {
+	if fieldValue.IsZero() {
+		return true
+	}
+	kind := fieldValue.Kind()
+	return (kind == reflect.Slice || kind == reflect.Map) && fieldValue.Len() == 0
+}
+
 // TableAndRowsCreate creates or retrieves the table and attribute rows for a given node.
 // It initializes all necessary nested structures in the relational data hierarchy.
 // Unlike TableAndRowsGet, this function creates missing structures rather than returning nil.
@@ -255,6 +288,26 @@ func KeyForRow(row *l8orms.L8OrmRow) string {
 	return buff.String()
 }
 
+// RootKeys returns the keys of the root rows in the relational data. Every
+// child row stored under a root row has a ParentKey starting with its key.
+func RootKeys(data *l8orms.L8OrmRData) []string {
+	keys := make([]string, 0)
+	table, ok := data.Tables[data.RootTypeName]
+	if !ok {
+		return keys
+	}
+	instRows, ok := table.InstanceRows[""]
+	if !ok {
+		return keys
+	}
+	for _, attrRows := range instRows.AttributeRows {
+		for _, row := range attrRows.Rows {
+			keys = append(keys, KeyForRow(row))
+		}
+	}
+	return keys
+}
+
 func extractTsData(value reflect.Value, node *l8reflect.L8Node, attrName string, data *l8orms.L8OrmRData, res ifs.IResources) {
 	fieldValue := value.FieldByName(attrName)
 	if !fieldValue.IsValid() || fieldValue.IsNil() || fieldValue.Len() == 0 {
@@ -0,0 +1,216 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package convert
+
+import (
+	strings2 "strings"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// IFieldMask is implemented by PATCH elements that carry an explicit list of
+// field paths. Listed fields are written as is, including zero values, and
+// fields that are not listed are left untouched. A listed slice or map field
+// replaces the stored one, so it can be shortened or cleared.
+type IFieldMask interface {
+	FieldMask() []string
+}
+
+// maskedElements attaches a field mask to PATCH elements.
+type maskedElements struct {
+	ifs.IElements
+	fields []string
+}
+
+// FieldMask returns the explicit field paths.
+func (this *maskedElements) FieldMask() []string {
+	return this.fields
+}
+
+// NewFieldMask attaches explicit field paths to PATCH elements. Paths are
+// relative to the root type and dot separated, e.g. "myint32" or
+// "mysingle.mystring", and may be prefixed with the root type name. A path
+// naming a nested struct, slice or map writes all of its fields.
+func NewFieldMask(elems ifs.IElements, fields ...string) ifs.IElements {
+	return &maskedElements{IElements: elems, fields: fields}
+}
+
+// NewFieldMaskFromQuery attaches the properties selected by a query as the
+// field paths of PATCH elements, e.g. "select myint32,mysingle.mystring from testproto".
+func NewFieldMaskFromQuery(elems ifs.IElements, query ifs.IQuery) ifs.IElements {
+	fields := make([]string, 0, len(query.Properties()))
+	for _, p := range query.Properties() {
+		id, _ := p.PropertyId()
+		fields = append(fields, id)
+	}
+	return NewFieldMask(elems, fields...)
+}
+
+// NewFieldMaskRequest returns PATCH elements with a field mask as relational
+// data carrying the mask, so the mask survives sending the PATCH to a service
+// over the network, where FieldMaskElements turns it back into the elements.
+func NewFieldMaskRequest(elems ifs.IElements, res ifs.IResources) ifs.IElements {
+	relData := ConvertTo(ifs.POST, elems, res)
+	if relData.Error() != nil {
+		return relData
+	}
+	relData.Element().(*l8orms.L8OrmRData).FieldMask = FieldMaskOf(elems)
+	return relData
+}
+
+// FieldMaskElements returns the masked PATCH elements of a request made with
+// NewFieldMaskRequest, or the elements as is when they are not such a request.
+func FieldMaskElements(elems ifs.IElements, res ifs.IResources) ifs.IElements {
+	if elems == nil {
+		return elems
+	}
+	data, ok := elems.Element().(*l8orms.L8OrmRData)
+	if !ok || len(data.FieldMask) == 0 {
+		return elems
+	}
+	objects := ConvertFrom(object.New(nil, data), nil, res)
+	if objects.Error() != nil {
+		return objects
+	}
+	return NewFieldMask(objects, data.FieldMask...)
+}
+
+// FieldMaskOf returns the field paths carried by the elements, or nil when
+// the elements carry no field mask.
+func FieldMaskOf(elems ifs.IElements) []string {
+	masked, ok := elems.(IFieldMask)
+	if !ok {
+		return nil
+	}
+	return masked.FieldMask()
+}
+
+// fieldMask is the tree of explicit field paths below one node. When all is
+// set, every field below the node is written.
+type fieldMask struct {
+	all      bool
+	children map[string]*fieldMask
+}
+
+// newFieldMask builds the field mask tree for the root node, or returns nil
+// when there are no paths.
+func newFieldMask(paths []string, node *l8reflect.L8Node) *fieldMask {
+	if len(paths) == 0 {
+		return nil
+	}
+	root := &fieldMask{children: make(map[string]*fieldMask)}
+	rootName := strings2.ToLower(node.TypeName)
+	for _, path := range paths {
+		names := strings2.Split(strings2.ToLower(path), ".")
+		if len(names) > 1 && names[0] == rootName {
+			names = names[1:]
+		}
+		current := root
+		for _, name := range names {
+			if current.all {
+				break
+			}
+			next, ok := current.children[name]
+			if !ok {
+				next = &fieldMask{children: make(map[string]*fieldMask)}
+				current.children[name] = next
+			}
+			current = next
+		}
+		current.all = true
+	}
+	return root
+}
+
+// child returns the mask of an attribute, or nil when the attribute is not listed.
+func (this *fieldMask) child(attrName string) *fieldMask {
+	if this.all {
+		return this
+	}
+	return this.children[strings2.ToLower(attrName)]
+}
+
+// Collection is a slice or map field of a stored row.
+type Collection struct {
+	ParentKey string // Full key of the row holding the field
+	FieldName string // Name of the slice or map field
+}
+
+// ReplacedCollections returns the slice and map fields that PATCH relational
+// data replaces as a whole, as listed by its field mask. Their stored rows are
+// deleted before the data is written, and their rows in the data are inserted.
+// Fields of nested structs are found through the rows of the structs in the
+// data; a path going through a slice or map patches its elements instead.
+func ReplacedCollections(data *l8orms.L8OrmRData, node *l8reflect.L8Node) []Collection {
+	mask := newFieldMask(data.FieldMask, node)
+	if mask == nil {
+		return nil
+	}
+	collections := make([]Collection, 0)
+	for _, key := range RootKeys(data) {
+		collections = replacedCollections(data, key, node, mask, collections)
+	}
+	return collections
+}
+
+// replacedCollections appends the collections replaced below the row with the
+// given key, of the given node, to collections.
+func replacedCollections(data *l8orms.L8OrmRData, key string, node *l8reflect.L8Node, mask *fieldMask, collections []Collection) []Collection {
+	for attrName, attr := range node.Attributes {
+		if !attr.IsStruct || common.IsTimeSeriesType(attr.TypeName) {
+			continue
+		}
+		attrMask := mask.child(attrName)
+		if attrMask == nil {
+			continue
+		}
+		if attr.IsSlice || attr.IsMap {
+			if attrMask.all {
+				collections = append(collections, Collection{ParentKey: key, FieldName: attr.FieldName})
+			}
+			continue
+		}
+		table, ok := data.Tables[attr.TypeName]
+		if !ok {
+			continue
+		}
+		instRows, ok := table.InstanceRows[key]
+		if !ok {
+			continue
+		}
+		if attrRows, ok := instRows.AttributeRows[attr.FieldName]; ok {
+			for _, row := range attrRows.Rows {
+				collections = replacedCollections(data, KeyForRow(row), attr, attrMask, collections)
+			}
+		}
+	}
+	return collections
+}
+
+// InCollections reports whether a row is an element of one of the
+// collections, or is stored below one.
+func InCollections(row *l8orms.L8OrmRow, collections []Collection) bool {
+	key := KeyForRow(row)
+	for _, collection := range collections {
+		if strings2.HasPrefix(key, collection.ParentKey+collection.FieldName+"[") {
+			return true
+		}
+	}
+	return false
+}
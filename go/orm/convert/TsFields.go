@@ -12,7 +12,7 @@ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 See the License for the specific language governing permissions and
 limitations under the License.
 */
-package postgres
+package convert
 
 import (
 	"reflect"
@@ -20,10 +20,24 @@ import (
 
 	"github.com/saichler/l8orm/go/orm/common"
 	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
 	"github.com/saichler/l8types/go/types/l8reflect"
 )
 
-func (this *Postgres) populateTsFields(result ifs.IElements, resources ifs.IResources) ifs.IElements {
+// TsLatestBatch returns the most recent points of many time series properties,
+// ordered chronologically and grouped by property ID.
+type TsLatestBatch func(propertyIds []string, limit int) (map[string][]*l8api.L8TimeSeriesPoint, error)
+
+// tsField is a time series field of a read element, with its property ID.
+type tsField struct {
+	field      reflect.Value
+	propertyId string
+}
+
+// PopulateTsFields fills the time series slice fields of read elements with the
+// latest points of each property, read with a single batch query. Property IDs
+// use the same "<type><key>.<attribute>" format that ConvertTo emits into TsData.
+func PopulateTsFields(result ifs.IElements, resources ifs.IResources, latest TsLatestBatch) ifs.IElements {
 	if result == nil || result.Error() != nil {
 		return result
 	}
@@ -42,11 +56,13 @@ func (this *Postgres) populateTsFields(result ifs.IElements, resources ifs.IReso
 		return result
 	}
 
-	tsAttrs := collectTsAttrs(node)
+	tsAttrs := CollectTsAttrs(node)
 	if len(tsAttrs) == 0 {
 		return result
 	}
 
+	var fields []tsField
+	var propertyIds []string
 	for _, elem := range elements {
 		if elem == nil {
 			continue
@@ -66,26 +82,39 @@ func (this *Postgres) populateTsFields(result ifs.IElements, resources ifs.IReso
 		prefix := strings.ToLower(typeName) + "<" + key + ">"
 
 		for attrName := range tsAttrs {
-			propertyId := prefix + "." + strings.ToLower(attrName)
-			points, err := this.tsdb.GetTSDBLatest(propertyId, 100)
-			if err != nil || len(points) == 0 {
-				continue
-			}
 			field := v.FieldByName(attrName)
 			if !field.IsValid() || !field.CanSet() {
 				continue
 			}
-			slice := reflect.MakeSlice(field.Type(), len(points), len(points))
-			for i, p := range points {
-				slice.Index(i).Set(reflect.ValueOf(p))
-			}
-			field.Set(slice)
+			propertyId := prefix + "." + strings.ToLower(attrName)
+			fields = append(fields, tsField{field: field, propertyId: propertyId})
+			propertyIds = append(propertyIds, propertyId)
+		}
+	}
+	if len(propertyIds) == 0 {
+		return result
+	}
+
+	points, err := latest(propertyIds, 100)
+	if err != nil {
+		return result
+	}
+	for _, f := range fields {
+		series := points[f.propertyId]
+		if len(series) == 0 {
+			continue
+		}
+		slice := reflect.MakeSlice(f.field.Type(), len(series), len(series))
+		for i, p := range series {
+			slice.Index(i).Set(reflect.ValueOf(p))
 		}
+		f.field.Set(slice)
 	}
 	return result
 }
 
-func collectTsAttrs(node *l8reflect.L8Node) map[string]*l8reflect.L8Node {
+// CollectTsAttrs returns the time series attributes of a node by attribute name.
+func CollectTsAttrs(node *l8reflect.L8Node) map[string]*l8reflect.L8Node {
 	tsAttrs := make(map[string]*l8reflect.L8Node)
 	for attrName, attrNode := range node.Attributes {
 		if attrNode.IsStruct && common.IsTimeSeriesType(attrNode.TypeName) {
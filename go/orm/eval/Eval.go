@@ -0,0 +1,448 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package eval evaluates L8Query criteria in process against the column values
+// of a single relational row. It follows the same rules as the SQL translation
+// in the stmt package: comparators on attributes of the evaluated type are
+// applied, comparators on attributes of its child tables match when any child
+// row at the attribute's path matches, string wildcards (*) match like SQL LIKE,
+// and conditions combine with AND binding tighter than OR.
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// Values maps the lowercased attribute names of a row to their decoded values.
+type Values map[string]interface{}
+
+// Children returns the decoded values of the child rows of the evaluated row
+// that are stored in the given table at the given field path, as computed by
+// common.NestedPath.
+type Children func(tableName string, path []string) ([]Values, error)
+
+// NewValues decodes serialized column values keyed by attribute name.
+func NewValues(columns map[string][]byte, registry ifs.IRegistry) (Values, error) {
+	values := make(Values, len(columns))
+	for name, data := range columns {
+		if len(data) == 0 {
+			continue
+		}
+		v, err := object.NewDecode(data, 0, registry).Get()
+		if err != nil {
+			return nil, err
+		}
+		values[strings.ToLower(name)] = v
+	}
+	return values, nil
+}
+
+// Expression evaluates a criteria expression against the values of a row of typeName.
+// It returns whether any condition applied to this type, the match result, and an
+// error when the expression uses an operator that cannot be evaluated in process.
+// A row matches an expression that has no applicable conditions. Comparators
+// on child tables are skipped; use NestedExpression to apply them.
+func Expression(exp ifs.IExpression, typeName string, values Values) (bool, bool, error) {
+	return NestedExpression(exp, typeName, values, nil)
+}
+
+// NestedExpression is Expression, also applying comparators on the attributes
+// of child tables of typeName to the child rows returned by children.
+func NestedExpression(exp ifs.IExpression, typeName string, values Values, children Children) (bool, bool, error) {
+	if isNil(exp) {
+		return false, true, nil
+	}
+	condOK, condMatch, err := condition(exp.Condition(), typeName, values, children)
+	if err != nil {
+		return false, false, err
+	}
+	nextOK, nextMatch, err := NestedExpression(exp.Next(), typeName, values, children)
+	if err != nil {
+		return false, false, err
+	}
+	if !condOK {
+		return nextOK, nextMatch, nil
+	}
+	if !nextOK {
+		return true, condMatch, nil
+	}
+	match, err := combine(exp.Operator(), condMatch, nextMatch)
+	return true, match, err
+}
+
+// Evaluable reports whether Expression gives the same result for a row of
+// typeName as the SQL translation of the expression. It holds when every
+// comparator compares attributes of typeName with =, != or <>, or orders
+// attributes that are not strings, whose SQL ordering depends on collation.
+// An empty expression is evaluable.
+func Evaluable(exp ifs.IExpression, typeName string) bool {
+	for ; !isNil(exp); exp = exp.Next() {
+		if !isNil(exp.Next()) {
+			if _, err := combine(exp.Operator(), true, true); err != nil {
+				return false
+			}
+		}
+		for cond := exp.Condition(); !isNil(cond); cond = cond.Next() {
+			if !evaluable(cond.Comparator(), typeName) {
+				return false
+			}
+			if !isNil(cond.Next()) {
+				if _, err := combine(cond.Operator(), true, true); err != nil {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// evaluable reports whether a single comparator is evaluable against a row of typeName.
+func evaluable(comp ifs.IComparator, typeName string) bool {
+	if isNil(comp) {
+		return true
+	}
+	props := 0
+	str := false
+	for _, prop := range []ifs.IProperty{comp.LeftProperty(), comp.RightProperty()} {
+		if isNil(prop) {
+			continue
+		}
+		if prop.Node().Parent.TypeName != typeName {
+			return false
+		}
+		props++
+		str = str || prop.IsString()
+	}
+	if props == 0 {
+		return false
+	}
+	switch normalize(comp.Operator()) {
+	case "=", "!=", "<>":
+		return true
+	case "<", "<=", ">", ">=":
+		return !str
+	}
+	return false
+}
+
+// condition evaluates a chain of comparators. AND binds tighter than OR,
+// matching the SQL the chain is translated to.
+func condition(cond ifs.ICondition, typeName string, values Values, children Children) (bool, bool, error) {
+	present := false
+	result := false
+	group := true
+	for !isNil(cond) {
+		ok, match, err := comparator(cond.Comparator(), typeName, values, children)
+		if err != nil {
+			return false, false, err
+		}
+		if ok {
+			present = true
+			group = group && match
+		}
+		if isNil(cond.Next()) {
+			break
+		}
+		op := normalize(cond.Operator())
+		switch op {
+		case "and":
+		case "or":
+			result = result || group
+			group = true
+		default:
+			return false, false, errors.New("unsupported logical operator " + cond.Operator())
+		}
+		cond = cond.Next()
+	}
+	return present, result || group, nil
+}
+
+// combine applies a logical operator to two results.
+func combine(operator string, a, b bool) (bool, error) {
+	switch normalize(operator) {
+	case "and":
+		return a && b, nil
+	case "or":
+		return a || b, nil
+	}
+	return false, errors.New("unsupported logical operator " + operator)
+}
+
+// comparator evaluates a single comparison. Comparators on properties of child
+// tables are evaluated against the child rows when children is set; those on
+// properties of other types report false so they are skipped by the caller.
+func comparator(comp ifs.IComparator, typeName string, values Values, children Children) (bool, bool, error) {
+	if isNil(comp) {
+		return false, false, nil
+	}
+	leftProp := !isNil(comp.LeftProperty()) && comp.LeftProperty().Node().Parent.TypeName == typeName
+	rightProp := !isNil(comp.RightProperty()) && comp.RightProperty().Node().Parent.TypeName == typeName
+	if !leftProp && !rightProp {
+		// Comparators on names that are not properties (e.g. aggregate aliases
+		// in a HAVING clause) are resolved directly against the values.
+		if isNil(comp.LeftProperty()) && isNil(comp.RightProperty()) {
+			if value, ok := values[strings.ToLower(comp.Left())]; ok {
+				match, err := Compare(value, normalize(comp.Operator()), stripQuotes(comp.Right()))
+				return true, match, err
+			}
+		}
+		return nested(comp, typeName, children)
+	}
+
+	op := normalize(comp.Operator())
+	switch {
+	case leftProp && rightProp:
+		left := values[strings.ToLower(comp.LeftProperty().Node().FieldName)]
+		right := values[strings.ToLower(comp.RightProperty().Node().FieldName)]
+		match, err := Compare(left, op, fmt.Sprint(right))
+		return true, match, err
+	case leftProp:
+		value := values[strings.ToLower(comp.LeftProperty().Node().FieldName)]
+		match, err := Compare(value, op, stripQuotes(comp.Right()))
+		return true, match, err
+	default:
+		value := values[strings.ToLower(comp.RightProperty().Node().FieldName)]
+		match, err := Compare(value, flip(op), stripQuotes(comp.Left()))
+		return true, match, err
+	}
+}
+
+// nested evaluates a comparator whose properties are all in one child table of
+// typeName, matching when any of the child rows at the property's path matches.
+func nested(comp ifs.IComparator, typeName string, children Children) (bool, bool, error) {
+	prop, other := comp.LeftProperty(), comp.RightProperty()
+	if isNil(prop) {
+		prop, other = other, prop
+	}
+	if children == nil || isNil(prop) {
+		return false, false, nil
+	}
+	path, ok := common.NestedPath(prop.Node(), typeName)
+	if !ok {
+		return false, false, nil
+	}
+	tableName := prop.Node().Parent.TypeName
+	if !isNil(other) && other.Node().Parent.TypeName != tableName {
+		return false, false, nil
+	}
+	rows, err := children(tableName, path)
+	if err != nil {
+		return false, false, err
+	}
+	for _, row := range rows {
+		_, match, err := comparator(comp, tableName, row, nil)
+		if err != nil || match {
+			return true, match, err
+		}
+	}
+	return true, false, nil
+}
+
+// Compare compares a decoded column value with a literal using a comparison operator.
+// The literal is converted to the kind of the value; string literals containing
+// a wildcard (*) are matched as patterns for = and !=.
+func Compare(value interface{}, operator, literal string) (bool, error) {
+	op := normalize(operator)
+	if s, ok := value.(string); ok && strings.Contains(literal, "*") {
+		matched := wildcardMatch(literal, s)
+		switch op {
+		case "=":
+			return matched, nil
+		case "!=", "<>":
+			return !matched, nil
+		}
+	}
+	cmp, err := CompareLiteral(value, literal)
+	if err != nil {
+		return false, err
+	}
+	switch op {
+	case "=":
+		return cmp == 0, nil
+	case "!=", "<>":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+	return false, errors.New("unsupported comparison operator " + operator)
+}
+
+// CompareLiteral returns -1, 0 or 1 as the value is less than, equal to or
+// greater than the literal converted to the value's kind. A missing value
+// compares as the zero value of an empty string.
+func CompareLiteral(value interface{}, literal string) (int, error) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return strings.Compare("", literal), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strings.Compare(v.String(), literal), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compareFloat(float64(v.Int()), f), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compareFloat(float64(v.Uint()), f), nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compareFloat(v.Float(), f), nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(literal)
+		if err != nil {
+			return 0, err
+		}
+		if v.Bool() == b {
+			return 0, nil
+		}
+		if !v.Bool() {
+			return -1, nil
+		}
+		return 1, nil
+	}
+	return 0, errors.New("cannot compare values of kind " + v.Kind().String())
+}
+
+// CompareValues orders two decoded column values of the same kind.
+// It is used for in-process sorting; values of different kinds compare by
+// their string representation.
+func CompareValues(a, b interface{}) int {
+	va := reflect.ValueOf(a)
+	vb := reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() {
+		switch {
+		case !va.IsValid() && !vb.IsValid():
+			return 0
+		case !va.IsValid():
+			return -1
+		}
+		return 1
+	}
+	if va.Kind() == vb.Kind() {
+		switch va.Kind() {
+		case reflect.String:
+			return strings.Compare(va.String(), vb.String())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return compareFloat(float64(va.Int()), float64(vb.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return compareFloat(float64(va.Uint()), float64(vb.Uint()))
+		case reflect.Float32, reflect.Float64:
+			return compareFloat(va.Float(), vb.Float())
+		case reflect.Bool:
+			if va.Bool() == vb.Bool() {
+				return 0
+			}
+			if !va.Bool() {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// wildcardMatch matches s against a pattern where * matches any sequence.
+func wildcardMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := len(parts) - 1
+	for i := 1; i < last; i++ {
+		index := strings.Index(s, parts[i])
+		if index == -1 {
+			return false
+		}
+		s = s[index+len(parts[i]):]
+	}
+	return strings.HasSuffix(s, parts[last])
+}
+
+// flip mirrors a comparison operator so "literal op value" becomes "value op literal".
+func flip(op string) string {
+	switch op {
+	case "<":
+		return ">"
+	case "<=":
+		return ">="
+	case ">":
+		return "<"
+	case ">=":
+		return "<="
+	}
+	return op
+}
+
+func normalize(op string) string {
+	return strings.ToLower(strings.TrimSpace(op))
+}
+
+// stripQuotes removes surrounding single or double quotes from a string.
+func stripQuotes(s string) string {
+	if len(s) >= 2 {
+		if (s[0] == '\'' && s[len(s)-1] == '\'') ||
+			(s[0] == '"' && s[len(s)-1] == '"') {
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
+
+// isNil checks if an interface value is nil, including nil interface values.
+func isNil(any interface{}) bool {
+	if any == nil {
+		return true
+	}
+	v := reflect.ValueOf(any)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package persist
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// maxBroadcastKeys is the number of changed elements above which a change
+// broadcast asks the other replicas to refresh the whole type.
+const maxBroadcastKeys = 1000
+
+// publishChanges tells the other replicas of the service that the given
+// elements were written or deleted, so they refresh them in their cache and
+// in the query cache of their ORM plugin. A nil list, or one longer than
+// maxBroadcastKeys, asks them to refresh the whole type. Nothing is published
+// when the service caches nothing, as the replicas have nothing to refresh.
+// The change carries the root rows of the elements as L8OrmRData marked as an
+// invalidation, and is sent as a GET so the mesh does not run it through the
+// service's transactions.
+func (this *OrmService) publishChanges(elements []interface{}, vnic ifs.IVNic) {
+	atomic.AddInt64(&this.changes, 1)
+	if !this.caching() {
+		return
+	}
+	if elements != nil {
+		changed := make([]interface{}, 0, len(elements))
+		for _, elem := range elements {
+			if elem != nil {
+				changed = append(changed, elem)
+			}
+		}
+		if len(changed) == 0 {
+			return
+		}
+		elements = changed
+	}
+	data := &l8orms.L8OrmRData{RootTypeName: reflect.TypeOf(this.sla.ServiceItem()).Elem().Name(), Invalidation: true}
+	if elements != nil && len(elements) <= maxBroadcastKeys {
+		relData := convert.ConvertTo(ifs.POST, object.New(nil, elements), vnic.Resources())
+		if relData.Error() == nil {
+			converted := relData.Element().(*l8orms.L8OrmRData)
+			if root, ok := converted.Tables[converted.RootTypeName]; ok {
+				data.Tables = map[string]*l8orms.L8OrmTable{converted.RootTypeName: root}
+			}
+		}
+	}
+	err := vnic.Multicast(this.sla.ServiceName(), this.sla.ServiceArea(), ifs.GET, data)
+	if err != nil {
+		vnic.Resources().Logger().Error("OrmService change broadcast failed for ",
+			this.sla.ServiceName(), " area ", this.sla.ServiceArea(), ": ", err.Error())
+	}
+}
+
+// caching reports whether the service caches elements, in its cache or in the
+// query cache of its ORM plugin.
+func (this *OrmService) caching() bool {
+	_, ok := this.orm.(common.IQueryCache)
+	return this.cache != nil || ok
+}
+
+// applyChanges refreshes the elements that another replica published as
+// changed, in the query cache of the ORM plugin and in the service cache, and
+// forgets their keys if they were recently found missing.
+// Changes without root rows refresh the whole type, in the background so the
+// GET handler delivering the change is not held by a full reload.
+func (this *OrmService) applyChanges(ctx context.Context, data *l8orms.L8OrmRData, vnic ifs.IVNic) {
+	atomic.AddInt64(&this.changes, 1)
+	var recKeys []string
+	if len(data.Tables) > 0 {
+		recKeys = convert.RootKeys(data)
+	}
+	if qc, ok := this.orm.(common.IQueryCache); ok {
+		qc.RefreshQueries(data.RootTypeName, recKeys)
+	}
+	if this.cache == nil {
+		return
+	}
+	if recKeys == nil {
+		this.missing.clear()
+		this.writes.addAll()
+		this.requestReload(vnic)
+		return
+	}
+	elements := convert.ConvertFrom(object.New(nil, data), nil, vnic.Resources())
+	if elements.Error() != nil {
+		this.missing.clear()
+		this.writes.addAll()
+		this.requestReload(vnic)
+		return
+	}
+	this.forgetMissing(elements, vnic)
+	this.markWritten(elements, vnic)
+	for _, elem := range elements.Elements() {
+		if elem != nil {
+			this.cacheSync(ctx, elem, vnic)
+		}
+	}
+}
+
+// cacheSync reloads an element from the database into the cache, or removes
+// it from the cache when it is no longer stored or cannot be read.
+func (this *OrmService) cacheSync(ctx context.Context, elem interface{}, vnic ifs.IVNic) {
+	q, err := ElementToQuery(object.New(nil, elem), this.sla.ServiceItem(), vnic)
+	if err != nil {
+		return
+	}
+	result := this.orm.ReadContext(ctx, q, vnic.Resources())
+	if result != nil && result.Error() == nil {
+		for _, stored := range result.Elements() {
+			if stored != nil {
+				this.cachePost(stored)
+				return
+			}
+		}
+	}
+	if err = this.cacheDelete(elem); err != nil {
+		vnic.Resources().Logger().Error("OrmService cache delete failed for ",
+			this.sla.ServiceName(), " area ", this.sla.ServiceArea(), ": ", err.Error())
+	}
+}
+
+// requestReload reloads the cache in the background. Requests arriving while a
+// reload runs are coalesced into one more reload after it, so a burst of
+// whole type changes costs at most two reloads. A reload that saw elements
+// change is repeated, up to maxLoadPasses times. Queries are answered from the
+// database until no reload is pending. Deactivation stops the reload and
+// waits for it, like the warm-up.
+func (this *OrmService) requestReload(vnic ifs.IVNic) {
+	if atomic.AddInt32(&this.reloads, 1) > 1 {
+		return
+	}
+	this.goLoad(func() {
+		for pass := 1; ; pass++ {
+			pending := atomic.LoadInt32(&this.reloads)
+			err := this.cacheReload(vnic)
+			if err == errWarmStopped {
+				return
+			}
+			if err == errCacheChanged && pass < maxLoadPasses {
+				continue
+			}
+			if atomic.AddInt32(&this.reloads, -pending) == 0 {
+				return
+			}
+		}
+	})
+}
+
+// cacheReload caches the stored elements selected by the warm-up, page by
+// page, and removes the cached elements that were not read. The removal is
+// skipped when the load did not read every page, because it failed, the
+// service was deactivated or elements changed during the load, as the unread
+// elements may still be stored; queries are then answered from the database
+// until a later load completes.
+func (this *OrmService) cacheReload(vnic ifs.IVNic) error {
+	q, err := this.selectAll(vnic)
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]bool)
+	err = this.loadPages(vnic, func(key string, elem interface{}) {
+		stored[key] = true
+		this.cacheLoad(key, elem, false)
+	})
+	if err != nil {
+		atomic.StoreInt32(&this.warmDone, 0)
+		return err
+	}
+	cached, _ := this.cache.Fetch(0, this.cache.Size(), q)
+	for _, elem := range cached {
+		if elem != nil && !stored[KeyOf(object.New(nil, elem), vnic.Resources())] {
+			this.cacheDelete(elem)
+		}
+	}
+	atomic.StoreInt32(&this.warmDone, 1)
+	return nil
+}
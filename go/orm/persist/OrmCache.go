@@ -15,9 +15,12 @@ limitations under the License.
 package persist
 
 import (
+	"context"
+
 	"github.com/saichler/l8srlz/go/serialize/object"
 	"github.com/saichler/l8types/go/ifs"
 	"github.com/saichler/l8types/go/types/l8api"
+	"google.golang.org/protobuf/proto"
 	"reflect"
 )
 
@@ -102,49 +105,42 @@ func (this *OrmService) cacheElements(elements ifs.IElements) {
 	}
 }
 
-// fetchFromDbAndCache reads from the database and caches each result element.
-// Returns the IElements result from the DB read.
-func (this *OrmService) fetchFromDbAndCache(query ifs.IQuery, resources ifs.IResources) ifs.IElements {
-	result := this.orm.Read(query, resources)
+// fetchFromDbAndCache reads from the database, bounded by ctx, and caches each
+// result element. Returns the IElements result from the DB read.
+func (this *OrmService) fetchFromDbAndCache(ctx context.Context, query ifs.IQuery, resources ifs.IResources) ifs.IElements {
+	result := this.orm.ReadContext(ctx, query, resources)
 	this.cacheElements(result)
 	return result
 }
 
-// loadCacheInitElements reads all records from the database for cache initialization.
-// Returns the elements as []interface{} to pass to NewCache's initElements parameter.
-// If the tables don't exist yet, returns nil so the cache starts empty.
-func (this *OrmService) loadCacheInitElements(vnic ifs.IVNic) []interface{} {
+// selectAll returns the query selecting all the elements of the service item type.
+func (this *OrmService) selectAll(vnic ifs.IVNic) (ifs.IQuery, error) {
 	typeName := reflect.TypeOf(this.sla.ServiceItem()).Elem().Name()
 	qe, err := object.NewQuery("select * from "+typeName, vnic.Resources())
 	if err != nil {
-		return nil
-	}
-	q, err := qe.Query(vnic.Resources())
-	if err != nil {
-		return nil
-	}
-	result := this.orm.Read(q, vnic.Resources())
-	if result == nil || result.Error() != nil {
-		return nil
+		return nil, err
 	}
-	elements := result.Elements()
-	filtered := make([]interface{}, 0, len(elements))
-	for _, elem := range elements {
-		if elem != nil {
-			filtered = append(filtered, elem)
-		}
-	}
-	return filtered
+	return qe.Query(vnic.Resources())
 }
 
-// cacheMetadata returns the cache metadata (counts) or nil if cache is nil.
-func (this *OrmService) cacheMetadata() *l8api.L8MetaData {
-	if this.cache == nil {
-		return nil
+// withCacheCounts returns a query result with the warm-up progress added to
+// its metadata counts, or the result as is when the cache is disabled. The
+// counts are added to a copy of the metadata, which the ORM plugin may keep in
+// its query cache, so the rest of the metadata is returned as is.
+func (this *OrmService) withCacheCounts(result ifs.IElements) ifs.IElements {
+	if this.cache == nil || result == nil || result.Error() != nil {
+		return result
 	}
-	counts := this.cache.Metadata()
 	metadata := &l8api.L8MetaData{}
-	metadata.KeyCount = &l8api.L8Count{}
-	metadata.KeyCount.Counts = counts
-	return metadata
+	if result.Metadata() != nil {
+		metadata = proto.Clone(result.Metadata()).(*l8api.L8MetaData)
+	}
+	if metadata.KeyCount == nil {
+		metadata.KeyCount = &l8api.L8Count{}
+	}
+	if metadata.KeyCount.Counts == nil {
+		metadata.KeyCount.Counts = make(map[string]float64)
+	}
+	this.warmUpCounts(metadata.KeyCount.Counts)
+	return object.NewQueryResult(result.Elements(), metadata)
 }
@@ -15,6 +15,9 @@ limitations under the License.
 package persist
 
 import (
+	"context"
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/convert"
 	"github.com/saichler/l8srlz/go/serialize/object"
 	"github.com/saichler/l8types/go/ifs"
 	"github.com/saichler/l8types/go/types/l8web"
@@ -22,9 +25,26 @@ import (
 )
 
 // do executes a database write operation (POST, PUT, PATCH) with callback support.
-// It follows the pattern: Before callbacks -> Cache update -> ORM write -> After callbacks.
-// Returns an empty response on success, or an error response on failure.
+// It follows the pattern: Before callbacks -> ORM write -> Cache update ->
+// change broadcast to the other replicas -> After callbacks.
+// The cache only takes the elements once the database accepted them; on a
+// failed write, the written elements are reloaded from the database, so the
+// cache matches the rows of a partially committed write.
+// Returns an empty response on success, or an error response on failure. A
+// stale version fails with the text of a VersionConflictError, which callers
+// recognize with common.IsVersionConflict.
+// A field mask carried by PATCH elements is kept across list unwrapping and
+// Before callbacks; a PATCH made with convert.NewFieldMaskRequest, which
+// carries its mask over the network, is turned back into masked elements. The database calls are bounded by the request deadline; a
+// write that runs past it fails with the text of a TimeoutError.
 func (this *OrmService) do(action ifs.Action, pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	if action == ifs.PATCH {
+		pb = convert.FieldMaskElements(pb, vnic.Resources())
+		if pb.Error() != nil {
+			return pb
+		}
+	}
+	mask := convert.FieldMaskOf(pb)
 	pb = elemList(pb)
 	pbBefore, cont := this.Before(action, pb, vnic)
 	if !cont {
@@ -36,15 +56,28 @@ func (this *OrmService) do(action ifs.Action, pb ifs.IElements, vnic ifs.IVNic)
 		}
 		pb = pbBefore
 	}
+	if mask != nil && convert.FieldMaskOf(pb) == nil {
+		pb = convert.NewFieldMask(pb, mask...)
+	}
 
-	// Cache elements before writing to DB
-	this.cacheAction(action, pb, vnic)
+	ctx, cancel := this.requestContext()
+	defer cancel()
 
-	err := this.orm.Write(action, pb, vnic.Resources())
+	err := this.timeoutError(ctx, "Write", this.orm.WriteContext(ctx, action, pb, vnic.Resources()))
+	this.forgetMissing(pb, vnic)
+	this.markWritten(pb, vnic)
 
 	if err != nil {
+		this.cacheRestore(pb, vnic)
 		return object.NewError(err.Error())
 	}
+
+	// Cache elements once the DB accepted them
+	this.cacheAction(ctx, action, pb, vnic)
+	if (action == ifs.PATCH && mask != nil) || this.versioned() {
+		this.cacheRefresh(ctx, pb, vnic)
+	}
+	this.publishChanges(pb.Elements(), vnic)
 	pbAfter, cont := this.After(action, pb, vnic)
 	if !cont {
 
@@ -58,7 +91,7 @@ func (this *OrmService) do(action ifs.Action, pb ifs.IElements, vnic ifs.IVNic)
 
 // cacheAction updates the cache based on the action type.
 // For POST/PUT, caches each element. For PATCH, applies partial updates.
-func (this *OrmService) cacheAction(action ifs.Action, pb ifs.IElements, vnic ifs.IVNic) {
+func (this *OrmService) cacheAction(ctx context.Context, action ifs.Action, pb ifs.IElements, vnic ifs.IVNic) {
 	if this.cache == nil {
 		return
 	}
@@ -70,12 +103,17 @@ func (this *OrmService) cacheAction(action ifs.Action, pb ifs.IElements, vnic if
 		case ifs.POST, ifs.PUT:
 			this.cachePost(elem)
 		case ifs.PATCH:
+			// A masked patch may write zero values, which the cache patch
+			// ignores, so the element is refreshed from the DB after the write
+			if convert.FieldMaskOf(pb) != nil {
+				continue
+			}
 			// For patch, ensure the element exists in cache first
 			if _, ok := this.cacheGet(elem); !ok {
 				// Cache miss — fetch from DB to populate cache before patching
 				q, e := ElementToQuery(pb, this.sla.ServiceItem(), vnic)
 				if e == nil {
-					result := this.orm.Read(q, vnic.Resources())
+					result := this.orm.ReadContext(ctx, q, vnic.Resources())
 					this.cacheElements(result)
 				}
 			}
@@ -84,6 +122,61 @@ func (this *OrmService) cacheAction(action ifs.Action, pb ifs.IElements, vnic if
 	}
 }
 
+// versioned reports whether the ORM increments a version on each write, in
+// which case cached elements must be reloaded to carry the new version.
+func (this *OrmService) versioned() bool {
+	v, ok := this.orm.(common.IVersioned)
+	return ok && v.VersionField() != ""
+}
+
+// cacheRestore brings the cached elements of a failed write back in line with
+// the database, under a new request deadline, as the failed one may have
+// expired. Elements that are stored are cached, elements that are not are
+// removed from the cache, and elements that cannot be read are left as cached.
+func (this *OrmService) cacheRestore(pb ifs.IElements, vnic ifs.IVNic) {
+	if this.cache == nil {
+		return
+	}
+	ctx, cancel := this.requestContext()
+	defer cancel()
+	for _, elem := range pb.Elements() {
+		if elem == nil {
+			continue
+		}
+		q, e := ElementToQuery(object.New(nil, elem), this.sla.ServiceItem(), vnic)
+		if e != nil {
+			continue
+		}
+		result := this.orm.ReadContext(ctx, q, vnic.Resources())
+		if result == nil || result.Error() != nil {
+			continue
+		}
+		if hasElements(result) {
+			this.cacheElements(result)
+		} else if err := this.cacheDelete(elem); err != nil {
+			vnic.Resources().Logger().Error("OrmService cache restore failed for ",
+				this.sla.ServiceName(), " area ", this.sla.ServiceArea(), ": ", err.Error())
+		}
+	}
+}
+
+// cacheRefresh reloads the written elements from the database into the cache.
+func (this *OrmService) cacheRefresh(ctx context.Context, pb ifs.IElements, vnic ifs.IVNic) {
+	if this.cache == nil {
+		return
+	}
+	for _, elem := range pb.Elements() {
+		if elem == nil {
+			continue
+		}
+		q, e := ElementToQuery(object.New(nil, elem), this.sla.ServiceItem(), vnic)
+		if e != nil {
+			continue
+		}
+		this.fetchFromDbAndCache(ctx, q, vnic.Resources())
+	}
+}
+
 func elemList(pb ifs.IElements) ifs.IElements {
 	if len(pb.Elements()) == 1 {
 		v := reflect.ValueOf(pb.Element())
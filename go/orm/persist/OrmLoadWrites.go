@@ -0,0 +1,119 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package persist
+
+import (
+	"sync"
+
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// loadWrites records the primary keys of the elements written while loads of
+// the cache run, so the loads do not cache the older versions their pages
+// may hold over the written ones.
+type loadWrites struct {
+	mtx   sync.Mutex
+	loads int             // Loads running
+	keys  map[string]bool // Keys written since the first running load began
+	all   bool            // Elements of unknown keys were written since
+}
+
+// begin records the start of a load.
+func (this *loadWrites) begin() {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.loads == 0 {
+		this.keys = make(map[string]bool)
+		this.all = false
+	}
+	this.loads++
+}
+
+// end records the end of a load, and reports whether elements of unknown keys
+// were written during it, in which case it may have skipped stored elements.
+func (this *loadWrites) end() bool {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	all := this.all
+	this.loads--
+	if this.loads == 0 {
+		this.keys = nil
+	}
+	return all
+}
+
+// add records the keys of written elements, to be called before the elements
+// are updated in the cache.
+func (this *loadWrites) add(keys ...string) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.keys == nil {
+		return
+	}
+	for _, key := range keys {
+		this.keys[key] = true
+	}
+}
+
+// addAll records a write of elements whose keys are not known, to be
+// called before the elements are updated in the cache.
+func (this *loadWrites) addAll() {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.loads > 0 {
+		this.all = true
+	}
+}
+
+// load runs cache, which caches an element of the given key read by a load,
+// unless the element was written since the load began.
+func (this *loadWrites) load(key string, cache func()) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.all || this.keys[key] {
+		return
+	}
+	cache()
+}
+
+// cacheLoad caches an element read by a load of the cache, unless it was
+// written since the load began, as the page may hold an older version of it.
+// With ifAbsent, an element already cached, by a write or a read since
+// activation, is also kept.
+func (this *OrmService) cacheLoad(key string, elem interface{}, ifAbsent bool) {
+	this.writes.load(key, func() {
+		if ifAbsent {
+			if _, ok := this.cacheGet(elem); ok {
+				return
+			}
+		}
+		this.cachePost(elem)
+	})
+}
+
+// markWritten records the written elements for the running loads of the cache.
+func (this *OrmService) markWritten(pb ifs.IElements, vnic ifs.IVNic) {
+	if this.cache == nil {
+		return
+	}
+	keys := make([]string, 0, len(pb.Elements()))
+	for _, elem := range pb.Elements() {
+		if elem != nil {
+			keys = append(keys, KeyOf(object.New(nil, elem), vnic.Resources()))
+		}
+	}
+	this.writes.add(keys...)
+}
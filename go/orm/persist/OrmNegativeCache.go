@@ -0,0 +1,142 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package persist
+
+import (
+	"sync"
+	"time"
+
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// DefaultNegativeTTL is how long a service with cache enabled remembers that a
+// primary key lookup found no element, answering lookups of that key without
+// reading the database.
+const DefaultNegativeTTL = 5 * time.Second
+
+// maxNegativeKeys bounds the number of remembered missing keys.
+const maxNegativeKeys = 10000
+
+// negativeCache remembers the primary keys that were not found, for a short time.
+// A nil negativeCache remembers nothing.
+type negativeCache struct {
+	mtx     sync.Mutex
+	ttl     time.Duration
+	missing map[string]time.Time // Key -> time the entry expires
+	writes  uint64               // Incremented on each remove or clear
+}
+
+// newNegativeCache creates a negative cache whose entries expire after ttl.
+func newNegativeCache(ttl time.Duration) *negativeCache {
+	return &negativeCache{ttl: ttl, missing: make(map[string]time.Time)}
+}
+
+// has reports whether the key was recently found missing.
+func (this *negativeCache) has(key string) bool {
+	if this == nil || key == "" {
+		return false
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	expires, ok := this.missing[key]
+	if ok && time.Now().After(expires) {
+		delete(this.missing, key)
+		return false
+	}
+	return ok
+}
+
+// generation returns the number of writes seen so far, to be taken before
+// the read whose miss is passed to add.
+func (this *negativeCache) generation() uint64 {
+	if this == nil {
+		return 0
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.writes
+}
+
+// add remembers that the key was found missing by a read that started at the
+// given generation. The miss is ignored when a write happened since, as the
+// element may have been stored after the read. When the cache is full, the
+// expired entries are dropped, and all of them when none has expired.
+func (this *negativeCache) add(key string, generation uint64) {
+	if this == nil || key == "" {
+		return
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.writes != generation {
+		return
+	}
+	now := time.Now()
+	if len(this.missing) >= maxNegativeKeys {
+		for k, expires := range this.missing {
+			if now.After(expires) {
+				delete(this.missing, k)
+			}
+		}
+		if len(this.missing) >= maxNegativeKeys {
+			this.missing = make(map[string]time.Time)
+		}
+	}
+	this.missing[key] = now.Add(this.ttl)
+}
+
+// remove forgets the key, after an element with it was written.
+func (this *negativeCache) remove(key string) {
+	if this == nil {
+		return
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.writes++
+	delete(this.missing, key)
+}
+
+// clear forgets all the keys, after writes whose keys are not known.
+func (this *negativeCache) clear() {
+	if this == nil {
+		return
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.writes++
+	this.missing = make(map[string]time.Time)
+}
+
+// forgetMissing removes the keys of written elements from the negative cache.
+func (this *OrmService) forgetMissing(pb ifs.IElements, vnic ifs.IVNic) {
+	if this.missing == nil {
+		return
+	}
+	for _, elem := range pb.Elements() {
+		if elem != nil {
+			this.missing.remove(KeyOf(object.New(nil, elem), vnic.Resources()))
+		}
+	}
+}
+
+// hasElements reports whether a read result holds at least one element.
+func hasElements(result ifs.IElements) bool {
+	for _, elem := range result.Elements() {
+		if elem != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -19,10 +19,15 @@ limitations under the License.
 package persist
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/saichler/l8orm/go/orm/common"
 	"github.com/saichler/l8orm/go/types/l8orms"
 	"github.com/saichler/l8srlz/go/serialize/object"
 	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8web"
 	"github.com/saichler/l8utils/go/utils/cache"
 )
 
@@ -30,10 +35,30 @@ import (
 // It handles service lifecycle, request routing, and transaction management
 // for database operations exposed through the service mesh.
 type OrmService struct {
-	orm   common.IORM                // The underlying ORM implementation
-	tsdb  common.ITSDB               // Time series database plugin
-	sla   *ifs.ServiceLevelAgreement // Service configuration and metadata
-	cache *cache.Cache               // Optional in-memory cache layer
+	orm     common.IORM                // The underlying ORM implementation
+	tsdb    common.ITSDB               // Time series database plugin
+	sla     *ifs.ServiceLevelAgreement // Service configuration and metadata
+	cache   *cache.Cache               // Optional in-memory cache layer
+	timeout time.Duration              // Deadline of a request's database calls, 0 for none
+	missing *negativeCache             // Primary keys recently not found, when cache is enabled
+
+	warmUp     WarmUp         // Paged background loading of the cache
+	warmStop   chan struct{}  // Closed on deactivation to stop the loads of the cache
+	stopMtx    sync.Mutex     // Orders the start of background loads with their stop
+	loaders    sync.WaitGroup // Background loads of the cache, waited for on deactivation
+	writes     loadWrites     // Keys written while loads of the cache run
+	warmLoaded int64          // Elements loaded by the current warm-up pass, updated atomically
+	warmDone   int32          // 1 once a warm-up or reload pass loaded all its pages, updated atomically
+	reloads    int32          // Cache reloads requested and not done yet, updated atomically
+	changes    int64          // Writes and deletes done or published since activation, updated atomically
+}
+
+// Options configures an OrmService beyond its ORM.
+type Options struct {
+	Cache   bool          // Enables the in-memory cache layer with write-through semantics
+	WarmUp  WarmUp        // Loading of the cache, all the elements in default pages when zero
+	Timeout time.Duration // Deadline of a request's database calls, 0 for none
+	TSDB    common.ITSDB  // Time series database answering L8TSDBQuery, nil for none
 }
 
 // Activate registers an OrmService with the service mesh.
@@ -42,21 +67,57 @@ type OrmService struct {
 // Set enableCache to true to enable an in-memory cache layer with write-through semantics.
 func Activate(serviceName string, serviceArea byte, item, itemList interface{},
 	vnic ifs.IVNic, orm common.IORM, callback ifs.IServiceCallback, enableCache bool, keys ...string) {
+	ActivateWithOptions(serviceName, serviceArea, item, itemList, vnic, orm, callback,
+		Options{Cache: enableCache}, keys...)
+}
+
+// ActivateWithOptions is like Activate, with the cache, its warm-up, the
+// request deadline and the TSDB set by options.
+func ActivateWithOptions(serviceName string, serviceArea byte, item, itemList interface{},
+	vnic ifs.IVNic, orm common.IORM, callback ifs.IServiceCallback, options Options, keys ...string) {
 	sla := ifs.NewServiceLevelAgreement(&OrmService{}, serviceName, serviceArea, false, callback)
 	sla.SetServiceItem(item)
 	sla.SetServiceItemList(itemList)
 	sla.SetPrimaryKeys(keys...)
-	sla.SetArgs(orm, enableCache)
+	sla.SetArgs(orm, options)
 	vnic.Resources().Services().Activate(sla, vnic)
 }
 
+// optionsOf returns the options passed in the service args after the ORM. The
+// args are looked up by type, so an SLA built with SetArgs(orm, enableCache,
+// tsdb) or with any of Options, a WarmUp, which enables the cache, or a
+// time.Duration, the request deadline, in any order is also accepted.
+func optionsOf(args []interface{}) Options {
+	options := Options{}
+	for _, arg := range args {
+		switch value := arg.(type) {
+		case Options:
+			options = value
+		case bool:
+			options.Cache = value
+		case WarmUp:
+			options.Cache = true
+			options.WarmUp = value
+		case time.Duration:
+			options.Timeout = value
+		case common.ITSDB:
+			options.TSDB = value
+		}
+	}
+	return options
+}
+
 // Activate initializes the OrmService when registered with the service mesh.
 // It configures primary key and unique key decorators, and registers necessary types.
-// If enableCache was passed via Args, initializes the in-memory cache layer.
+// With the cache enabled by the Options passed via Args, initializes the
+// in-memory cache layer and starts its warm-up in the background.
 func (this *OrmService) Activate(sla *ifs.ServiceLevelAgreement, vnic ifs.IVNic) error {
 	vnic.Resources().Logger().Info("ORM Activated for ", sla.ServiceName(), " area ", sla.ServiceArea())
 	this.sla = sla
 	this.orm = this.sla.Args()[0].(common.IORM)
+	options := optionsOf(this.sla.Args()[1:])
+	this.timeout = options.Timeout
+	this.tsdb = options.TSDB
 	_, err := vnic.Resources().Registry().Register(&l8orms.L8OrmRData{})
 	if err != nil {
 		return err
@@ -82,30 +143,28 @@ func (this *OrmService) Activate(sla *ifs.ServiceLevelAgreement, vnic ifs.IVNic)
 		}
 	}
 
-	// Initialize cache if enabled
-	if len(this.sla.Args()) > 1 {
-		if enableCache, ok := this.sla.Args()[1].(bool); ok && enableCache {
-			initElements := this.loadCacheInitElements(vnic)
-			this.cache = cache.NewCache(sla.ServiceItem(), initElements, nil, vnic.Resources())
-			vnic.Resources().Logger().Info("Cache enabled for ", sla.ServiceName(), " with ", len(initElements), " elements")
-		}
-	}
-
-	// Initialize TSDB if provided as 3rd arg
-	if len(this.sla.Args()) > 2 {
-		if tsdb, ok := this.sla.Args()[2].(common.ITSDB); ok {
-			this.tsdb = tsdb
-		}
+	// Initialize cache if enabled, it is warmed up in the background
+	if options.Cache {
+		this.warmUp = warmUpConfig(options.WarmUp)
+		this.warmStop = make(chan struct{})
+		this.cache = cache.NewCache(sla.ServiceItem(), nil, nil, vnic.Resources())
+		this.missing = newNegativeCache(DefaultNegativeTTL)
+		this.goLoad(func() { this.warmCache(vnic) })
+		vnic.Resources().Logger().Info("Cache enabled for ", sla.ServiceName(), ", warming up in pages of ", this.warmUp.PageSize)
 	}
 
 	return nil
 }
 
 // DeActivate cleans up the OrmService, closing the cache, TSDB, and underlying database connection.
+// The background loads of the cache are stopped and waited for first, as they
+// use the cache and the ORM.
 func (this *OrmService) DeActivate() error {
 	if this.cache != nil {
+		this.stopLoads()
 		this.cache.Close()
 		this.cache = nil
+		this.missing = nil
 	}
 	if this.tsdb != nil {
 		this.tsdb.Close()
@@ -134,8 +193,14 @@ func (this *OrmService) Patch(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
 // Delete handles DELETE requests to remove records matching a query or filter.
 // Supports both query-based deletion and filter mode using an example object.
 // When cache is enabled, removes elements from cache in addition to the database.
+// A successful delete is published to the other replicas of the service; a
+// query delete asks them to refresh the whole type.
+// A delete that runs past the request deadline fails with a TimeoutError.
 func (this *OrmService) Delete(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	ctx, cancel := this.requestContext()
+	defer cancel()
 	if pb.IsFilterMode() {
+		this.markWritten(pb, vnic)
 		if err := this.cacheDelete(pb.Element()); err != nil {
 			vnic.Resources().Logger().Error("OrmService.Delete cache delete failed for ",
 				this.sla.ServiceName(), " area ", this.sla.ServiceArea(), ": ", err.Error())
@@ -145,8 +210,11 @@ func (this *OrmService) Delete(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
 		if e != nil {
 			return object.NewError(e.Error())
 		}
-		err := this.orm.Delete(q, vnic.Resources())
-		return object.New(err, nil)
+		err := this.orm.DeleteContext(ctx, q, vnic.Resources())
+		if err == nil {
+			this.publishChanges([]interface{}{pb.Element()}, vnic)
+		}
+		return object.New(this.timeoutError(ctx, "Delete", err), nil)
 	}
 
 	// This is a query
@@ -157,12 +225,14 @@ func (this *OrmService) Delete(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
 
 	// Fetch matching elements from cache before deleting from DB,
 	// so we can remove them from cache after a successful delete.
+	this.writes.addAll()
 	cached := this.cacheFetch(query)
 
-	err = this.orm.Delete(query, vnic.Resources())
+	err = this.orm.DeleteContext(ctx, query, vnic.Resources())
 	if err != nil {
-		return object.New(err, nil)
+		return object.New(this.timeoutError(ctx, "Delete", err), nil)
 	}
+	this.publishChanges(nil, vnic)
 
 	// Remove the matched elements from cache
 	if cached != nil {
@@ -181,23 +251,52 @@ func (this *OrmService) Delete(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
 
 // Get handles GET requests to retrieve records from the database.
 // Supports both query-based retrieval and filter mode using an example object.
-// When cache is enabled, checks cache first before falling back to the database.
+// When cache is enabled, checks cache first before falling back to the database;
+// queries only use the cache once its warm-up loaded all the elements, and
+// their results carry the warm-up progress in the metadata counts.
+// A primary key lookup that found no element is remembered for
+// DefaultNegativeTTL, answering the next lookups of the key with an empty
+// result until an element with the key is written.
+// Changes published by other replicas of the service also arrive as a GET, of
+// an L8OrmRData marked as an invalidation.
+// A read that runs past the request deadline fails with a TimeoutError.
 func (this *OrmService) Get(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	ctx, cancel := this.requestContext()
+	defer cancel()
+
+	if changes, ok := pb.Element().(*l8orms.L8OrmRData); ok && changes.Invalidation {
+		this.applyChanges(ctx, changes, vnic)
+		return object.New(nil, &l8web.L8Empty{})
+	}
 
 	if pb.IsFilterMode() {
 		// Try cache first for filter mode (primary key lookup)
 		if cached, ok := this.cacheGet(pb.Element()); ok {
 			return object.New(nil, cached)
 		}
+		key := ""
+		if this.missing != nil {
+			key = KeyOf(pb, vnic.Resources())
+			if this.missing.has(key) {
+				return object.NewQueryResult([]interface{}{}, nil)
+			}
+		}
+		generation := this.missing.generation()
 
 		q, e := ElementToQuery(pb, this.sla.ServiceItem(), vnic)
 		if e != nil {
 			return object.NewError(e.Error())
 		}
-		result := this.fetchFromDbAndCache(q, vnic.Resources())
+		result := this.fetchFromDbAndCache(ctx, q, vnic.Resources())
 		if result.Error() == nil {
+			if !hasElements(result) {
+				this.missing.add(key, generation)
+			}
 			return result
 		}
+		if common.IsTimeout(ctx.Err()) {
+			return this.timeoutResult(ctx, "Read", result)
+		}
 		return pb
 	}
 
@@ -212,11 +311,13 @@ func (this *OrmService) Get(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
 		return this.handleTsdbQuery(query)
 	}
 
-	if cached := this.cacheFetch(query); cached != nil {
-		return cached
+	if this.cacheComplete() {
+		if cached := this.cacheFetch(query); cached != nil {
+			return this.withCacheCounts(cached)
+		}
 	}
 
-	return this.fetchFromDbAndCache(query, vnic.Resources())
+	return this.withCacheCounts(this.timeoutResult(ctx, "Read", this.fetchFromDbAndCache(ctx, query, vnic.Resources())))
 }
 
 // GetCopy handles copy requests. Currently not implemented.
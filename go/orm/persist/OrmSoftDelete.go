@@ -0,0 +1,78 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package persist
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// ActivateSoftDelete is like Activate, but puts the service item type in soft
+// delete mode first: Delete keeps the rows as tombstones, reads skip them, and
+// they can be brought back with Restore or removed for good with Purge.
+// The ORM plugin must implement common.ISoftDelete.
+func ActivateSoftDelete(serviceName string, serviceArea byte, item, itemList interface{},
+	vnic ifs.IVNic, orm common.IORM, callback ifs.IServiceCallback, enableCache bool, keys ...string) {
+	sd, ok := orm.(common.ISoftDelete)
+	if ok {
+		sd.EnableSoftDelete(reflect.TypeOf(item).Elem().Name())
+	} else {
+		vnic.Resources().Logger().Error("ORM plugin does not support soft delete, ", serviceName, " deletes are permanent")
+	}
+	Activate(serviceName, serviceArea, item, itemList, vnic, orm, callback, enableCache, keys...)
+}
+
+// softDelete returns the ORM as ISoftDelete when the service item type is in
+// soft delete mode.
+func (this *OrmService) softDelete() (common.ISoftDelete, error) {
+	sd, ok := this.orm.(common.ISoftDelete)
+	if !ok || !sd.IsSoftDelete(reflect.TypeOf(this.sla.ServiceItem()).Elem().Name()) {
+		return nil, errors.New("soft delete is not enabled for " + this.sla.ServiceName())
+	}
+	return sd, nil
+}
+
+// Restore brings back the soft deleted elements matching the query, loads
+// them into the cache and asks the other replicas to refresh the type.
+func (this *OrmService) Restore(query ifs.IQuery, vnic ifs.IVNic) error {
+	sd, err := this.softDelete()
+	if err != nil {
+		return err
+	}
+	err = sd.Restore(query)
+	if err != nil {
+		return err
+	}
+	this.missing.clear()
+	if this.cache != nil {
+		this.fetchFromDbAndCache(context.Background(), query, vnic.Resources())
+	}
+	this.publishChanges(nil, vnic)
+	return nil
+}
+
+// Purge permanently removes the elements that were soft deleted age or more ago.
+func (this *OrmService) Purge(age time.Duration) error {
+	sd, err := this.softDelete()
+	if err != nil {
+		return err
+	}
+	return sd.Purge(reflect.TypeOf(this.sla.ServiceItem()).Elem().Name(), age)
+}
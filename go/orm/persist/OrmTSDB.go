@@ -16,8 +16,12 @@ package persist
 
 import (
 	"errors"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/saichler/l8orm/go/orm/common"
 	"github.com/saichler/l8srlz/go/serialize/object"
 	"github.com/saichler/l8types/go/ifs"
 	"github.com/saichler/l8types/go/types/l8api"
@@ -46,30 +50,103 @@ func (this *OrmService) GetTSDB(propertyId string, start, end int64) []*l8api.L8
 	return points
 }
 
+// GetTSDBBuckets retrieves time series data for a property within a time
+// range, aggregated with function per bucket of the given seconds.
+func (this *OrmService) GetTSDBBuckets(propertyId string, start, end, bucket int64, function string) []*l8api.L8TimeSeriesPoint {
+	if this.tsdb == nil {
+		return nil
+	}
+	points, err := this.tsdb.GetTSDBBuckets(propertyId, start, end, bucket, function)
+	if err != nil {
+		return nil
+	}
+	return points
+}
+
+// GetTSDBBatch retrieves time series data for many properties, or for the
+// properties matching patterns, within a time range, grouped by property ID
+// and aggregated with function per bucket when either is given.
+func (this *OrmService) GetTSDBBatch(propertyIds []string, start, end, bucket int64, function string) map[string][]*l8api.L8TimeSeriesPoint {
+	if this.tsdb == nil {
+		return nil
+	}
+	points, err := this.tsdb.GetTSDBBatch(propertyIds, start, end, bucket, function)
+	if err != nil {
+		return nil
+	}
+	return points
+}
+
 // isTsdbQuery checks if a parsed query targets the TSDB.
 func isTsdbQuery(query ifs.IQuery) bool {
 	return query.RootType().TypeName == tsdbQueryType
 }
 
-// handleTsdbQuery extracts propertyId, start, end from the query's
-// where clause and delegates to GetTSDB.
+// tsdbParams are the parameters of an L8TSDBQuery.
+type tsdbParams struct {
+	propertyIds []string
+	start       int64
+	end         int64
+	bucket      int64  // Bucket seconds, 0 for the raw points
+	function    string // Aggregate function of the buckets
+}
+
+// handleTsdbQuery extracts the property IDs, start, end and the optional
+// bucket and function from the query's where clause. A single property ID is
+// answered with its points, from GetTSDBBuckets when a bucket is given and
+// from GetTSDB otherwise. Several property IDs, or a pattern, are answered
+// with GetTSDBBatch as one L8TSDBNotification per point, ordered by property
+// ID, so each point carries its property.
 func (this *OrmService) handleTsdbQuery(query ifs.IQuery) ifs.IElements {
 	if this.tsdb == nil {
 		return object.NewError("TSDB is not configured")
 	}
-	propertyId, start, end, err := extractTsdbParams(query)
+	params, err := extractTsdbParams(query)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	if len(params.propertyIds) > 1 || strings.Contains(params.propertyIds[0], common.TsdbWildcard) {
+		return this.tsdbBatch(params)
+	}
+	if params.bucket == 0 && params.function == "" {
+		points := this.GetTSDB(params.propertyIds[0], params.start, params.end)
+		return object.New(nil, points)
+	}
+	points, err := this.tsdb.GetTSDBBuckets(params.propertyIds[0], params.start, params.end, params.bucket, params.function)
 	if err != nil {
 		return object.NewError(err.Error())
 	}
-	points := this.GetTSDB(propertyId, start, end)
 	return object.New(nil, points)
 }
 
+// tsdbBatch answers a TSDB query of several property IDs or patterns with the
+// points of every matching property, as notifications ordered by property ID.
+func (this *OrmService) tsdbBatch(params *tsdbParams) ifs.IElements {
+	points, err := this.tsdb.GetTSDBBatch(params.propertyIds, params.start, params.end, params.bucket, params.function)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	propertyIds := make([]string, 0, len(points))
+	for propertyId := range points {
+		propertyIds = append(propertyIds, propertyId)
+	}
+	sort.Strings(propertyIds)
+	notifications := make([]*l8notify.L8TSDBNotification, 0)
+	for _, propertyId := range propertyIds {
+		for _, point := range points[propertyId] {
+			notifications = append(notifications, &l8notify.L8TSDBNotification{PropertyId: propertyId, Point: point})
+		}
+	}
+	return object.New(nil, notifications)
+}
+
 // extractTsdbParams walks the query's criteria expression tree and extracts
-// the propertyId, tsdbstart, and tsdbend parameters from comparators.
-func extractTsdbParams(query ifs.IQuery) (string, int64, int64, error) {
-	var propertyId string
-	var start, end int64
+// the propertyId, tsdbstart, tsdbend, tsdbbucket and tsdbfunction parameters
+// from comparators. propertyId may be repeated and hold patterns, tsdbbucket
+// is a number of seconds or a duration such as "5m", and tsdbfunction one of
+// avg, min, max, last or count.
+func extractTsdbParams(query ifs.IQuery) (*tsdbParams, error) {
+	params := &tsdbParams{}
 
 	expr := query.Criteria()
 	for expr != nil {
@@ -81,11 +158,19 @@ func extractTsdbParams(query ifs.IQuery) (string, int64, int64, error) {
 				right := comp.Right()
 				switch left {
 				case "PropertyId":
-					propertyId = right
+					params.propertyIds = append(params.propertyIds, right)
 				case "Tsdbstart":
-					start = parseTimestamp(right)
+					params.start = parseTimestamp(right)
 				case "Tsdbend":
-					end = parseTimestamp(right)
+					params.end = parseTimestamp(right)
+				case "Tsdbbucket":
+					bucket, err := parseBucket(right)
+					if err != nil {
+						return nil, err
+					}
+					params.bucket = bucket
+				case "Tsdbfunction":
+					params.function = right
 				}
 			}
 			cond = cond.Next()
@@ -93,14 +178,14 @@ func extractTsdbParams(query ifs.IQuery) (string, int64, int64, error) {
 		expr = expr.Next()
 	}
 
-	if propertyId == "" {
-		return "", 0, 0, errors.New("propertyId is required for TSDB query")
+	if len(params.propertyIds) == 0 {
+		return nil, errors.New("propertyId is required for TSDB query")
 	}
-	if start == 0 || end == 0 {
-		return "", 0, 0, errors.New("tsdbstart and tsdbend are required for TSDB query")
+	if params.start == 0 || params.end == 0 {
+		return nil, errors.New("tsdbstart and tsdbend are required for TSDB query")
 	}
 
-	return propertyId, start, end, nil
+	return params, nil
 }
 
 // parseTimestamp converts a string timestamp value to int64.
@@ -108,3 +193,15 @@ func parseTimestamp(s string) int64 {
 	v, _ := strconv.ParseInt(s, 10, 64)
 	return v
 }
+
+// parseBucket converts a bucket, in seconds or as a duration, to seconds.
+func parseBucket(s string) (int64, error) {
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return v, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errors.New("invalid tsdbbucket " + s + ", expected seconds or a duration such as 5m")
+	}
+	return int64(d / time.Second), nil
+}
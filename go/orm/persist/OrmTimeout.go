@@ -0,0 +1,70 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package persist
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// ActivateWithTimeout is like Activate, but bounds the database calls of every
+// request by the given timeout; services activated without one have no
+// deadline, and a timeout of 0 or less disables it. A request that runs out of
+// time fails with a common.TimeoutError.
+func ActivateWithTimeout(serviceName string, serviceArea byte, item, itemList interface{},
+	vnic ifs.IVNic, orm common.IORM, callback ifs.IServiceCallback, enableCache bool, timeout time.Duration, keys ...string) {
+	ActivateWithOptions(serviceName, serviceArea, item, itemList, vnic, orm, callback,
+		Options{Cache: enableCache, Timeout: timeout}, keys...)
+}
+
+// requestContext returns the context bounding the database calls of one
+// request. The caller must call the returned cancel function when done.
+func (this *OrmService) requestContext() (context.Context, context.CancelFunc) {
+	if this.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), this.timeout)
+}
+
+// timeoutError replaces the error of an operation that ran out of time with a
+// TimeoutError, as the error the driver returns on cancellation varies.
+func (this *OrmService) timeoutError(ctx context.Context, operation string, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return &common.TimeoutError{
+		Operation: operation,
+		TypeName:  reflect.TypeOf(this.sla.ServiceItem()).Elem().Name(),
+		Timeout:   this.timeout,
+	}
+}
+
+// timeoutResult is timeoutError for the result of a read.
+func (this *OrmService) timeoutResult(ctx context.Context, operation string, result ifs.IElements) ifs.IElements {
+	if result == nil || result.Error() == nil {
+		return result
+	}
+	err := this.timeoutError(ctx, operation, result.Error())
+	if err == result.Error() {
+		return result
+	}
+	return object.NewError(err.Error())
+}
@@ -0,0 +1,217 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package persist
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// DefaultWarmUpPageSize is the number of elements a cache warm-up reads per page.
+const DefaultWarmUpPageSize = 1000
+
+// maxLoadPasses is the number of times a cache load reads all the pages when
+// elements keep changing while it reads them.
+const maxLoadPasses = 3
+
+// errWarmStopped is returned by loadPages when the service was deactivated
+// before all the pages were read.
+var errWarmStopped = errors.New("cache load stopped by deactivation")
+
+// errCacheChanged is returned by loadPages when elements were written or
+// deleted while it read the pages, which may have shifted an element to a
+// page already read.
+var errCacheChanged = errors.New("elements changed during the cache load")
+
+// WarmUp configures how a service with cache enabled loads its cache. The
+// cache is loaded in pages in the background after activation; until all the
+// pages are loaded, queries are answered from the database.
+type WarmUp struct {
+	PageSize  int    // Elements read per page, DefaultWarmUpPageSize when 0 or less
+	Predicate string // GSQL condition selecting the elements to load, "" for all of them
+}
+
+// ActivateWithWarmUp is like Activate with cache enabled, but loads the cache
+// as configured by warmUp. With a predicate, only the selected elements are
+// loaded, for example recently modified ones with "lastModified>1700000000",
+// and queries are always answered from the database, as the cache holds a
+// subset of the elements.
+func ActivateWithWarmUp(serviceName string, serviceArea byte, item, itemList interface{},
+	vnic ifs.IVNic, orm common.IORM, callback ifs.IServiceCallback, warmUp WarmUp, keys ...string) {
+	ActivateWithOptions(serviceName, serviceArea, item, itemList, vnic, orm, callback,
+		Options{Cache: true, WarmUp: warmUp}, keys...)
+}
+
+// warmUpConfig returns the warm-up with its page size defaulted to
+// DefaultWarmUpPageSize.
+func warmUpConfig(config WarmUp) WarmUp {
+	if config.PageSize <= 0 {
+		config.PageSize = DefaultWarmUpPageSize
+	}
+	return config
+}
+
+// goLoad runs a load of the cache in the background, unless the service was
+// deactivated.
+func (this *OrmService) goLoad(load func()) {
+	this.stopMtx.Lock()
+	defer this.stopMtx.Unlock()
+	select {
+	case <-this.warmStop:
+		return
+	default:
+	}
+	this.loaders.Add(1)
+	go func() {
+		defer this.loaders.Done()
+		load()
+	}()
+}
+
+// stopLoads stops the background loads of the cache and waits for them to
+// return. A load stops before its next page.
+func (this *OrmService) stopLoads() {
+	this.stopMtx.Lock()
+	close(this.warmStop)
+	this.stopMtx.Unlock()
+	this.loaders.Wait()
+}
+
+// warmCache loads the elements selected by the warm-up into the cache, page by
+// page. Elements already cached, by a write or a read since activation, or
+// written since the pass began are newer than the page and are kept. When elements changed during the load, it
+// is repeated, up to maxLoadPasses times, as the pages may have skipped some;
+// until one pass sees no change, queries are answered from the database. A
+// failed page stops the warm-up, leaving queries answered from the database.
+func (this *OrmService) warmCache(vnic ifs.IVNic) {
+	var err error
+	for pass := 0; pass < maxLoadPasses; pass++ {
+		atomic.StoreInt64(&this.warmLoaded, 0)
+		err = this.loadPages(vnic, func(key string, elem interface{}) {
+			this.cacheLoad(key, elem, true)
+			atomic.AddInt64(&this.warmLoaded, 1)
+		})
+		if err != errCacheChanged {
+			break
+		}
+	}
+	if err == errWarmStopped {
+		return
+	}
+	if err != nil {
+		vnic.Resources().Logger().Error("Cache warm-up failed for ", this.sla.ServiceName(),
+			" area ", this.sla.ServiceArea(), " after ", atomic.LoadInt64(&this.warmLoaded), " elements: ", err.Error())
+		return
+	}
+	atomic.StoreInt32(&this.warmDone, 1)
+	vnic.Resources().Logger().Info("Cache warm-up done for ", this.sla.ServiceName(),
+		" with ", atomic.LoadInt64(&this.warmLoaded), " elements")
+}
+
+// loadPages reads the elements selected by the warm-up page by page, in
+// primary key order, each page bounded by the request deadline, and passes
+// them to load with their primary key. It stops at the first failed page, or
+// with errWarmStopped once the service is deactivated. It returns
+// errCacheChanged when the service wrote, deleted or was told of changed
+// elements while it read the pages. The written elements are recorded so
+// that cacheLoad keeps them.
+func (this *OrmService) loadPages(vnic ifs.IVNic, load func(string, interface{})) (err error) {
+	changes := atomic.LoadInt64(&this.changes)
+	this.writes.begin()
+	defer func() {
+		if this.writes.end() && err == nil {
+			err = errCacheChanged
+		}
+	}()
+	for page := 0; ; page++ {
+		select {
+		case <-this.warmStop:
+			return errWarmStopped
+		default:
+		}
+		q, e := this.warmUpQuery(vnic, page)
+		if e != nil {
+			return e
+		}
+		ctx, cancel := this.requestContext()
+		result := this.orm.ReadContext(ctx, q, vnic.Resources())
+		cancel()
+		if result == nil {
+			break
+		}
+		if result.Error() != nil {
+			return result.Error()
+		}
+		elements := result.Elements()
+		for _, elem := range elements {
+			if elem != nil {
+				load(KeyOf(object.New(nil, elem), vnic.Resources()), elem)
+			}
+		}
+		if len(elements) < this.warmUp.PageSize {
+			break
+		}
+	}
+	if atomic.LoadInt64(&this.changes) != changes {
+		return errCacheChanged
+	}
+	return nil
+}
+
+// warmUpQuery returns the query of one page of the elements selected by the
+// warm-up, sorted by the primary key so the pages do not overlap.
+func (this *OrmService) warmUpQuery(vnic ifs.IVNic, page int) (ifs.IQuery, error) {
+	gsql := "select * from " + reflect.TypeOf(this.sla.ServiceItem()).Elem().Name()
+	if this.warmUp.Predicate != "" {
+		gsql += " where " + this.warmUp.Predicate
+	}
+	gsql += " limit " + strconv.Itoa(this.warmUp.PageSize) + " page " + strconv.Itoa(page)
+	qe, err := object.NewQuery(gsql, vnic.Resources())
+	if err != nil {
+		return nil, err
+	}
+	q, err := qe.Query(vnic.Resources())
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]common.SortKey, 0, len(this.sla.PrimaryKeys()))
+	for _, key := range this.sla.PrimaryKeys() {
+		keys = append(keys, common.Asc(key))
+	}
+	return common.OrderBy(q, keys...), nil
+}
+
+// cacheComplete reports whether the cache holds all the stored elements, so
+// queries can be answered from it: the warm-up loaded every element, writes
+// kept the cache up to date since and no reload requested by another replica
+// is pending.
+func (this *OrmService) cacheComplete() bool {
+	return this.cache != nil && this.warmUp.Predicate == "" && atomic.LoadInt32(&this.warmDone) == 1 &&
+		atomic.LoadInt32(&this.reloads) == 0
+}
+
+// warmUpCounts adds the warm-up progress to cache metadata counts: the number
+// of elements loaded by the current pass, and 1 once the cache holds all the
+// stored elements.
+func (this *OrmService) warmUpCounts(counts map[string]float64) {
+	counts["WarmUpLoaded"] = float64(atomic.LoadInt64(&this.warmLoaded))
+	counts["WarmUpDone"] = float64(atomic.LoadInt32(&this.warmDone))
+}
@@ -0,0 +1,86 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// DeleteRelational removes the root rows matching the query criteria together
+// with all child rows stored under their keys. Root types in soft delete mode
+// only have their root rows stamped.
+func (this *Memory) DeleteRelational(query ifs.IQuery) error {
+	data, err := convert.NewRelationsDataForQuery(query)
+	if err != nil {
+		return err
+	}
+
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	matches, err := this.matchRoots(query)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	if this.IsSoftDelete(query.RootType().TypeName) {
+		now := time.Now().Unix()
+		for _, m := range matches {
+			m.row.deletedAt = now
+		}
+		return nil
+	}
+
+	root := this.table(query.RootType().TypeName, false)
+	rootKeys := make(map[string]bool, len(matches))
+	for _, m := range matches {
+		key := m.row.parentKey + m.row.recKey
+		rootKeys[key] = true
+		delete(root.rows, key)
+	}
+
+	for tableName := range data.Tables {
+		stored := this.table(tableName, false)
+		if stored == nil || stored == root {
+			continue
+		}
+		for key, row := range stored.rows {
+			if hasRootPrefix(row.parentKey, rootKeys) {
+				delete(stored.rows, key)
+			}
+		}
+	}
+	return nil
+}
+
+// Delete removes records matching the query.
+func (this *Memory) Delete(q ifs.IQuery, resources ifs.IResources) error {
+	return this.DeleteRelational(q)
+}
+
+// DeleteContext is Delete, failing with the context's error when ctx is already done.
+func (this *Memory) DeleteContext(ctx context.Context, q ifs.IQuery, resources ifs.IResources) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return this.Delete(q, resources)
+}
@@ -0,0 +1,155 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package memory provides a pure in-memory implementation of the IORM,
+// IORMRelational and ITSDB interfaces. Relational rows are kept in maps with
+// the same ParentKey/RecKey layout the SQL plugins use, and query criteria,
+// sorting, paging and aggregates are evaluated in process. It is intended for
+// tests and short-lived simulators that should run without a database.
+package memory
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/eval"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
+	"github.com/saichler/l8types/go/types/l8notify"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// memRow is a stored relational row. Column values are kept by attribute name
+// because the column indexes of L8OrmTable differ from one conversion to the next.
+type memRow struct {
+	parentKey string
+	recKey    string
+	columns   map[string][]byte
+	deletedAt int64 // Unix time of a soft delete, 0 for live rows
+}
+
+// memTable holds the rows of one table keyed by ParentKey + RecKey.
+type memTable struct {
+	rows map[string]*memRow
+}
+
+// Memory implements the IORM interface over in-memory tables.
+type Memory struct {
+	tables map[string]*memTable // Lowercased table name -> rows
+	mtx    *sync.RWMutex        // Protects tables
+	res    ifs.IResources       // Layer 8 resources (introspector, registry, etc.)
+
+	versionField string   // Root attribute holding the row version, "" when disabled
+	softDelete   sync.Map // Root type names in soft delete mode
+
+	tsdb *Tsdb
+}
+
+// NewMemory creates a new, empty in-memory ORM instance.
+func NewMemory(resourcs ifs.IResources) *Memory {
+	return &Memory{
+		tables: make(map[string]*memTable),
+		mtx:    &sync.RWMutex{},
+		res:    resourcs,
+		tsdb:   NewTsdb(),
+	}
+}
+
+// table returns the stored table for the given name, creating it when create is true.
+func (this *Memory) table(tableName string, create bool) *memTable {
+	name := strings.ToLower(tableName)
+	t, ok := this.tables[name]
+	if !ok && create {
+		t = &memTable{rows: make(map[string]*memRow)}
+		this.tables[name] = t
+	}
+	return t
+}
+
+// childTables returns the stored tables of the struct types nested under the
+// root type, which hold the child rows of its elements.
+func (this *Memory) childTables(rootTypeName string) []*memTable {
+	node, ok := this.res.Introspector().NodeByTypeName(rootTypeName)
+	if !ok {
+		return nil
+	}
+	root := this.table(rootTypeName, false)
+	seen := map[string]bool{node.TypeName: true}
+	tables := make([]*memTable, 0)
+	var collect func(node *l8reflect.L8Node)
+	collect = func(node *l8reflect.L8Node) {
+		for _, attr := range node.Attributes {
+			if !attr.IsStruct || common.IsTimeSeriesType(attr.TypeName) || seen[attr.TypeName] {
+				continue
+			}
+			seen[attr.TypeName] = true
+			if stored := this.table(attr.TypeName, false); stored != nil && stored != root {
+				tables = append(tables, stored)
+			}
+			collect(attr)
+		}
+	}
+	collect(node)
+	return tables
+}
+
+// values decodes the column values of a stored row for criteria evaluation and sorting.
+func (this *Memory) values(row *memRow) (eval.Values, error) {
+	return eval.NewValues(row.columns, this.res.Registry())
+}
+
+// toRow rebuilds an L8OrmRow for a stored row using the column indexes of the
+// table being read. Columns that are not part of the table (projections) are omitted.
+func toRow(row *memRow, columns map[string]int32) *l8orms.L8OrmRow {
+	result := &l8orms.L8OrmRow{ParentKey: row.parentKey, RecKey: row.recKey}
+	result.ColumnValues = make(map[int32][]byte)
+	for name, index := range columns {
+		data, ok := row.columns[name]
+		if ok {
+			result.ColumnValues[index] = data
+		}
+	}
+	return result
+}
+
+func (this *Memory) AddTSDB(notifications []*l8notify.L8TSDBNotification) error {
+	return this.tsdb.AddTSDB(notifications)
+}
+
+func (this *Memory) GetTSDB(propertyId string, start, end int64) ([]*l8api.L8TimeSeriesPoint, error) {
+	return this.tsdb.GetTSDB(propertyId, start, end)
+}
+
+func (this *Memory) GetTSDBBuckets(propertyId string, start, end, bucket int64, function string) ([]*l8api.L8TimeSeriesPoint, error) {
+	return this.tsdb.GetTSDBBuckets(propertyId, start, end, bucket, function)
+}
+
+func (this *Memory) GetTSDBLatestBatch(propertyIds []string, limit int) (map[string][]*l8api.L8TimeSeriesPoint, error) {
+	return this.tsdb.GetTSDBLatestBatch(propertyIds, limit)
+}
+
+func (this *Memory) GetTSDBBatch(propertyIds []string, start, end, bucket int64, function string) (map[string][]*l8api.L8TimeSeriesPoint, error) {
+	return this.tsdb.GetTSDBBatch(propertyIds, start, end, bucket, function)
+}
+
+// Close drops all stored rows and time series points.
+func (this *Memory) Close() error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.tables = make(map[string]*memTable)
+	return this.tsdb.Close()
+}
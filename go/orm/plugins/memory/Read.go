@@ -0,0 +1,462 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8orm/go/orm/eval"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
+	"github.com/saichler/l8types/go/types/l8reflect"
+	"github.com/saichler/l8utils/go/utils/cache"
+)
+
+// rootMatch is a root row that matched the query criteria, with its decoded
+// values and the values it sorts by.
+type rootMatch struct {
+	row        *memRow
+	values     eval.Values
+	sortValues []interface{}
+}
+
+// ReadRelational evaluates a query against the stored tables and returns the
+// matching root rows, with their child rows, as relational data. The metadata
+// carries the total number of matching root rows before paging.
+func (this *Memory) ReadRelational(query ifs.IQuery) (*l8orms.L8OrmRData, *l8api.L8MetaData, error) {
+	data, err := convert.NewRelationsDataForQuery(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+
+	matches, err := this.matchRoots(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata := newMetadata()
+	metadata.KeyCount.Counts["Total"] = float64(len(matches))
+	matches = pageOf(matches, query.Page(), query.Limit())
+
+	rootName := query.RootType().TypeName
+	rootKeys := make(map[string]bool, len(matches))
+	for tableName, table := range data.Tables {
+		if !strings.EqualFold(tableName, rootName) {
+			continue
+		}
+		for _, m := range matches {
+			addRowToTable(table, toRow(m.row, table.Columns))
+			rootKeys[m.row.parentKey+m.row.recKey] = true
+		}
+	}
+
+	for tableName, table := range data.Tables {
+		if strings.EqualFold(tableName, rootName) {
+			continue
+		}
+		stored := this.table(tableName, false)
+		if stored == nil {
+			continue
+		}
+		for _, row := range stored.rows {
+			if hasRootPrefix(row.parentKey, rootKeys) {
+				addRowToTable(table, toRow(row, table.Columns))
+			}
+		}
+	}
+	return data, metadata, nil
+}
+
+// ReadRelationalContext is ReadRelational, failing with the context's error
+// when ctx is already done. Reads from memory do not block, so ctx is not
+// checked again once the read started.
+func (this *Memory) ReadRelationalContext(ctx context.Context, query ifs.IQuery) (*l8orms.L8OrmRData, *l8api.L8MetaData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	return this.ReadRelational(query)
+}
+
+// Read executes a query and returns the results as Go objects.
+func (this *Memory) Read(q ifs.IQuery, resources ifs.IResources) ifs.IElements {
+	if q.IsAggregate() {
+		return this.readAggregate(q)
+	}
+	relData, metadata, err := this.ReadRelational(q)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	result := convert.ConvertFrom(object.New(nil, relData), metadata, resources)
+	return convert.PopulateTsFields(result, resources, this.tsdb.GetTSDBLatestBatch)
+}
+
+// ReadContext is Read, failing with the context's error when ctx is already done.
+func (this *Memory) ReadContext(ctx context.Context, q ifs.IQuery, resources ifs.IResources) ifs.IElements {
+	if err := ctx.Err(); err != nil {
+		return object.NewError(err.Error())
+	}
+	return this.Read(q, resources)
+}
+
+// matchRoots returns the root rows matching the query criteria, sorted by the
+// query's sort keys. Rows that are equal on all keys, or all rows without sort
+// keys, are ordered by RecKey so that paging is stable. Tombstoned rows are
+// skipped unless the query is WithDeleted.
+func (this *Memory) matchRoots(query ifs.IQuery) ([]*rootMatch, error) {
+	rootName := query.RootType().TypeName
+	stored := this.table(rootName, false)
+	if stored == nil {
+		return []*rootMatch{}, nil
+	}
+	matches := make([]*rootMatch, 0, len(stored.rows))
+	for _, row := range stored.rows {
+		if row.parentKey != "" {
+			continue
+		}
+		if row.deletedAt != 0 && !common.IncludesDeleted(query) {
+			continue
+		}
+		values, err := this.values(row)
+		if err != nil {
+			return nil, err
+		}
+		_, match, err := eval.NestedExpression(query.Criteria(), rootName, values, this.children(row))
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			matches = append(matches, &rootMatch{row: row, values: values})
+		}
+	}
+
+	keys := common.SortKeys(query)
+	rootNode, _ := this.res.Introspector().NodeByTypeName(rootName)
+	for _, m := range matches {
+		sortValues, err := this.sortValues(m, rootNode, keys)
+		if err != nil {
+			return nil, err
+		}
+		m.sortValues = sortValues
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		for k, key := range keys {
+			cmp := eval.CompareValues(matches[i].sortValues[k], matches[j].sortValues[k])
+			if cmp != 0 {
+				if key.Descending {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+		}
+		return matches[i].row.recKey < matches[j].row.recKey
+	})
+	return matches, nil
+}
+
+// sortValues returns the values a root row sorts by, one per sort key. Keys on
+// attributes of nested structs take the value from the child row at their path.
+func (this *Memory) sortValues(m *rootMatch, rootNode *l8reflect.L8Node, keys []common.SortKey) ([]interface{}, error) {
+	result := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if rootNode == nil {
+			result[i] = m.values[strings.ToLower(key.Field)]
+			continue
+		}
+		attr, path, ok := common.SortAttribute(rootNode, key.Field)
+		if !ok {
+			result[i] = m.values[strings.ToLower(key.Field)]
+			continue
+		}
+		name := strings.ToLower(attr.FieldName)
+		if len(path) == 0 {
+			result[i] = m.values[name]
+			continue
+		}
+		rows, err := this.children(m.row)(attr.Parent.TypeName, path)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) > 0 {
+			result[i] = rows[0][name]
+		}
+	}
+	return result, nil
+}
+
+// children returns the lookup of the child rows stored under a root row, used
+// to evaluate criteria on child table attributes.
+func (this *Memory) children(root *memRow) eval.Children {
+	rootKey := root.parentKey + root.recKey
+	return func(tableName string, path []string) ([]eval.Values, error) {
+		result := make([]eval.Values, 0)
+		stored := this.table(tableName, false)
+		if stored == nil {
+			return result, nil
+		}
+		for _, row := range stored.rows {
+			if !common.UnderNestedPath(row.parentKey, row.recKey, rootKey, path) {
+				continue
+			}
+			values, err := this.values(row)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, values)
+		}
+		return result, nil
+	}
+}
+
+// readAggregate evaluates an aggregate query over the matching root rows and
+// returns the results packed into L8MetaData.KeyCount.Counts, like the SQL plugins.
+func (this *Memory) readAggregate(q ifs.IQuery) ifs.IElements {
+	this.mtx.RLock()
+	matches, err := this.matchRoots(q)
+	this.mtx.RUnlock()
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+
+	order := make([]string, 0)
+	members := make(map[string][]*rootMatch)
+	for _, m := range matches {
+		key := groupKey(m.values, q.GroupBy())
+		if _, ok := members[key]; !ok {
+			order = append(order, key)
+		}
+		members[key] = append(members[key], m)
+	}
+	if len(q.GroupBy()) == 0 && len(order) == 0 {
+		order = append(order, "")
+	}
+
+	groups := make([]map[string]interface{}, 0, len(order))
+	for _, key := range order {
+		rows := members[key]
+		group := make(map[string]interface{})
+		having := make(eval.Values)
+		for _, gb := range q.GroupBy() {
+			var value interface{}
+			if len(rows) > 0 {
+				value = rows[0].values[strings.ToLower(gb)]
+			}
+			group[gb] = value
+			having[strings.ToLower(gb)] = value
+		}
+		for _, agg := range q.Aggregates() {
+			value, err := aggregate(agg.Function, strings.ToLower(agg.Field), rows)
+			if err != nil {
+				return object.NewError(err.Error())
+			}
+			group[agg.Alias] = value
+			having[strings.ToLower(agg.Alias)] = value
+		}
+		_, match, err := eval.Expression(q.Having(), q.RootType().TypeName, having)
+		if err != nil {
+			return object.NewError(err.Error())
+		}
+		if match {
+			groups = append(groups, group)
+		}
+	}
+
+	if sortBy := q.SortBy(); sortBy != "" {
+		sort.SliceStable(groups, func(i, j int) bool {
+			cmp := eval.CompareValues(groupValue(groups[i], sortBy), groupValue(groups[j], sortBy))
+			if q.Descending() {
+				return cmp > 0
+			}
+			return cmp < 0
+		})
+	}
+	if q.Limit() > 0 {
+		start := int(q.Page() * q.Limit())
+		if start > len(groups) {
+			start = len(groups)
+		}
+		end := start + int(q.Limit())
+		if end > len(groups) {
+			end = len(groups)
+		}
+		groups = groups[start:end]
+	}
+
+	metadata := newMetadata()
+	cache.PackAggregateResults(groups, q.Aggregates(), q.GroupBy(), metadata)
+	return object.NewQueryResult([]interface{}{}, metadata)
+}
+
+// aggregate computes a single aggregate function over a group of rows.
+func aggregate(function, field string, rows []*rootMatch) (interface{}, error) {
+	fn := strings.ToLower(function)
+	if fn == "count" {
+		if field == "*" || field == "" {
+			return int64(len(rows)), nil
+		}
+		count := int64(0)
+		for _, m := range rows {
+			if _, ok := m.values[field]; ok {
+				count++
+			}
+		}
+		return count, nil
+	}
+
+	var result interface{}
+	sum := 0.0
+	n := 0
+	for _, m := range rows {
+		value, ok := m.values[field]
+		if !ok {
+			continue
+		}
+		switch fn {
+		case "min":
+			if result == nil || eval.CompareValues(value, result) < 0 {
+				result = value
+			}
+		case "max":
+			if result == nil || eval.CompareValues(value, result) > 0 {
+				result = value
+			}
+		case "sum", "avg":
+			f, err := toFloat(value)
+			if err != nil {
+				return nil, err
+			}
+			sum += f
+			n++
+		default:
+			return nil, fmt.Errorf("unsupported aggregate function %s", function)
+		}
+	}
+	switch fn {
+	case "sum":
+		return sum, nil
+	case "avg":
+		if n == 0 {
+			return nil, nil
+		}
+		return sum / float64(n), nil
+	}
+	return result, nil
+}
+
+// toFloat converts a decoded numeric column value to float64.
+func toFloat(value interface{}) (float64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return 0, fmt.Errorf("cannot aggregate non numeric value %v", value)
+}
+
+// groupKey builds the group identity of a row from its group-by values.
+func groupKey(values eval.Values, groupBy []string) string {
+	key := strings.Builder{}
+	for _, gb := range groupBy {
+		key.WriteString(fmt.Sprint(values[strings.ToLower(gb)]))
+		key.WriteString("\x00")
+	}
+	return key.String()
+}
+
+// groupValue looks up a group column case-insensitively.
+func groupValue(group map[string]interface{}, name string) interface{} {
+	for key, value := range group {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return nil
+}
+
+// pageOf returns the requested page of matches. A limit of 0 returns all matches.
+func pageOf(matches []*rootMatch, page, limit int32) []*rootMatch {
+	if limit <= 0 {
+		return matches
+	}
+	start := int(page * limit)
+	if start >= len(matches) {
+		return []*rootMatch{}
+	}
+	end := start + int(limit)
+	if end > len(matches) {
+		end = len(matches)
+	}
+	return matches[start:end]
+}
+
+// hasRootPrefix checks whether a child ParentKey belongs to one of the root keys.
+// Root keys end with "]", so only the prefixes ending there need to be looked up.
+func hasRootPrefix(parentKey string, rootKeys map[string]bool) bool {
+	for i := 0; i < len(parentKey); i++ {
+		if parentKey[i] == ']' && rootKeys[parentKey[:i+1]] {
+			return true
+		}
+	}
+	return false
+}
+
+func newMetadata() *l8api.L8MetaData {
+	return &l8api.L8MetaData{
+		KeyCount: &l8api.L8Count{
+			Counts: make(map[string]float64),
+		},
+	}
+}
+
+// nameOfField extracts the field name from a RecKey by removing the bracketed portion.
+func nameOfField(recKey string) string {
+	index := strings.Index(recKey, "[")
+	if index == -1 {
+		return recKey
+	}
+	return recKey[0:index]
+}
+
+// addRowToTable adds a row to the table's nested structure.
+func addRowToTable(table *l8orms.L8OrmTable, row *l8orms.L8OrmRow) {
+	fldName := nameOfField(row.RecKey)
+	if table.InstanceRows == nil {
+		table.InstanceRows = make(map[string]*l8orms.L8OrmInstanceRows)
+	}
+	if table.InstanceRows[row.ParentKey] == nil {
+		table.InstanceRows[row.ParentKey] = &l8orms.L8OrmInstanceRows{}
+	}
+	if table.InstanceRows[row.ParentKey].AttributeRows == nil {
+		table.InstanceRows[row.ParentKey].AttributeRows = make(map[string]*l8orms.L8OrmAttributeRows)
+	}
+	if table.InstanceRows[row.ParentKey].AttributeRows[fldName] == nil {
+		table.InstanceRows[row.ParentKey].AttributeRows[fldName] = &l8orms.L8OrmAttributeRows{}
+	}
+	attrRows := table.InstanceRows[row.ParentKey].AttributeRows[fldName]
+	attrRows.Rows = append(attrRows.Rows, row)
+}
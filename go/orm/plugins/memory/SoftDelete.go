@@ -0,0 +1,79 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package memory
+
+import (
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// EnableSoftDelete turns on soft delete mode for a root type.
+func (this *Memory) EnableSoftDelete(typeName string) {
+	this.softDelete.Store(typeName, true)
+}
+
+// IsSoftDelete reports whether a root type is in soft delete mode.
+func (this *Memory) IsSoftDelete(typeName string) bool {
+	_, ok := this.softDelete.Load(typeName)
+	return ok
+}
+
+// Restore clears the tombstones of the deleted root rows matching the query.
+func (this *Memory) Restore(query ifs.IQuery) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	matches, err := this.matchRoots(common.WithDeleted(query))
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		m.row.deletedAt = 0
+	}
+	return nil
+}
+
+// Purge removes the root rows of the type that were soft deleted at least
+// age ago, together with their child rows.
+func (this *Memory) Purge(typeName string, age time.Duration) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	root := this.table(typeName, false)
+	if root == nil {
+		return nil
+	}
+	before := time.Now().Add(-age).Unix()
+	rootKeys := make(map[string]bool)
+	for key, row := range root.rows {
+		if row.parentKey == "" && row.deletedAt != 0 && row.deletedAt <= before {
+			rootKeys[key] = true
+			delete(root.rows, key)
+		}
+	}
+	if len(rootKeys) == 0 {
+		return nil
+	}
+	for _, stored := range this.childTables(typeName) {
+		for key, row := range stored.rows {
+			if hasRootPrefix(row.parentKey, rootKeys) {
+				delete(stored.rows, key)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package memory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8types/go/types/l8api"
+	"github.com/saichler/l8types/go/types/l8notify"
+)
+
+// Tsdb implements the ITSDB interface with per-property point slices kept
+// sorted by stamp.
+type Tsdb struct {
+	series map[string][]*l8api.L8TimeSeriesPoint
+	mtx    *sync.RWMutex
+}
+
+// NewTsdb creates a new, empty in-memory TSDB.
+func NewTsdb() *Tsdb {
+	return &Tsdb{
+		series: make(map[string][]*l8api.L8TimeSeriesPoint),
+		mtx:    &sync.RWMutex{},
+	}
+}
+
+// AddTSDB stores time series notifications, keeping each series ordered by stamp.
+func (this *Tsdb) AddTSDB(notifications []*l8notify.L8TSDBNotification) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	for _, n := range notifications {
+		if n == nil || n.Point == nil {
+			continue
+		}
+		points := this.series[n.PropertyId]
+		point := &l8api.L8TimeSeriesPoint{Stamp: n.Point.Stamp, Value: n.Point.Value}
+		index := sort.Search(len(points), func(i int) bool { return points[i].Stamp > point.Stamp })
+		points = append(points, nil)
+		copy(points[index+1:], points[index:])
+		points[index] = point
+		this.series[n.PropertyId] = points
+	}
+	return nil
+}
+
+// GetTSDB retrieves the points of a property between start and end, inclusive.
+func (this *Tsdb) GetTSDB(propertyId string, start, end int64) ([]*l8api.L8TimeSeriesPoint, error) {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	return rangePoints(this.series[propertyId], start, end), nil
+}
+
+// rangePoints copies the points of a series between start and end, inclusive.
+func rangePoints(points []*l8api.L8TimeSeriesPoint, start, end int64) []*l8api.L8TimeSeriesPoint {
+	from := sort.Search(len(points), func(i int) bool { return points[i].Stamp >= start })
+	to := sort.Search(len(points), func(i int) bool { return points[i].Stamp > end })
+	var result []*l8api.L8TimeSeriesPoint
+	for _, p := range points[from:to] {
+		result = append(result, &l8api.L8TimeSeriesPoint{Stamp: p.Stamp, Value: p.Value})
+	}
+	return result
+}
+
+// GetTSDBBuckets aggregates the data points of a property within a time range
+// per bucket of the given seconds, the buckets aligned on the Unix epoch and
+// the range widened to whole buckets.
+func (this *Tsdb) GetTSDBBuckets(propertyId string, start, end, bucket int64, function string) ([]*l8api.L8TimeSeriesPoint, error) {
+	function, err := common.TsdbBucketArgs(bucket, function)
+	if err != nil {
+		return nil, err
+	}
+	start, end = common.BucketRange(start, end, bucket)
+	points, err := this.GetTSDB(propertyId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return common.BucketPoints(points, bucket, function), nil
+}
+
+// GetTSDBLatest retrieves the most recent N data points for a property, ordered chronologically.
+func (this *Tsdb) GetTSDBLatest(propertyId string, limit int) ([]*l8api.L8TimeSeriesPoint, error) {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	return latestPoints(this.series[propertyId], limit), nil
+}
+
+// GetTSDBLatestBatch retrieves the most recent limit points of each of the
+// properties, or of each property matching a pattern, ordered chronologically
+// and grouped by property ID.
+func (this *Tsdb) GetTSDBLatestBatch(propertyIds []string, limit int) (map[string][]*l8api.L8TimeSeriesPoint, error) {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	result := make(map[string][]*l8api.L8TimeSeriesPoint)
+	for propertyId, points := range this.matchingSeries(propertyIds) {
+		result[propertyId] = latestPoints(points, limit)
+	}
+	return result, nil
+}
+
+// GetTSDBBatch retrieves the points of each of the properties, or of each
+// property matching a pattern, between start and end, grouped by property ID.
+// With a bucket or a function, the points are aggregated per bucket.
+func (this *Tsdb) GetTSDBBatch(propertyIds []string, start, end, bucket int64, function string) (map[string][]*l8api.L8TimeSeriesPoint, error) {
+	bucketed := bucket != 0 || function != ""
+	if bucketed {
+		var err error
+		if function, err = common.TsdbBucketArgs(bucket, function); err != nil {
+			return nil, err
+		}
+		start, end = common.BucketRange(start, end, bucket)
+	}
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	result := make(map[string][]*l8api.L8TimeSeriesPoint)
+	for propertyId, points := range this.matchingSeries(propertyIds) {
+		selected := rangePoints(points, start, end)
+		if len(selected) == 0 {
+			continue
+		}
+		if bucketed {
+			selected = common.BucketPoints(selected, bucket, function)
+		}
+		result[propertyId] = selected
+	}
+	return result, nil
+}
+
+// matchingSeries returns the stored series of the property IDs, and of the
+// properties matching the patterns among them.
+func (this *Tsdb) matchingSeries(propertyIds []string) map[string][]*l8api.L8TimeSeriesPoint {
+	matching := make(map[string][]*l8api.L8TimeSeriesPoint)
+	for _, pattern := range propertyIds {
+		if !strings.Contains(pattern, common.TsdbWildcard) {
+			if points, ok := this.series[pattern]; ok {
+				matching[pattern] = points
+			}
+			continue
+		}
+		for propertyId, points := range this.series {
+			if common.MatchTsdbProperty(pattern, propertyId) {
+				matching[propertyId] = points
+			}
+		}
+	}
+	return matching
+}
+
+// latestPoints copies the most recent limit points of a series.
+func latestPoints(points []*l8api.L8TimeSeriesPoint, limit int) []*l8api.L8TimeSeriesPoint {
+	from := len(points) - limit
+	if from < 0 {
+		from = 0
+	}
+	var result []*l8api.L8TimeSeriesPoint
+	for _, p := range points[from:] {
+		result = append(result, &l8api.L8TimeSeriesPoint{Stamp: p.Stamp, Value: p.Value})
+	}
+	return result
+}
+
+// Close drops all stored series.
+func (this *Tsdb) Close() error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.series = make(map[string][]*l8api.L8TimeSeriesPoint)
+	return nil
+}
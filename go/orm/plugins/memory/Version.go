@@ -0,0 +1,120 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package memory
+
+import (
+	"reflect"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8srlz/go/serialize/object"
+)
+
+// EnableVersioning turns on optimistic concurrency for root types that have an
+// int64 attribute with the given name.
+func (this *Memory) EnableVersioning(field string) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.versionField = field
+}
+
+// VersionField returns the version attribute name, or "" when versioning is disabled.
+func (this *Memory) VersionField() string {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	return this.versionField
+}
+
+// checkVersions applies optimistic concurrency to the root rows, like the SQL
+// plugins. All rows are checked before any of them is written, so a conflict
+// leaves the stored rows untouched. Each row is changed to carry its new version.
+func (this *Memory) checkVersions(data *l8orms.L8OrmRData) error {
+	if this.versionField == "" {
+		return nil
+	}
+	node, ok := this.res.Introspector().NodeByTypeName(data.RootTypeName)
+	if !ok {
+		return nil
+	}
+	attr, ok := node.Attributes[this.versionField]
+	if !ok || attr.IsStruct || attr.TypeName != "int64" {
+		return nil
+	}
+	table, ok := data.Tables[data.RootTypeName]
+	if !ok {
+		return nil
+	}
+	col, ok := table.Columns[this.versionField]
+	if !ok {
+		return nil
+	}
+	instRows, ok := table.InstanceRows[""]
+	if !ok {
+		return nil
+	}
+
+	stored := this.table(data.RootTypeName, false)
+	next := make(map[*l8orms.L8OrmRow]int64)
+	for _, attrRows := range instRows.AttributeRows {
+		for _, row := range attrRows.Rows {
+			var existing *memRow
+			if stored != nil {
+				existing = stored.rows[convert.KeyForRow(row)]
+			}
+			if existing == nil {
+				next[row] = 1
+				continue
+			}
+			version := this.decodeVersion(row.ColumnValues[col])
+			current := this.decodeVersion(existing.columns[this.versionField])
+			if version != 0 && version != current {
+				return &common.VersionConflictError{
+					TypeName: data.RootTypeName,
+					Key:      convert.KeyForRow(row),
+					Version:  version,
+					Stored:   current,
+				}
+			}
+			next[row] = current + 1
+		}
+	}
+
+	for row, version := range next {
+		obj := object.NewEncode()
+		err := obj.Add(version)
+		if err != nil {
+			return err
+		}
+		row.ColumnValues[col] = obj.Data()
+	}
+	return nil
+}
+
+// decodeVersion decodes a serialized version column, 0 when it holds none.
+func (this *Memory) decodeVersion(data []byte) int64 {
+	if len(data) == 0 {
+		return 0
+	}
+	val, err := object.NewDecode(data, 0, this.res.Registry()).Get()
+	if err != nil {
+		return 0
+	}
+	v := reflect.ValueOf(val)
+	if v.Kind() != reflect.Int64 {
+		return 0
+	}
+	return v.Int()
+}
@@ -0,0 +1,155 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package memory
+
+import (
+	"context"
+
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// WriteRelational stores relational data in the in-memory tables.
+// POST and PUT replace the stored row, like the SQL upsert. PUT also removes
+// the child rows of the written roots first, so elements dropped from a slice
+// or map do not survive. PATCH merges the provided columns into an existing row
+// and, like the SQL UPDATE, ignores rows that do not exist yet; the slice and
+// map fields listed by a field mask are replaced instead.
+// With versioning enabled, a root row carrying a stale version fails the whole
+// write with a VersionConflictError. POST/PUT of a soft deleted element store a
+// live row again, clearing its tombstone.
+func (this *Memory) WriteRelational(action ifs.Action, data *l8orms.L8OrmRData) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	err := this.checkVersions(data)
+	if err != nil {
+		return err
+	}
+	if action == ifs.PUT {
+		this.deleteChildRows(data)
+	}
+	var replaced []convert.Collection
+	if action == ifs.PATCH {
+		if rootNode, ok := this.res.Introspector().NodeByTypeName(data.RootTypeName); ok {
+			replaced = convert.ReplacedCollections(data, rootNode)
+			this.deleteCollections(data, replaced)
+		}
+	}
+
+	for tableName, table := range data.Tables {
+		names := make(map[int32]string, len(table.Columns))
+		for name, index := range table.Columns {
+			names[index] = name
+		}
+		stored := this.table(tableName, true)
+		for _, instRows := range table.InstanceRows {
+			for _, attrRows := range instRows.AttributeRows {
+				for _, row := range attrRows.Rows {
+					key := convert.KeyForRow(row)
+					existing, ok := stored.rows[key]
+					if action == ifs.PATCH && !convert.InCollections(row, replaced) {
+						if !ok {
+							continue
+						}
+					} else {
+						existing = &memRow{parentKey: row.ParentKey, recKey: row.RecKey}
+						existing.columns = make(map[string][]byte, len(row.ColumnValues))
+						stored.rows[key] = existing
+					}
+					for index, value := range row.ColumnValues {
+						name, ok := names[index]
+						if !ok || len(value) == 0 {
+							continue
+						}
+						buff := make([]byte, len(value))
+						copy(buff, value)
+						existing.columns[name] = buff
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// WriteRelationalContext is WriteRelational, failing with the context's error
+// when ctx is already done.
+func (this *Memory) WriteRelationalContext(ctx context.Context, action ifs.Action, data *l8orms.L8OrmRData) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return this.WriteRelational(action, data)
+}
+
+// deleteChildRows removes every stored child row under the root rows of data,
+// from the tables of the root type's nested struct types.
+func (this *Memory) deleteChildRows(data *l8orms.L8OrmRData) {
+	rootKeys := make(map[string]bool)
+	for _, key := range convert.RootKeys(data) {
+		rootKeys[key] = true
+	}
+	if len(rootKeys) == 0 {
+		return
+	}
+	for _, stored := range this.childTables(data.RootTypeName) {
+		for key, row := range stored.rows {
+			if hasRootPrefix(row.parentKey, rootKeys) {
+				delete(stored.rows, key)
+			}
+		}
+	}
+}
+
+// deleteCollections removes the stored rows of the slice and map fields that a
+// PATCH with a field mask replaces.
+func (this *Memory) deleteCollections(data *l8orms.L8OrmRData, collections []convert.Collection) {
+	if len(collections) == 0 {
+		return
+	}
+	for _, stored := range this.childTables(data.RootTypeName) {
+		for key, row := range stored.rows {
+			if convert.InCollections(&l8orms.L8OrmRow{ParentKey: row.parentKey, RecKey: row.recKey}, collections) {
+				delete(stored.rows, key)
+			}
+		}
+	}
+}
+
+// Write converts Go objects to relational data and stores them.
+// Time series fields are routed to the in-memory TSDB.
+func (this *Memory) Write(action ifs.Action, elems ifs.IElements, resources ifs.IResources) error {
+	relData := convert.ConvertTo(action, elems, resources)
+	if relData.Error() != nil {
+		return relData.Error()
+	}
+	data := relData.Element().(*l8orms.L8OrmRData)
+	if err := this.WriteRelational(action, data); err != nil {
+		return err
+	}
+	if len(data.TsData) == 0 {
+		return nil
+	}
+	return this.tsdb.AddTSDB(data.TsData)
+}
+
+// WriteContext is Write, failing with the context's error when ctx is already done.
+func (this *Memory) WriteContext(ctx context.Context, action ifs.Action, elems ifs.IElements, resources ifs.IResources) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return this.Write(action, elems, resources)
+}
@@ -0,0 +1,252 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mysql provides a MySQL/MariaDB implementation of the IORM,
+// IORMRelational and ITSDB interfaces. It uses the same ParentKey/RecKey table
+// layout as the PostgreSQL plugin and shares the relational plugin with SQLite and PostgreSQL
+// through the stmt MySQL dialect. The plugin does not import a driver; callers open the
+// *sql.DB with the MySQL driver of their choice.
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	strings2 "strings"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/plugins/relational"
+	"github.com/saichler/l8orm/go/orm/stmt"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+	"github.com/saichler/l8utils/go/utils/strings"
+)
+
+// keyColumnType is the column type of ParentKey and RecKey. InnoDB limits a
+// primary key to 3072 bytes, which two utf8mb4 VARCHAR(384) columns fill exactly.
+// The binary collation makes keys compare case-sensitively, as on the other
+// backends, so the key prefix LIKE of stmt.MySQL can use the primary key.
+const keyColumnType = "VARCHAR(384) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin"
+
+// maxKeyLength is the length of keyColumnType. Longer keys are rejected before
+// the write, as strict mode would fail it and other modes truncate the key.
+const maxKeyLength = 384
+
+// indexPrefix is the prefix length used when indexing TEXT columns, which
+// MySQL cannot index in full.
+const indexPrefix = "191"
+
+// tsdbDDL creates the time series table. The index is declared inline
+// because MySQL has no CREATE INDEX IF NOT EXISTS.
+var tsdbDDL = []string{`CREATE TABLE IF NOT EXISTS l8tsdb (
+		stamp    BIGINT       NOT NULL,
+		prop_id  VARCHAR(384) NOT NULL,
+		value    DOUBLE       NOT NULL,
+		INDEX idx_l8tsdb_prop_stamp (prop_id, stamp)
+	) DEFAULT CHARSET=utf8mb4`}
+
+// Mysql implements the IORM, IORMRelational and ITSDB interfaces on top of a
+// MySQL or MariaDB database. The relational plugin does the reads and writes
+// with the MySQL dialect; Mysql creates and migrates the tables.
+type Mysql struct {
+	*relational.Relational
+}
+
+// NewMysql creates a new MySQL ORM instance with the given database connection.
+// The time series store shares the same connection pool and schema.
+func NewMysql(db *sql.DB, resourcs ifs.IResources) *Mysql {
+	mysql := &Mysql{}
+	mysql.Relational = relational.New(db, resourcs, stmt.MySQL, mysql,
+		relational.NewTsdb(db, false, stmt.MySQL, tsdbDDL...))
+	mysql.SetMaxKeyLength(maxKeyLength)
+	return mysql
+}
+
+// VerifyTable checks if a table exists in the current schema and creates it if not.
+// If the table already exists, its columns are reconciled with the current
+// proto definition. Table name case sensitivity depends on the server's
+// lower_case_table_names setting, so the lookup compares case-insensitively.
+func (this *Mysql) VerifyTable(ctx context.Context, tableName string, root bool) error {
+	var count int
+	err := this.DB().QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND LOWER(table_name) = ?",
+		strings2.ToLower(tableName)).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return this.createTable(ctx, tableName, root)
+	}
+	return this.migrateTable(ctx, tableName, root)
+}
+
+// migrateTable compares the live table columns against the current proto
+// definition and adds any missing columns via ALTER TABLE ADD COLUMN.
+// Like the PostgreSQL plugin it is purely additive, apart from converting
+// the key columns to the binary collation.
+func (this *Mysql) migrateTable(ctx context.Context, tableName string, root bool) error {
+	node, ok := this.Resources().Introspector().NodeByTypeName(tableName)
+	if !ok {
+		return errors.New("Cannot find node for table " + tableName)
+	}
+	err := this.migrateKeyColumns(ctx, tableName)
+	if err != nil {
+		return err
+	}
+
+	liveColumns, err := this.schemaNames(ctx,
+		"SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND LOWER(table_name) = ?",
+		tableName)
+	if err != nil {
+		return err
+	}
+
+	missing := make([]string, 0)
+	missingTypes := make(map[string]string)
+	for attrName, attr := range node.Attributes {
+		if attr.IsStruct {
+			continue
+		}
+		if common.IsTimeSeriesType(attr.TypeName) {
+			continue
+		}
+		if liveColumns[strings2.ToLower(attrName)] {
+			continue
+		}
+		missing = append(missing, attrName)
+		missingTypes[attrName] = this.ColumnType(attrName, attr, root)
+	}
+	if root && this.IsSoftDelete(tableName) && !liveColumns[strings2.ToLower(common.DeletedAtColumn)] {
+		missing = append(missing, common.DeletedAtColumn)
+		missingTypes[common.DeletedAtColumn] = this.StampType()
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	this.Resources().Logger().Info("Migrating table ", tableName, ": adding columns ", missing)
+
+	for _, attrName := range missing {
+		alterQ := strings.New("ALTER TABLE ", tableName, " ADD COLUMN ", attrName, " ", missingTypes[attrName], ";")
+		_, err = this.DB().ExecContext(ctx, alterQ.String())
+		if err != nil {
+			return err
+		}
+	}
+	return this.createIndexes(ctx, tableName, node)
+}
+
+// migrateKeyColumns converts the key columns of a table created by an earlier
+// version, under the case-insensitive default collation, to keyColumnType.
+func (this *Mysql) migrateKeyColumns(ctx context.Context, tableName string) error {
+	binary, err := this.schemaNames(ctx,
+		"SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND LOWER(table_name) = ? "+
+			"AND collation_name = 'utf8mb4_bin'",
+		tableName)
+	if err != nil {
+		return err
+	}
+	if binary["parentkey"] && binary["reckey"] {
+		return nil
+	}
+	this.Resources().Logger().Info("Migrating table ", tableName, ": binary collation of the key columns")
+	alterQ := strings.New("ALTER TABLE ", tableName, " MODIFY ParentKey ", keyColumnType, " NOT NULL, MODIFY RecKey ",
+		keyColumnType, " NOT NULL;")
+	_, err = this.DB().ExecContext(ctx, alterQ.String())
+	return err
+}
+
+// createTable generates and executes DDL to create a table for the given type.
+// The layout matches the PostgreSQL plugin: ParentKey and RecKey columns
+// forming the primary key, followed by one column per non-struct attribute.
+func (this *Mysql) createTable(ctx context.Context, tableName string, root bool) error {
+	node, ok := this.Resources().Introspector().NodeByTypeName(tableName)
+	if !ok {
+		return errors.New("Cannot find node for table " + tableName)
+	}
+	q := strings.New("create table ", tableName, " (\n")
+	q.Add("ParentKey ", keyColumnType, " NOT NULL,\n")
+	q.Add("RecKey ", keyColumnType, " NOT NULL,\n")
+	for attrName, attr := range node.Attributes {
+		if attr.IsStruct {
+			continue
+		}
+		q.Add(attrName)
+		q.Add(" ")
+		q.Add(this.ColumnType(attrName, attr, root))
+		q.Add(",\n")
+	}
+	if root && this.IsSoftDelete(tableName) {
+		q.Add(common.DeletedAtColumn, " ", this.StampType(), ",\n")
+	}
+	q.Add("PRIMARY KEY (ParentKey, RecKey)\n) DEFAULT CHARSET=utf8mb4;")
+	_, err := this.DB().ExecContext(ctx, q.String())
+	if err != nil {
+		return err
+	}
+	return this.createIndexes(ctx, tableName, node)
+}
+
+// createIndexes creates the non-unique indexes for decorated fields that do
+// not exist yet. MySQL has no CREATE INDEX IF NOT EXISTS, so existing index
+// names are read from information_schema.statistics first.
+func (this *Mysql) createIndexes(ctx context.Context, tableName string, node *l8reflect.L8Node) error {
+	nonUniqueFields, err := this.Resources().Introspector().Decorators().Fields(node, l8reflect.L8DecoratorType_NonUnique)
+	if err != nil || nonUniqueFields == nil {
+		return nil
+	}
+	liveIndexes, err := this.schemaNames(ctx,
+		"SELECT index_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND LOWER(table_name) = ?",
+		tableName)
+	if err != nil {
+		return err
+	}
+	for _, fieldName := range nonUniqueFields {
+		indexName := tableName + "_" + fieldName + "_idx"
+		if liveIndexes[strings2.ToLower(indexName)] {
+			continue
+		}
+		column := fieldName
+		if attr, ok := node.Attributes[fieldName]; ok && stmt.MySQL.TypeName(attr) == "TEXT" {
+			column = fieldName + "(" + indexPrefix + ")"
+		}
+		indexQ := strings.New("CREATE INDEX ", indexName, " ON ", tableName, " (", column, ");")
+		_, err = this.DB().ExecContext(ctx, indexQ.String())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaNames runs an information_schema query for a table and returns the
+// lowercased names it yields.
+func (this *Mysql) schemaNames(ctx context.Context, query, tableName string) (map[string]bool, error) {
+	rows, err := this.DB().QueryContext(ctx, query, strings2.ToLower(tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[strings2.ToLower(name)] = true
+	}
+	return names, rows.Err()
+}
@@ -0,0 +1,194 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8orm/go/orm/stmt"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8notify"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// SetBulkThreshold sets the number of elements above which POST and PUT
+// writes use the COPY bulk path. 0 disables the bulk path.
+func (this *Postgres) SetBulkThreshold(threshold int) {
+	this.bulkThreshold = threshold
+}
+
+// bulkNode returns the root node of a write that should use the bulk path:
+// a POST or PUT of more elements than the bulk threshold. Versioned root types
+// keep the row by row path, as each row's version is checked on its own.
+func (this *Postgres) bulkNode(action ifs.Action, elems ifs.IElements) (*l8reflect.L8Node, bool) {
+	if action != ifs.POST && action != ifs.PUT {
+		return nil, false
+	}
+	if this.bulkThreshold <= 0 || len(elems.Elements()) <= this.bulkThreshold {
+		return nil, false
+	}
+	typeName, err := convert.TypeOf(reflect.ValueOf(elems.Element()))
+	if err != nil {
+		return nil, false
+	}
+	node, ok := this.Resources().Introspector().NodeByTypeName(typeName)
+	if !ok {
+		node, err = this.Resources().Introspector().Inspect(elems.Element())
+		if err != nil {
+			return nil, false
+		}
+	}
+	if stmt.NewStatement(node, nil, nil, this.Resources().Registry(), stmt.Postgres).IsVersioned(this.VersionField()) {
+		return nil, false
+	}
+	return node, true
+}
+
+// writeBulk writes all elements in a single transaction. The elements are
+// converted in batches and their rows are streamed with COPY FROM STDIN into
+// per-table staging tables, which are then merged into the tables with one
+// upsert per table. It returns the time series data of the elements.
+func (this *Postgres) writeBulk(ctx context.Context, action ifs.Action, rootNode *l8reflect.L8Node, elems ifs.IElements, resources ifs.IResources) ([]*l8notify.L8TSDBNotification, error) {
+	err := this.VerifyTables(ctx, rootNode)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := this.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	tsData, err := this.loadBulk(ctx, tx, action, rootNode, elems, resources)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tsData, tx.Commit()
+}
+
+// loadBulk stages the elements batch by batch and merges the staging tables.
+// The work keyed by root elements (history, PUT child row removal and
+// tombstone clearing) is done per batch, before the merge, by PrepareWrite.
+func (this *Postgres) loadBulk(ctx context.Context, tx *sql.Tx, action ifs.Action, rootNode *l8reflect.L8Node, elems ifs.IElements, resources ifs.IResources) ([]*l8notify.L8TSDBNotification, error) {
+	history := this.IsHistory(rootNode.TypeName)
+	now := time.Now().Unix()
+	staged := make(map[string]bool)
+	batchKeys := make([][]string, 0)
+	tsData := make([]*l8notify.L8TSDBNotification, 0)
+
+	elements := elems.Elements()
+	for start := 0; start < len(elements); start += this.BatchSize() {
+		end := start + this.BatchSize()
+		if end > len(elements) {
+			end = len(elements)
+		}
+		relData := convert.ConvertTo(action, object.New(nil, elements[start:end]), resources)
+		if relData.Error() != nil {
+			return nil, relData.Error()
+		}
+		data := relData.Element().(*l8orms.L8OrmRData)
+		rootKeys := convert.RootKeys(data)
+
+		err := this.PrepareWrite(ctx, tx, action, rootNode, rootKeys, now)
+		if err != nil {
+			return nil, err
+		}
+		err = this.copyData(ctx, tx, action, data, staged)
+		if err != nil {
+			return nil, err
+		}
+		batchKeys = append(batchKeys, rootKeys)
+		tsData = append(tsData, data.TsData...)
+	}
+
+	for tableName := range staged {
+		node, ok := this.Resources().Introspector().NodeByTypeName(tableName)
+		if !ok {
+			return nil, errors.New("No node was found for " + tableName)
+		}
+		statement := stmt.NewStatement(node, nil, nil, this.Resources().Registry(), stmt.Postgres)
+		_, err := tx.ExecContext(ctx, statement.MergeSql())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if history {
+		for _, rootKeys := range batchKeys {
+			err := this.Stamp(ctx, tx, rootNode, rootKeys, now)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return tsData, nil
+}
+
+// copyData streams the rows of one converted batch into the staging tables,
+// creating each staging table on first use. A COPY must finish before the
+// next statement runs, so each table is copied and flushed in turn.
+func (this *Postgres) copyData(ctx context.Context, tx *sql.Tx, action ifs.Action, data *l8orms.L8OrmRData, staged map[string]bool) error {
+	for tableName, table := range data.Tables {
+		node, ok := this.Resources().Introspector().NodeByTypeName(tableName)
+		if !ok {
+			return errors.New("No node was found for " + tableName)
+		}
+		statement := stmt.NewStatement(node, table.Columns, nil, this.Resources().Registry(), stmt.Postgres)
+		if !staged[tableName] {
+			_, err := tx.ExecContext(ctx, statement.CreateStagingSql())
+			if err != nil {
+				return err
+			}
+			staged[tableName] = true
+		}
+
+		copyStmt, err := tx.PrepareContext(ctx, statement.CopySql())
+		if err != nil {
+			return err
+		}
+		for _, instRows := range table.InstanceRows {
+			for _, attrRows := range instRows.AttributeRows {
+				for _, row := range attrRows.Rows {
+					args, err := statement.RowValues(action, row)
+					if err != nil {
+						copyStmt.Close()
+						return err
+					}
+					_, err = copyStmt.ExecContext(ctx, args...)
+					if err != nil {
+						copyStmt.Close()
+						return err
+					}
+				}
+			}
+		}
+		_, err = copyStmt.ExecContext(ctx)
+		if err != nil {
+			copyStmt.Close()
+			return err
+		}
+		err = copyStmt.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
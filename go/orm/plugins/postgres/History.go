@@ -0,0 +1,170 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8orm/go/orm/plugins/relational"
+	"github.com/saichler/l8orm/go/orm/stmt"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+	"github.com/saichler/l8utils/go/utils/strings"
+)
+
+// EnableHistory turns on history mode for a root type. Writes and deletes of
+// its elements keep the replaced rows in the <table>_history tables.
+func (this *Postgres) EnableHistory(typeName string) {
+	this.history.Store(typeName, true)
+}
+
+// IsHistory reports whether a root type is in history mode.
+func (this *Postgres) IsHistory(typeName string) bool {
+	_, ok := this.history.Load(typeName)
+	return ok
+}
+
+// VerifyHistoryTable adds the valid-from column to the table and creates its
+// history table, or adds the columns the history table is missing. History
+// tables have no primary key, as they hold many versions of the same row.
+func (this *Postgres) VerifyHistoryTable(ctx context.Context, tableName string) error {
+	node, ok := this.Resources().Introspector().NodeByTypeName(tableName)
+	if !ok {
+		return errors.New("Cannot find node for table " + tableName)
+	}
+	historyName := common.HistoryTable(tableName)
+
+	q := strings.New("ALTER TABLE ", tableName, " ADD COLUMN IF NOT EXISTS ", common.ValidFromColumn, " ", this.StampType(), ";")
+	_, err := this.DB().ExecContext(ctx, q.String())
+	if err != nil {
+		return err
+	}
+
+	q = strings.New("CREATE TABLE IF NOT EXISTS ", historyName, " (\n")
+	q.Add("ParentKey text,\n")
+	q.Add("RecKey text,\n")
+	q.Add(common.ValidFromColumn, " ", this.StampType(), ",\n")
+	q.Add(common.ValidToColumn, " ", this.StampType(), "\n);")
+	_, err = this.DB().ExecContext(ctx, q.String())
+	if err != nil {
+		return err
+	}
+
+	for attrName, attr := range node.Attributes {
+		if attr.IsStruct {
+			continue
+		}
+		q = strings.New("ALTER TABLE ", historyName, " ADD COLUMN IF NOT EXISTS ", attrName, " ", stmt.Postgres.TypeName(attr), ";")
+		_, err = this.DB().ExecContext(ctx, q.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	q = strings.New("CREATE INDEX IF NOT EXISTS ", historyName, "_key_idx ON ", historyName, " (ParentKey, RecKey);")
+	_, err = this.DB().ExecContext(ctx, q.String())
+	return err
+}
+
+// Archive copies the current rows of the given root elements, in every table
+// of the type hierarchy, into the history tables as valid until now.
+func (this *Postgres) Archive(ctx context.Context, tx *sql.Tx, rootNode *l8reflect.L8Node, rootKeys []string, now int64) error {
+	return this.forElementTables(rootNode, rootKeys, func(statement *stmt.Statement, root bool) error {
+		sqlStr, args := statement.ArchiveSql(rootKeys, root, now)
+		_, err := tx.ExecContext(ctx, sqlStr, args...)
+		return err
+	})
+}
+
+// Stamp marks the current rows of the given root elements, in every table of
+// the type hierarchy, as valid from now.
+func (this *Postgres) Stamp(ctx context.Context, tx *sql.Tx, rootNode *l8reflect.L8Node, rootKeys []string, now int64) error {
+	return this.forElementTables(rootNode, rootKeys, func(statement *stmt.Statement, root bool) error {
+		sqlStr, args := statement.StampSql(rootKeys, root, now)
+		_, err := tx.ExecContext(ctx, sqlStr, args...)
+		return err
+	})
+}
+
+// forElementTables calls do with a statement for every table of the type
+// hierarchy, when there are root elements to apply it to.
+func (this *Postgres) forElementTables(rootNode *l8reflect.L8Node, rootKeys []string, do func(*stmt.Statement, bool) error) error {
+	if len(rootKeys) == 0 {
+		return nil
+	}
+	tables := make(map[string]bool)
+	relational.CollectTables(rootNode, tables)
+	for tableName := range tables {
+		node, ok := this.Resources().Introspector().NodeByTypeName(tableName)
+		if !ok {
+			return errors.New("table not found " + tableName)
+		}
+		statement := stmt.NewStatement(node, nil, nil, this.Resources().Registry(), stmt.Postgres)
+		err := do(statement, tableName == rootNode.TypeName)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readAsOf reads the elements matching the query as they stood at the given
+// Unix time, combining the current rows valid since then with the history
+// rows valid at that time.
+func (this *Postgres) readAsOf(ctx context.Context, query ifs.IQuery, asOf int64, resources ifs.IResources) ifs.IElements {
+	rootName := query.RootType().TypeName
+	if !this.IsHistory(rootName) {
+		return object.NewError("history is not enabled for " + rootName)
+	}
+	data, err := convert.NewRelationsDataForQuery(query)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+
+	rootNode, ok := this.Resources().Introspector().NodeByTypeName(rootName)
+	if !ok {
+		return object.NewError("root table not found " + rootName)
+	}
+	err = this.VerifyTables(ctx, rootNode)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+
+	for tableName, table := range data.Tables {
+		node, ok := this.Resources().Introspector().NodeByTypeName(tableName)
+		if !ok {
+			return object.NewError("table not found " + tableName)
+		}
+		statement := stmt.NewStatement(node, table.Columns, query, this.Resources().Registry(), stmt.Postgres)
+		sqlStr, args := statement.Query2AsOfSql(query, tableName, asOf)
+		rows, err := this.DB().QueryContext(ctx, sqlStr, args...)
+		if err != nil {
+			return object.NewError(err.Error())
+		}
+		dataRows, err := relational.ReadRows(rows, statement)
+		if err != nil {
+			return object.NewError(err.Error())
+		}
+		for _, row := range dataRows {
+			relational.AddRowToTable(table, row)
+		}
+	}
+	return convert.ConvertFrom(object.New(nil, data), nil, resources)
+}
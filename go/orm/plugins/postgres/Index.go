@@ -0,0 +1,430 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/eval"
+	"github.com/saichler/l8orm/go/orm/plugins/relational"
+	"github.com/saichler/l8orm/go/orm/stmt"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// IndexConfig bounds the pagination index, the in-memory cache of the record
+// keys of paged queries. Zero fields take the values of DefaultIndexConfig, and
+// a negative MaxEntries or MaxBytes removes that bound.
+type IndexConfig struct {
+	TTL        time.Duration // How long an unused query stays cached
+	Tick       time.Duration // Interval at which expired queries are removed
+	MaxEntries int           // Maximum number of cached queries
+	MaxBytes   int64         // Maximum estimated memory of the cached record keys
+}
+
+// IndexStats reports the activity and size of the pagination index.
+type IndexStats struct {
+	Hits      int64 // Paged reads served from a cached query
+	Misses    int64 // Paged reads that read the record keys from the database
+	Evictions int64 // Least recently used queries removed to stay within the bounds
+	Expired   int64 // Queries removed after being unused for the TTL
+	Entries   int   // Cached queries
+	Bytes     int64 // Estimated memory of the cached record keys
+}
+
+// DefaultIndexConfig returns the default bounds of the pagination index: a
+// 30 second TTL checked every 10 seconds, 1000 queries and 256 MiB of keys.
+func DefaultIndexConfig() IndexConfig {
+	return IndexConfig{
+		TTL:        30 * time.Second,
+		Tick:       10 * time.Second,
+		MaxEntries: 1000,
+		MaxBytes:   256 << 20,
+	}
+}
+
+// indexConfigOf returns the first of the configs with its zero fields set to
+// the defaults, or the defaults when there is none.
+func indexConfigOf(configs []IndexConfig) IndexConfig {
+	config := DefaultIndexConfig()
+	if len(configs) == 0 {
+		return config
+	}
+	if configs[0].TTL > 0 {
+		config.TTL = configs[0].TTL
+	}
+	if configs[0].Tick > 0 {
+		config.Tick = configs[0].Tick
+	}
+	if configs[0].MaxEntries != 0 {
+		config.MaxEntries = configs[0].MaxEntries
+	}
+	if configs[0].MaxBytes != 0 {
+		config.MaxBytes = configs[0].MaxBytes
+	}
+	return config
+}
+
+// IndexStats returns the counters and the current size of the pagination index.
+func (this *Postgres) IndexStats() IndexStats {
+	this.indexMtx.RLock()
+	defer this.indexMtx.RUnlock()
+	return IndexStats{
+		Hits:      atomic.LoadInt64(&this.indexHits),
+		Misses:    atomic.LoadInt64(&this.indexMisses),
+		Evictions: atomic.LoadInt64(&this.indexEvicted),
+		Expired:   atomic.LoadInt64(&this.indexExpired),
+		Entries:   len(this.indexQueries),
+		Bytes:     this.indexBytes,
+	}
+}
+
+// putIndex caches a query under hash, replacing the entry cached under it, and
+// evicts the least recently used queries until the index is within its bounds.
+// A query whose keys alone exceed MaxBytes is not cached. The caller holds indexMtx.
+func (this *Postgres) putIndex(hash int64, cached *cachedQuery) {
+	this.removeIndex(hash)
+	cached.bytes = keysSize(cached.recKeys, cached.sortValues)
+	if this.indexConfig.MaxBytes > 0 && cached.bytes > this.indexConfig.MaxBytes {
+		atomic.AddInt64(&this.indexEvicted, 1)
+		return
+	}
+	this.indexQueries[hash] = cached
+	this.indexBytes += cached.bytes
+	if !this.overBounds() {
+		return
+	}
+	hashes := make([]int64, 0, len(this.indexQueries))
+	for other := range this.indexQueries {
+		if other != hash {
+			hashes = append(hashes, other)
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return atomic.LoadInt64(&this.indexQueries[hashes[i]].lastUsed) < atomic.LoadInt64(&this.indexQueries[hashes[j]].lastUsed)
+	})
+	for _, other := range hashes {
+		if !this.overBounds() {
+			return
+		}
+		this.removeIndex(other)
+		atomic.AddInt64(&this.indexEvicted, 1)
+	}
+}
+
+// removeIndex removes the query cached under hash, if any. The caller holds indexMtx.
+func (this *Postgres) removeIndex(hash int64) {
+	if cached, ok := this.indexQueries[hash]; ok {
+		this.indexBytes -= cached.bytes
+		delete(this.indexQueries, hash)
+	}
+}
+
+// overBounds reports whether the index exceeds its entry count or byte budget.
+// The caller holds indexMtx.
+func (this *Postgres) overBounds() bool {
+	return this.indexConfig.MaxEntries > 0 && len(this.indexQueries) > this.indexConfig.MaxEntries ||
+		this.indexConfig.MaxBytes > 0 && this.indexBytes > this.indexConfig.MaxBytes
+}
+
+// keysSize estimates the memory held by cached keys: the bytes and string
+// header of each record key, and the interface header of each sort value.
+func keysSize(recKeys []string, sortValues []interface{}) int64 {
+	size := int64(len(sortValues)) * 16
+	for _, recKey := range recKeys {
+		size += int64(len(recKey)) + 16
+	}
+	return size
+}
+
+// maintainable reports whether the cached keys of a query can be maintained in
+// place on writes, and returns the root column and direction they are sorted by.
+// That holds for queries of all users that exclude tombstones, whose criteria
+// evaluate in process with the same result as in SQL, and that are unsorted or
+// sorted by a single root column that is not a string, as the SQL ordering of
+// strings depends on the database collation.
+func (this *Postgres) maintainable(q ifs.IQuery, aaaId string) (string, bool, bool) {
+	if aaaId != "" || common.IncludesDeleted(q) {
+		return "", false, false
+	}
+	rootNode, ok := this.Resources().Introspector().NodeByTypeName(q.RootType().TypeName)
+	if !ok || !eval.Evaluable(q.Criteria(), rootNode.TypeName) {
+		return "", false, false
+	}
+	keys := common.SortKeys(q)
+	switch len(keys) {
+	case 0:
+		return "", false, true
+	case 1:
+		attr, path, ok := common.SortAttribute(rootNode, keys[0].Field)
+		if !ok || len(path) > 0 || attr.IsSlice || attr.IsMap || attr.TypeName == "string" {
+			return "", false, false
+		}
+		return attr.FieldName, keys[0].Descending, true
+	}
+	return "", false, false
+}
+
+// RefreshQueries implements common.IQueryCache by maintaining the cached
+// queries of the type with the rows written by another replica.
+func (this *Postgres) RefreshQueries(typeName string, recKeys []string) {
+	this.maintainIndex(context.Background(), typeName, recKeys)
+}
+
+// maintainIndex brings the cached queries of a root type up to date after the
+// root rows with the given RecKeys were written or deleted. The rows are read
+// back, and every maintained entry that was fresh before the write has each of
+// them kept in place, inserted at its sorted position or removed, as the row
+// now matches the entry's criteria. The other cached queries depending on the
+// type's tables are invalidated, and so are all of them when recKeys is nil or
+// the rows cannot be read back.
+func (this *Postgres) maintainIndex(ctx context.Context, typeName string, recKeys []string) {
+	rootNode, ok := this.Resources().Introspector().NodeByTypeName(typeName)
+	if !ok || recKeys == nil {
+		this.invalidateIndex(typeName)
+		return
+	}
+
+	// Reading the rows back and applying them is serialized, so concurrent
+	// writes of the same rows leave the entries with the last state read.
+	this.maintainMtx.Lock()
+	defer this.maintainMtx.Unlock()
+
+	if !this.hasMaintained(typeName) {
+		this.invalidateIndex(typeName)
+		return
+	}
+	rows, err := this.readRootValues(ctx, rootNode, recKeys)
+	if err != nil {
+		this.Resources().Logger().Error("Failed to maintain cached queries of ", typeName, ": ", err.Error())
+		this.invalidateIndex(typeName)
+		return
+	}
+
+	this.indexMtx.Lock()
+	defer this.indexMtx.Unlock()
+	fresh := make(map[int64]*cachedQuery)
+	for hash, cached := range this.indexQueries {
+		if cached.query != nil && cached.typeName == typeName && this.isFresh(cached) {
+			fresh[hash] = cached
+		}
+	}
+	stamp := this.stampTables(rootNode)
+	for hash, cached := range fresh {
+		if this.indexQueries[hash] != cached {
+			// Evicted to make room for an entry maintained before it
+			continue
+		}
+		updated, err := cached.apply(recKeys, rows)
+		if err != nil {
+			// Left stale, so its next read rebuilds it
+			continue
+		}
+		updated.stamp = stamp
+		this.putIndex(hash, updated)
+	}
+}
+
+// hasMaintained reports whether a maintained entry of the root type is cached.
+func (this *Postgres) hasMaintained(typeName string) bool {
+	this.indexMtx.RLock()
+	defer this.indexMtx.RUnlock()
+	for _, cached := range this.indexQueries {
+		if cached.query != nil && cached.typeName == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+// readRootValues reads the live root rows with the given RecKeys and returns
+// their decoded column values by RecKey. Keys without a live row are absent.
+func (this *Postgres) readRootValues(ctx context.Context, rootNode *l8reflect.L8Node, recKeys []string) (map[string]eval.Values, error) {
+	columns := make(map[string]int32)
+	for attrName, attr := range rootNode.Attributes {
+		if !attr.IsStruct {
+			columns[attrName] = int32(len(columns))
+		}
+	}
+	result := make(map[string]eval.Values, len(recKeys))
+	for start := 0; start < len(recKeys); start += this.BatchSize() {
+		end := start + this.BatchSize()
+		if end > len(recKeys) {
+			end = len(recKeys)
+		}
+		statement := stmt.NewStatement(rootNode, columns, nil, this.Resources().Registry(), stmt.Postgres).WithContext(ctx)
+		sqlStr, args := statement.Query2SqlByRecKeys(rootNode.TypeName, recKeys[start:end])
+		if this.IsSoftDelete(rootNode.TypeName) {
+			sqlStr += " AND " + stmt.Postgres.Quote(common.DeletedAtColumn) + " IS NULL"
+		}
+		rows, err := this.DB().QueryContext(ctx, sqlStr, args...)
+		if err != nil {
+			return nil, err
+		}
+		dataRows, err := relational.ReadRows(rows, statement)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range dataRows {
+			data := make(map[string][]byte, len(columns))
+			for attrName, pos := range columns {
+				data[attrName] = row.ColumnValues[pos]
+			}
+			values, err := eval.NewValues(data, this.Resources().Registry())
+			if err != nil {
+				return nil, err
+			}
+			result[row.RecKey] = values
+		}
+	}
+	return result, nil
+}
+
+// apply returns a copy of a maintained entry with the written root rows
+// applied. A row that matches the criteria keeps its position, or is inserted
+// at its sorted position, after the keys it sorts equal to; other rows are
+// removed. Keys of an unsorted entry that are new are appended in key order.
+func (cq *cachedQuery) apply(recKeys []string, rows map[string]eval.Values) (*cachedQuery, error) {
+	changed := make(map[string]bool, len(recKeys))
+	matched := make(map[string]interface{})
+	for _, recKey := range recKeys {
+		changed[recKey] = true
+		values, ok := rows[recKey]
+		if !ok {
+			continue
+		}
+		_, match, err := eval.Expression(cq.query.Criteria(), cq.typeName, values)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			matched[recKey] = indexValue(values[strings.ToLower(cq.sortField)])
+		}
+	}
+
+	updated := &cachedQuery{
+		tables:     cq.tables,
+		lastUsed:   atomic.LoadInt64(&cq.lastUsed),
+		typeName:   cq.typeName,
+		query:      cq.query,
+		sortField:  cq.sortField,
+		descending: cq.descending,
+	}
+	keys := make([]string, 0, len(cq.recKeys)+len(matched))
+	if cq.sortField == "" {
+		for _, recKey := range cq.recKeys {
+			if changed[recKey] {
+				if _, ok := matched[recKey]; !ok {
+					continue
+				}
+				delete(matched, recKey)
+			}
+			keys = append(keys, recKey)
+		}
+		added := make([]string, 0, len(matched))
+		for recKey := range matched {
+			added = append(added, recKey)
+		}
+		sort.Strings(added)
+		updated.recKeys = append(keys, added...)
+	} else {
+		added := make([]string, 0, len(matched))
+		for recKey := range matched {
+			added = append(added, recKey)
+		}
+		sort.Slice(added, func(i, j int) bool {
+			cmp := cq.compare(matched[added[i]], matched[added[j]])
+			return cmp < 0 || cmp == 0 && added[i] < added[j]
+		})
+		values := make([]interface{}, 0, cap(keys))
+		next := 0
+		for i, recKey := range cq.recKeys {
+			if changed[recKey] {
+				continue
+			}
+			for next < len(added) && cq.compare(matched[added[next]], cq.sortValues[i]) < 0 {
+				keys = append(keys, added[next])
+				values = append(values, matched[added[next]])
+				next++
+			}
+			keys = append(keys, recKey)
+			values = append(values, cq.sortValues[i])
+		}
+		for ; next < len(added); next++ {
+			keys = append(keys, added[next])
+			values = append(values, matched[added[next]])
+		}
+		updated.recKeys = keys
+		updated.sortValues = values
+	}
+
+	updated.metadata = &l8api.L8MetaData{KeyCount: &l8api.L8Count{Counts: map[string]float64{}}}
+	if cq.metadata != nil && cq.metadata.KeyCount != nil {
+		for name, count := range cq.metadata.KeyCount.Counts {
+			updated.metadata.KeyCount.Counts[name] = count
+		}
+	}
+	updated.metadata.KeyCount.Counts["Total"] = float64(len(updated.recKeys))
+	return updated, nil
+}
+
+// compare orders two sort column values in the entry's direction. Missing
+// values sort last in ascending order, as NULL does in PostgreSQL.
+func (cq *cachedQuery) compare(a, b interface{}) int {
+	var cmp int
+	switch {
+	case a == nil && b == nil:
+		cmp = 0
+	case a == nil:
+		cmp = 1
+	case b == nil:
+		cmp = -1
+	default:
+		cmp = eval.CompareValues(a, b)
+	}
+	if cq.descending {
+		return -cmp
+	}
+	return cmp
+}
+
+// indexValue normalizes a sort column value, as scanned from the database or
+// decoded from a row, so the values of a column compare by a single kind.
+func indexValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		return v.Bool()
+	}
+	if data, ok := value.([]byte); ok {
+		return string(data)
+	}
+	return value
+}
@@ -14,11 +14,14 @@ limitations under the License.
 */
 
 // Package postgres provides a PostgreSQL implementation of the IORM interface.
-// It handles database connections, table creation, query execution, and includes
-// an in-memory query cache with TTL support for optimized pagination performance.
+// Reads, writes and deletes are done by the relational plugin with the
+// PostgreSQL dialect; this plugin creates the tables and adds an in-memory
+// query cache with TTL support for optimized pagination performance, COPY bulk
+// writes, history and the TimescaleDB time series store.
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	strings2 "strings"
@@ -27,9 +30,10 @@ import (
 	"time"
 
 	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/plugins/relational"
+	"github.com/saichler/l8orm/go/orm/stmt"
 	"github.com/saichler/l8types/go/ifs"
 	"github.com/saichler/l8types/go/types/l8api"
-	"github.com/saichler/l8types/go/types/l8notify"
 	"github.com/saichler/l8types/go/types/l8reflect"
 	"github.com/saichler/l8utils/go/utils/strings"
 )
@@ -37,16 +41,25 @@ import (
 // cachedQuery represents a cached query result with its sorted RecKey array.
 // This cache enables efficient pagination by storing the full result set's keys
 // and serving page requests from memory rather than re-querying the database.
+// Entries are not modified once cached; maintaining one replaces it.
 type cachedQuery struct {
-	recKeys  []string            // Sorted array of record keys for the query
-	stamp    int64               // Cache creation timestamp for invalidation
-	lastUsed int64               // Last access time for TTL cleanup
-	metadata *l8api.L8MetaData   // Query metadata (total count, etc.)
+	recKeys  []string          // Sorted array of record keys for the query
+	tables   []string          // Tables of the queried type hierarchy, for invalidation
+	stamp    int64             // Index stamp at creation, for invalidation
+	lastUsed int64             // Last access time in nanoseconds, for TTL cleanup and LRU eviction
+	bytes    int64             // Estimated memory of the record keys, for the byte budget
+	metadata *l8api.L8MetaData // Query metadata (total count, etc.)
+
+	typeName   string        // Root type of the query
+	query      ifs.IQuery    // Query of an entry maintained on writes, nil when writes invalidate it
+	sortField  string        // Root column a maintained entry is sorted by, "" when unsorted
+	descending bool          // Whether a maintained entry is sorted in descending order
+	sortValues []interface{} // Sort column value of each record key of a sorted maintained entry
 }
 
-// touch updates the lastUsed timestamp to prevent TTL expiration.
+// touch updates the lastUsed timestamp to prevent TTL expiration and LRU eviction.
 func (cq *cachedQuery) touch() {
-	atomic.StoreInt64(&cq.lastUsed, time.Now().Unix())
+	atomic.StoreInt64(&cq.lastUsed, time.Now().UnixNano())
 }
 
 // pageKeys returns the subset of record keys for the requested page.
@@ -67,51 +80,62 @@ func (cq *cachedQuery) pageKeys(page, limit int32) []string {
 }
 
 // Postgres implements the IORM interface for PostgreSQL databases.
-// It provides connection pooling, automatic table creation, query caching,
-// and batch write support for efficient database operations.
+// The embedded relational plugin does the reads and writes with the PostgreSQL
+// dialect; Postgres creates and migrates the tables, and adds query caching,
+// bulk writes, history and the TimescaleDB time series store.
+// There is no process wide lock: requests run concurrently on the connection
+// pool, and each write is isolated by its own database transaction.
 type Postgres struct {
-	db        *sql.DB              // Database connection pool
-	verifyed  map[string]bool      // Tracks verified/created tables
-	mtx       *sync.Mutex          // Protects database operations
-	res       ifs.IResources       // Layer 8 resources (introspector, registry, etc.)
-	batchSize int                  // Maximum elements per write batch
+	*relational.Relational
+
+	history       sync.Map // Root type names in history mode
+	bulkThreshold int      // Elements above which POST/PUT writes use COPY, 0 disables
 
 	tsdb *Tsdb
 
 	// Primary index for paging - caches query results for pagination
-	indexMtx      *sync.RWMutex              // Protects index cache
-	indexQueries  map[int64]*cachedQuery     // Query hash (+ AAA ID) -> cached results
-	indexStamp    int64                      // Global invalidation stamp
-	indexTTL      int64                      // Cache entry TTL in seconds
-	indexStopCh   chan struct{}              // Signal to stop TTL cleaner
+	indexMtx     *sync.RWMutex          // Protects index cache
+	indexQueries map[int64]*cachedQuery // Query hash (+ AAA ID) -> cached results
+	indexStamp   int64                  // Invalidation counter, advanced by every write
+	tableStamps  map[string]int64       // Table name -> index stamp of its last write
+	indexFloor   int64                  // Index stamp of the last write of an unknown type
+	indexConfig  IndexConfig            // TTL, cleaner tick and size bounds of the index cache
+	indexBytes   int64                  // Estimated memory of the cached record keys
+	indexHits    int64                  // Paged reads served from the index, updated atomically
+	indexMisses  int64                  // Paged reads that rebuilt an index entry, updated atomically
+	indexEvicted int64                  // Entries evicted to stay within bounds, updated atomically
+	indexExpired int64                  // Entries removed by the TTL cleaner, updated atomically
+	maintainMtx  *sync.Mutex            // Serializes maintaining cached queries after writes
+	indexStopCh  chan struct{}          // Signal to stop TTL cleaner
 }
 
 // NewPostgres creates a new PostgreSQL ORM instance with the given database connection.
-// It initializes the query cache with a 30-second TTL and starts a background
-// goroutine to clean up expired cache entries every 10 seconds.
-func NewPostgres(db *sql.DB, resourcs ifs.IResources) *Postgres {
+// It initializes the query cache with the bounds of the optional IndexConfig,
+// DefaultIndexConfig by default, and starts a background goroutine to clean up
+// expired cache entries every tick.
+func NewPostgres(db *sql.DB, resourcs ifs.IResources, config ...IndexConfig) *Postgres {
 	p := &Postgres{
-		db:           db,
-		verifyed:     make(map[string]bool),
-		mtx:          &sync.Mutex{},
-		res:          resourcs,
-		batchSize:    500,
-		tsdb:         NewTsdb(db, false),
-		indexMtx:     &sync.RWMutex{},
-		indexQueries: make(map[int64]*cachedQuery),
-		indexStamp:   time.Now().Unix(),
-		indexTTL:     30,
-		indexStopCh:  make(chan struct{}),
+		bulkThreshold: 10000,
+		tsdb:          NewTsdb(db, false),
+		indexMtx:      &sync.RWMutex{},
+		indexQueries:  make(map[int64]*cachedQuery),
+		indexStamp:    time.Now().Unix(),
+		tableStamps:   make(map[string]int64),
+		indexConfig:   indexConfigOf(config),
+		maintainMtx:   &sync.Mutex{},
+		indexStopCh:   make(chan struct{}),
 	}
+	p.Relational = relational.New(db, resourcs, stmt.Postgres, p, p.tsdb)
+	p.SetSerialized(false)
 	go p.indexTTLCleaner()
 	return p
 }
 
 // indexTTLCleaner runs in a goroutine to periodically remove expired cache entries.
-// It checks every 10 seconds and removes entries that haven't been accessed
+// It checks every tick and removes entries that haven't been accessed
 // within the TTL window.
 func (this *Postgres) indexTTLCleaner() {
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(this.indexConfig.Tick)
 	defer ticker.Stop()
 	for {
 		select {
@@ -127,74 +151,89 @@ func (this *Postgres) indexTTLCleaner() {
 func (this *Postgres) cleanExpiredQueries() {
 	this.indexMtx.Lock()
 	defer this.indexMtx.Unlock()
-	now := time.Now().Unix()
+	now := time.Now().UnixNano()
 	for hash, q := range this.indexQueries {
-		if now-atomic.LoadInt64(&q.lastUsed) > this.indexTTL {
-			delete(this.indexQueries, hash)
+		if now-atomic.LoadInt64(&q.lastUsed) > int64(this.indexConfig.TTL) {
+			this.removeIndex(hash)
+			atomic.AddInt64(&this.indexExpired, 1)
 		}
 	}
 }
 
-// invalidateIndex marks all cached queries as stale by updating the global stamp.
-// Called after write or delete operations to ensure cache consistency.
-func (this *Postgres) invalidateIndex() {
+// invalidateIndex marks the cached queries that depend on a table of the root
+// type's hierarchy as stale, by stamping those tables with the advanced index
+// stamp. Called after write or delete operations to ensure cache consistency.
+// A type that is not known to the introspector invalidates every cached query.
+// The stamp is a counter rather than the time, so a write in the same second
+// as a concurrent read still invalidates the keys that read cached.
+func (this *Postgres) invalidateIndex(typeName string) {
+	node, ok := this.Resources().Introspector().NodeByTypeName(typeName)
 	this.indexMtx.Lock()
 	defer this.indexMtx.Unlock()
-	this.indexStamp = time.Now().Unix()
+	if !ok {
+		this.indexStamp++
+		this.indexFloor = this.indexStamp
+		return
+	}
+	this.stampTables(node)
 }
 
-// collectTables recursively collects all table names needed for a type hierarchy.
-// It traverses nested struct attributes to find all related table types.
-func collectTables(node *l8reflect.L8Node, tables map[string]bool) {
-	tables[node.TypeName] = true
-	if node.Attributes != nil {
-		for _, attr := range node.Attributes {
-			if attr.IsStruct {
-				if common.IsTimeSeriesType(attr.TypeName) {
-					continue
-				}
-				_, ok := tables[attr.TypeName]
-				if !ok {
-					collectTables(attr, tables)
-				}
-			}
-		}
+// stampTables advances the index stamp and stamps the tables of the root
+// type's hierarchy with it, returning the new stamp. The caller holds indexMtx.
+func (this *Postgres) stampTables(rootNode *l8reflect.L8Node) int64 {
+	tables := make(map[string]bool)
+	relational.CollectTables(rootNode, tables)
+	this.indexStamp++
+	for tableName := range tables {
+		this.tableStamps[tableName] = this.indexStamp
 	}
+	return this.indexStamp
 }
 
-// verifyTables ensures all required tables exist in the database.
-// It checks each table in the type hierarchy and creates missing tables.
-func (this *Postgres) verifyTables(rootNode *l8reflect.L8Node) error {
-	tables := make(map[string]bool)
-	collectTables(rootNode, tables)
-	for tableName, _ := range tables {
-		_, ok := this.verifyed[tableName]
-		if !ok {
-			err := this.verifyTable(tableName)
-			if err != nil {
-				return err
-			}
-			this.verifyed[tableName] = true
+// isFresh reports whether none of the tables a cached query depends on was
+// written since the query was cached. The caller holds indexMtx.
+func (this *Postgres) isFresh(cached *cachedQuery) bool {
+	if this.indexFloor > cached.stamp {
+		return false
+	}
+	for _, tableName := range cached.tables {
+		if this.tableStamps[tableName] > cached.stamp {
+			return false
 		}
 	}
-	return nil
+	return true
 }
 
-// verifyTable checks if a table exists and creates it if not.
+// VerifyTable checks if a table exists and creates it if not.
 // If the table already exists, it reconciles its columns with the current
 // proto definition and adds any missing columns via ALTER TABLE.
 // Uses a test query to detect non-existent tables.
-func (this *Postgres) verifyTable(tableName string) error {
+func (this *Postgres) VerifyTable(ctx context.Context, tableName string, root bool) error {
 	q := strings.New("select * from ", tableName, " where false;")
-	_, err := this.db.Exec(q.String())
+	_, err := this.DB().ExecContext(ctx, q.String())
 	if err != nil {
-		if strings2.Contains(err.Error(), "does not exist") {
-			return this.createTable(tableName)
+		if !strings2.Contains(err.Error(), "does not exist") {
+			return err
 		}
+		err = this.createTable(ctx, tableName, root)
+	} else {
+		// Table exists — reconcile its columns with the current proto definition.
+		err = this.migrateTable(ctx, tableName, root)
+	}
+	if err != nil || root {
 		return err
 	}
-	// Table exists — reconcile its columns with the current proto definition.
-	return this.migrateTable(tableName)
+	return this.createParentKeyIndex(ctx, tableName)
+}
+
+// createParentKeyIndex creates the index that serves ParentKey prefix matches
+// on a child table, used to load the child rows of a page of root rows. The
+// text_pattern_ops operator class lets LIKE 'prefix%' use the index under any
+// database collation.
+func (this *Postgres) createParentKeyIndex(ctx context.Context, tableName string) error {
+	q := strings.New("CREATE INDEX IF NOT EXISTS ", tableName, "_parentkey_idx ON ", tableName, " (ParentKey text_pattern_ops);")
+	_, err := this.DB().ExecContext(ctx, q.String())
+	return err
 }
 
 // migrateTable compares the live table columns against the current proto
@@ -203,15 +242,15 @@ func (this *Postgres) verifyTable(tableName string) error {
 // proto are left alone, and type changes are not handled. Non-unique
 // indexes are created for any newly added columns that are decorated as
 // non-unique, matching the DDL pattern used by createTable.
-func (this *Postgres) migrateTable(tableName string) error {
-	node, ok := this.res.Introspector().NodeByTypeName(tableName)
+func (this *Postgres) migrateTable(ctx context.Context, tableName string, root bool) error {
+	node, ok := this.Resources().Introspector().NodeByTypeName(tableName)
 	if !ok {
 		return errors.New("Cannot find node for table " + tableName)
 	}
 
 	// Fetch the live column set. information_schema folds unquoted
 	// identifiers to lowercase, so we compare case-insensitively.
-	rows, err := this.db.Query(
+	rows, err := this.DB().QueryContext(ctx,
 		"SELECT column_name FROM information_schema.columns WHERE table_name = $1",
 		strings2.ToLower(tableName))
 	if err != nil {
@@ -243,18 +282,22 @@ func (this *Postgres) migrateTable(tableName string) error {
 			continue
 		}
 		missing = append(missing, attrName)
-		missingTypes[attrName] = postgresTypeOf(attr)
+		missingTypes[attrName] = this.ColumnType(attrName, attr, root)
+	}
+	if root && this.IsSoftDelete(tableName) && !liveColumns[strings2.ToLower(common.DeletedAtColumn)] {
+		missing = append(missing, common.DeletedAtColumn)
+		missingTypes[common.DeletedAtColumn] = this.StampType()
 	}
 
 	if len(missing) == 0 {
 		return nil
 	}
 
-	this.res.Logger().Info("Migrating table ", tableName, ": adding columns ", missing)
+	this.Resources().Logger().Info("Migrating table ", tableName, ": adding columns ", missing)
 
 	for _, attrName := range missing {
 		alterQ := strings.New("ALTER TABLE ", tableName, " ADD COLUMN ", attrName, " ", missingTypes[attrName], ";")
-		_, err = this.db.Exec(alterQ.String())
+		_, err = this.DB().ExecContext(ctx, alterQ.String())
 		if err != nil {
 			return err
 		}
@@ -263,7 +306,7 @@ func (this *Postgres) migrateTable(tableName string) error {
 	// Recreate non-unique indexes for any newly added columns that are
 	// decorated as non-unique. Use IF NOT EXISTS so a partially-applied
 	// prior migration does not fail.
-	nonUniqueFields, nonUniqueErr := this.res.Introspector().Decorators().Fields(node, l8reflect.L8DecoratorType_NonUnique)
+	nonUniqueFields, nonUniqueErr := this.Resources().Introspector().Decorators().Fields(node, l8reflect.L8DecoratorType_NonUnique)
 	if nonUniqueErr == nil && nonUniqueFields != nil {
 		missingSet := make(map[string]bool, len(missing))
 		for _, name := range missing {
@@ -273,9 +316,9 @@ func (this *Postgres) migrateTable(tableName string) error {
 			if !missingSet[fieldName] {
 				continue
 			}
-			this.res.Logger().Info("Creating non-unique index ", tableName, "_", fieldName, "_idx")
+			this.Resources().Logger().Info("Creating non-unique index ", tableName, "_", fieldName, "_idx")
 			indexQ := strings.New("CREATE INDEX IF NOT EXISTS ", tableName, "_", fieldName, "_idx ON ", tableName, " (", fieldName, ");")
-			_, err = this.db.Exec(indexQ.String())
+			_, err = this.DB().ExecContext(ctx, indexQ.String())
 			if err != nil {
 				return err
 			}
@@ -288,12 +331,12 @@ func (this *Postgres) migrateTable(tableName string) error {
 // createTable generates and executes DDL to create a table for the given type.
 // It creates columns for all non-struct attributes and adds a composite primary
 // key (ParentKey, RecKey). Non-unique indexes are created for decorated fields.
-func (this *Postgres) createTable(tableName string) error {
+func (this *Postgres) createTable(ctx context.Context, tableName string, root bool) error {
 	q := strings.New("create table ", tableName, " (\n")
 	q.Add("ParentKey text,\n")
 	q.Add("RecKey text,\n")
-	node, ok := this.res.Introspector().NodeByTypeName(tableName)
-	nonUniqueFieldsIndex, nonUniqueErr := this.res.Introspector().Decorators().Fields(node, l8reflect.L8DecoratorType_NonUnique)
+	node, ok := this.Resources().Introspector().NodeByTypeName(tableName)
+	nonUniqueFieldsIndex, nonUniqueErr := this.Resources().Introspector().Decorators().Fields(node, l8reflect.L8DecoratorType_NonUnique)
 
 	if !ok {
 		return errors.New("Cannot find node for table " + tableName)
@@ -304,11 +347,14 @@ func (this *Postgres) createTable(tableName string) error {
 		}
 		q.Add(attrName)
 		q.Add(" ")
-		q.Add(postgresTypeOf(attr))
+		q.Add(this.ColumnType(attrName, attr, root))
 		q.Add(",\n")
 	}
+	if root && this.IsSoftDelete(tableName) {
+		q.Add(common.DeletedAtColumn, " ", this.StampType(), ",\n")
+	}
 	q.Add("CONSTRAINT ", tableName, "_key PRIMARY KEY (ParentKey, RecKey)\n);")
-	_, err := this.db.Exec(q.String())
+	_, err := this.DB().ExecContext(ctx, q.String())
 	if err != nil {
 		return err
 	}
@@ -317,7 +363,7 @@ func (this *Postgres) createTable(tableName string) error {
 	if nonUniqueErr == nil && nonUniqueFieldsIndex != nil {
 		for _, fieldName := range nonUniqueFieldsIndex {
 			indexQ := strings.New("CREATE INDEX ", tableName, "_", fieldName, "_idx ON ", tableName, " (", fieldName, ");")
-			_, err = this.db.Exec(indexQ.String())
+			_, err = this.DB().ExecContext(ctx, indexQ.String())
 			if err != nil {
 				return err
 			}
@@ -327,36 +373,11 @@ func (this *Postgres) createTable(tableName string) error {
 	return nil
 }
 
-// postgresTypeOf maps Go types to PostgreSQL column types.
-// Maps and slices are stored as text (serialized), and enums default to integer.
-func postgresTypeOf(node *l8reflect.L8Node) string {
-	if node.IsMap || node.IsSlice {
-		return "text"
-	}
-	switch node.TypeName {
-	case "string":
-		return "text"
-	case "int32":
-		return "integer"
-	case "int64":
-		return "bigint"
-	case "float64":
-		return "float8"
-	case "float32":
-		return "real"
-	case "bool":
-		return "boolean"
-	}
-	//default to enum for now - @TODO - reflect find what is the kind
-	return "integer"
-}
-
-func (this *Postgres) AddTSDB(notifications []*l8notify.L8TSDBNotification) error {
-	return this.tsdb.AddTSDB(notifications)
-}
-
-func (this *Postgres) GetTSDB(propertyId string, start, end int64) ([]*l8api.L8TimeSeriesPoint, error) {
-	return this.tsdb.GetTSDB(propertyId, start, end)
+// SetTsdbConfig sets the lifecycle of the plugin's time series, which is
+// DefaultTsdbConfig otherwise. Call it before the first time series read or
+// write.
+func (this *Postgres) SetTsdbConfig(config TsdbConfig) {
+	this.tsdb.SetConfig(config)
 }
 
 func hashString(s string) int32 {
@@ -367,10 +388,10 @@ func hashString(s string) int32 {
 	return h
 }
 
-// Close stops the TTL cleaner goroutine and closes the database connection.
+// Close stops the TTL cleaner goroutine and closes the time series store and
+// the database connection.
 func (this *Postgres) Close() error {
 	close(this.indexStopCh)
-	this.tsdb.Close()
-	this.db.Close()
+	this.Relational.Close()
 	return nil
 }
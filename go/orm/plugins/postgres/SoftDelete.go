@@ -0,0 +1,36 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package postgres
+
+import (
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// Restore clears the tombstones of the deleted root rows matching the query,
+// and invalidates the cached queries of the type.
+func (this *Postgres) Restore(query ifs.IQuery) error {
+	defer this.invalidateIndex(query.RootType().TypeName)
+	return this.Relational.Restore(query)
+}
+
+// Purge physically removes the root rows of the type that were soft deleted
+// age or more ago, together with their child rows, and invalidates the cached
+// queries of the type.
+func (this *Postgres) Purge(typeName string, age time.Duration) error {
+	defer this.invalidateIndex(typeName)
+	return this.Relational.Purge(typeName, age)
+}
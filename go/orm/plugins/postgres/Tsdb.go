@@ -18,32 +18,48 @@ import (
 	"database/sql"
 	"sync"
 
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/stmt"
 	"github.com/saichler/l8types/go/types/l8api"
 	"github.com/saichler/l8types/go/types/l8notify"
 )
 
 // Tsdb implements the ITSDB interface using TimescaleDB (PostgreSQL extension).
 // It stores time series data in a single hypertable with a narrow schema
-// (stamp, prop_id, value) for efficient compression and querying.
+// (stamp, prop_id, value) for efficient compression and querying, rolled up
+// into hourly and daily continuous aggregates as configured by its TsdbConfig.
 type Tsdb struct {
 	db       *sql.DB
 	mtx      *sync.Mutex
 	verified bool
 	ownsDb   bool
+	config   TsdbConfig // Aggregates, compression and retention of the time series
 }
 
 // NewTsdb creates a new TSDB instance. If ownsDb is true, Close() will close
 // the database connection. Set ownsDb to false when sharing the connection
-// with the relational ORM.
-func NewTsdb(db *sql.DB, ownsDb bool) *Tsdb {
+// with the relational ORM. The optional TsdbConfig sets the lifecycle of the
+// time series, DefaultTsdbConfig by default; it is applied with the table on
+// first use.
+func NewTsdb(db *sql.DB, ownsDb bool, config ...TsdbConfig) *Tsdb {
 	return &Tsdb{
 		db:     db,
 		mtx:    &sync.Mutex{},
 		ownsDb: ownsDb,
+		config: tsdbConfigOf(config),
 	}
 }
 
-// verifyTable creates the l8tsdb hypertable and index if they don't exist.
+// SetConfig sets the lifecycle of the time series. Call it before the first
+// use, when the policies are added.
+func (this *Tsdb) SetConfig(config TsdbConfig) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.config = tsdbConfigOf([]TsdbConfig{config})
+}
+
+// verifyTable creates the l8tsdb hypertable and index if they don't exist,
+// then sets up its lifecycle.
 func (this *Tsdb) verifyTable() error {
 	_, err := this.db.Exec(`CREATE TABLE IF NOT EXISTS l8tsdb (
 		stamp    TIMESTAMPTZ NOT NULL,
@@ -66,21 +82,31 @@ func (this *Tsdb) verifyTable() error {
 		return err
 	}
 
+	return this.setupLifecycle()
+}
+
+// ensureTable verifies the table and its lifecycle once, before their first use.
+func (this *Tsdb) ensureTable() error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.verified {
+		return nil
+	}
+	if err := this.verifyTable(); err != nil {
+		return err
+	}
+	this.verified = true
 	return nil
 }
 
 // AddTSDB writes time series notifications to the database in a single transaction.
 func (this *Tsdb) AddTSDB(notifications []*l8notify.L8TSDBNotification) error {
+	if err := this.ensureTable(); err != nil {
+		return err
+	}
 	this.mtx.Lock()
 	defer this.mtx.Unlock()
 
-	if !this.verified {
-		if err := this.verifyTable(); err != nil {
-			return err
-		}
-		this.verified = true
-	}
-
 	tx, err := this.db.Begin()
 	if err != nil {
 		return err
@@ -136,6 +162,44 @@ func (this *Tsdb) GetTSDB(propertyId string, start, end int64) ([]*l8api.L8TimeS
 	return points, rows.Err()
 }
 
+// GetTSDBBuckets aggregates the data points of a property within a time range
+// per bucket of the given seconds with TimescaleDB time_bucket, the buckets
+// aligned on the Unix epoch. Buckets of whole days or hours are read from the
+// daily or hourly aggregate, so they outlive the raw points. The range is
+// widened to whole buckets, so every tier aggregates the same points.
+func (this *Tsdb) GetTSDBBuckets(propertyId string, start, end, bucket int64, function string) ([]*l8api.L8TimeSeriesPoint, error) {
+	function, err := common.TsdbBucketArgs(bucket, function)
+	if err != nil {
+		return nil, err
+	}
+	if err = this.ensureTable(); err != nil {
+		return nil, err
+	}
+	start, end = common.BucketRange(start, end, bucket)
+	tier := this.tierOf(bucket)
+	rows, err := this.db.Query(
+		"SELECT extract(epoch from bucket)::bigint, "+tier.aggregates[function]+" FROM ("+
+			"SELECT time_bucket(make_interval(secs => $4), "+tier.stamp+", TIMESTAMPTZ 'epoch') AS bucket, "+
+			tier.columns+" FROM "+tier.table+" "+
+			"WHERE prop_id = $1 AND "+tier.stamp+" BETWEEN to_timestamp($2) AND to_timestamp($3)"+
+			") sub GROUP BY bucket ORDER BY bucket",
+		propertyId, start, end, bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []*l8api.L8TimeSeriesPoint
+	for rows.Next() {
+		p := &l8api.L8TimeSeriesPoint{}
+		if err := rows.Scan(&p.Stamp, &p.Value); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
 // GetTSDBLatest retrieves the most recent N data points for a property, ordered chronologically.
 func (this *Tsdb) GetTSDBLatest(propertyId string, limit int) ([]*l8api.L8TimeSeriesPoint, error) {
 	rows, err := this.db.Query(
@@ -160,10 +224,86 @@ func (this *Tsdb) GetTSDBLatest(propertyId string, limit int) ([]*l8api.L8TimeSe
 	return points, rows.Err()
 }
 
-// SetRetention configures automatic data expiry for the TSDB hypertable.
+// GetTSDBLatestBatch retrieves the most recent limit points of each of the
+// properties, or of each property matching a pattern, ordered chronologically
+// and grouped by property ID, in a single query.
+func (this *Tsdb) GetTSDBLatestBatch(propertyIds []string, limit int) (map[string][]*l8api.L8TimeSeriesPoint, error) {
+	if len(propertyIds) == 0 {
+		return map[string][]*l8api.L8TimeSeriesPoint{}, nil
+	}
+	if err := this.ensureTable(); err != nil {
+		return nil, err
+	}
+	filter, args := stmt.TsdbPropertyFilter(stmt.Postgres, propertyIds)
+	rows, err := this.db.Query(
+		"SELECT prop_id, stamp_epoch, value FROM ("+
+			"SELECT prop_id, extract(epoch from stamp)::bigint AS stamp_epoch, value, "+
+			"row_number() OVER (PARTITION BY prop_id ORDER BY stamp DESC) AS row_num "+
+			"FROM l8tsdb WHERE "+filter+
+			") sub WHERE row_num <= "+stmt.Postgres.Placeholder(len(args)+1)+" ORDER BY prop_id, stamp_epoch",
+		append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ScanPropertyPoints(rows)
+}
+
+// GetTSDBBatch retrieves the points of each of the properties, or of each
+// property matching a pattern, between start and end, grouped by property ID,
+// in a single query. With a bucket or a function, the points are aggregated
+// per bucket from the same tier GetTSDBBuckets reads.
+func (this *Tsdb) GetTSDBBatch(propertyIds []string, start, end, bucket int64, function string) (map[string][]*l8api.L8TimeSeriesPoint, error) {
+	bucketed := bucket != 0 || function != ""
+	if bucketed {
+		var err error
+		if function, err = common.TsdbBucketArgs(bucket, function); err != nil {
+			return nil, err
+		}
+		start, end = common.BucketRange(start, end, bucket)
+	}
+	if len(propertyIds) == 0 {
+		return map[string][]*l8api.L8TimeSeriesPoint{}, nil
+	}
+	if err := this.ensureTable(); err != nil {
+		return nil, err
+	}
+	filter, args := stmt.TsdbPropertyFilter(stmt.Postgres, propertyIds)
+	from, to := stmt.Postgres.Placeholder(len(args)+1), stmt.Postgres.Placeholder(len(args)+2)
+	args = append(args, start, end)
+	var query string
+	if !bucketed {
+		query = "SELECT prop_id, extract(epoch from stamp)::bigint, value FROM l8tsdb " +
+			"WHERE " + filter + " AND stamp BETWEEN to_timestamp(" + from + ") AND to_timestamp(" + to + ") " +
+			"ORDER BY prop_id, stamp"
+	} else {
+		tier := this.tierOf(bucket)
+		args = append(args, bucket)
+		query = "SELECT prop_id, extract(epoch from bucket)::bigint, " + tier.aggregates[function] + " FROM (" +
+			"SELECT prop_id, time_bucket(make_interval(secs => " + stmt.Postgres.Placeholder(len(args)) + "), " +
+			tier.stamp + ", TIMESTAMPTZ 'epoch') AS bucket, " + tier.columns + " FROM " + tier.table + " " +
+			"WHERE " + filter + " AND " + tier.stamp + " BETWEEN to_timestamp(" + from + ") AND to_timestamp(" + to + ")" +
+			") sub GROUP BY prop_id, bucket ORDER BY prop_id, bucket"
+	}
+	rows, err := this.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ScanPropertyPoints(rows)
+}
+
+// SetRetention replaces the retention policy of the raw points with one
+// dropping them after the given PostgreSQL interval, such as "90 days".
+//
+// Deprecated: set TsdbConfig.RawRetention instead.
 func (this *Tsdb) SetRetention(interval string) error {
-	_, err := this.db.Exec(
-		"SELECT add_retention_policy('l8tsdb', INTERVAL '" + interval + "', if_not_exists => true)")
+	if err := this.ensureTable(); err != nil {
+		return err
+	}
+	_, err := this.db.Exec("SELECT remove_retention_policy('l8tsdb', if_exists => true)")
+	if err != nil {
+		return err
+	}
+	_, err = this.db.Exec("SELECT add_retention_policy('l8tsdb', $1::interval)", interval)
 	return err
 }
 
@@ -0,0 +1,245 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package postgres
+
+import (
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+)
+
+const (
+	hour = time.Hour
+	day  = 24 * time.Hour
+)
+
+// TsdbConfig configures the lifecycle of the TimescaleDB time series: the
+// hourly and daily continuous aggregates, the compression of the raw points
+// and the retention of each tier. Zero fields take the values of
+// DefaultTsdbConfig, and a negative retention keeps that tier forever, a
+// negative CompressAfter disables compression. The policies are only added
+// when the table has none, so a policy already in the database, for example
+// one tuned by an operator, is kept.
+type TsdbConfig struct {
+	RawRetention    time.Duration // How long raw points are kept
+	HourlyRetention time.Duration // How long the hourly aggregate is kept
+	DailyRetention  time.Duration // How long the daily aggregate is kept
+	CompressAfter   time.Duration // Age at which raw chunks are compressed, segmented by prop_id
+	NoAggregates    bool          // Keep only the raw points, without the hourly and daily aggregates
+}
+
+// DefaultTsdbConfig returns the default TSDB lifecycle: raw points kept 7
+// days and compressed after 1 day, the hourly aggregate kept 90 days and the
+// daily aggregate 2 years.
+func DefaultTsdbConfig() TsdbConfig {
+	return TsdbConfig{
+		RawRetention:    7 * day,
+		HourlyRetention: 90 * day,
+		DailyRetention:  730 * day,
+		CompressAfter:   day,
+	}
+}
+
+// tsdbConfigOf returns the first of the configs with its zero fields set to
+// the defaults, or the defaults when there is none.
+func tsdbConfigOf(configs []TsdbConfig) TsdbConfig {
+	config := DefaultTsdbConfig()
+	if len(configs) == 0 {
+		return config
+	}
+	if configs[0].RawRetention != 0 {
+		config.RawRetention = configs[0].RawRetention
+	}
+	if configs[0].HourlyRetention != 0 {
+		config.HourlyRetention = configs[0].HourlyRetention
+	}
+	if configs[0].DailyRetention != 0 {
+		config.DailyRetention = configs[0].DailyRetention
+	}
+	if configs[0].CompressAfter != 0 {
+		config.CompressAfter = configs[0].CompressAfter
+	}
+	config.NoAggregates = configs[0].NoAggregates
+	return config
+}
+
+// tsdbTier is a table of time series data a bucketed query can read from.
+type tsdbTier struct {
+	table      string            // Hypertable or continuous aggregate
+	period     int64             // Seconds per row of a prop_id, 0 for raw points
+	stamp      string            // Time column of the table
+	columns    string            // Columns read, the time column as stamp
+	aggregates map[string]string // TSDB function -> SQL aggregate of the columns
+}
+
+// rawTier reads the raw points, last being the TimescaleDB hyperfunction.
+var rawTier = &tsdbTier{
+	table:   "l8tsdb",
+	stamp:   "stamp",
+	columns: "stamp, value",
+	aggregates: map[string]string{
+		common.TsdbAvg:   "avg(value)",
+		common.TsdbMin:   "min(value)",
+		common.TsdbMax:   "max(value)",
+		common.TsdbLast:  "last(value, stamp)",
+		common.TsdbCount: "count(*)::float8",
+	},
+}
+
+// rollupAggregates combine the rows of a continuous aggregate, the average
+// being weighted by the number of points of each row.
+var rollupAggregates = map[string]string{
+	common.TsdbAvg:   "sum(sum_value) / sum(count_value)::float8",
+	common.TsdbMin:   "min(min_value)",
+	common.TsdbMax:   "max(max_value)",
+	common.TsdbLast:  "last(last_value, stamp)",
+	common.TsdbCount: "sum(count_value)::float8",
+}
+
+// rollupColumns are the columns of a continuous aggregate after its time column.
+const rollupColumns = "sum_value, count_value, min_value, max_value, last_value"
+
+var hourlyTier = &tsdbTier{table: "l8tsdb_hourly", period: int64(hour / time.Second), stamp: "bucket",
+	columns: "bucket AS stamp, " + rollupColumns, aggregates: rollupAggregates}
+var dailyTier = &tsdbTier{table: "l8tsdb_daily", period: int64(day / time.Second), stamp: "day_bucket",
+	columns: "day_bucket AS stamp, " + rollupColumns, aggregates: rollupAggregates}
+
+// tierOf returns the coarsest tier whose period divides the bucket, so a
+// bucketed query reads the fewest rows, and the raw points when the
+// aggregates are disabled or the bucket is not whole hours.
+func (this *Tsdb) tierOf(bucket int64) *tsdbTier {
+	if this.config.NoAggregates {
+		return rawTier
+	}
+	for _, tier := range []*tsdbTier{dailyTier, hourlyTier} {
+		if bucket%tier.period == 0 {
+			return tier
+		}
+	}
+	return rawTier
+}
+
+// setupLifecycle creates the continuous aggregates and adds the configured
+// compression, refresh and retention policies the tables do not have yet.
+func (this *Tsdb) setupLifecycle() error {
+	if err := this.setupCompression(); err != nil {
+		return err
+	}
+	if !this.config.NoAggregates {
+		if err := this.setupAggregates(); err != nil {
+			return err
+		}
+	}
+	if err := this.setRetention(rawTier.table, this.config.RawRetention); err != nil {
+		return err
+	}
+	if this.config.NoAggregates {
+		return nil
+	}
+	if err := this.setRetention(hourlyTier.table, this.config.HourlyRetention); err != nil {
+		return err
+	}
+	return this.setRetention(dailyTier.table, this.config.DailyRetention)
+}
+
+// setupCompression enables compression of the raw chunks, segmented by prop_id
+// so the points of a property are stored together, and compresses chunks
+// older than CompressAfter unless the table already has a compression policy.
+func (this *Tsdb) setupCompression() error {
+	if this.config.CompressAfter < 0 {
+		return nil
+	}
+	var enabled bool
+	err := this.db.QueryRow(
+		"SELECT compression_enabled FROM timescaledb_information.hypertables WHERE hypertable_name = 'l8tsdb'").Scan(&enabled)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		_, err = this.db.Exec(
+			"ALTER TABLE l8tsdb SET (timescaledb.compress, timescaledb.compress_segmentby = 'prop_id', " +
+				"timescaledb.compress_orderby = 'stamp DESC')")
+		if err != nil {
+			return err
+		}
+	}
+	_, err = this.db.Exec("SELECT add_compression_policy('l8tsdb', make_interval(secs => $1), if_not_exists => true)",
+		this.config.CompressAfter.Seconds())
+	return err
+}
+
+// setupAggregates creates the hourly aggregate over the raw points and the
+// daily aggregate over the hourly one, with real-time aggregation so they also
+// cover the data not materialized yet. Each is refreshed over a window that
+// ends before the retention of its source, so dropped source rows do not
+// remove materialized ones.
+func (this *Tsdb) setupAggregates() error {
+	_, err := this.db.Exec(`CREATE MATERIALIZED VIEW IF NOT EXISTS l8tsdb_hourly
+		WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+		SELECT time_bucket(INTERVAL '1 hour', stamp) AS bucket, prop_id,
+			sum(value) AS sum_value, count(*) AS count_value,
+			min(value) AS min_value, max(value) AS max_value, last(value, stamp) AS last_value
+		FROM l8tsdb GROUP BY bucket, prop_id WITH NO DATA`)
+	if err != nil {
+		return err
+	}
+	_, err = this.db.Exec(`CREATE MATERIALIZED VIEW IF NOT EXISTS l8tsdb_daily
+		WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+		SELECT time_bucket(INTERVAL '1 day', bucket) AS day_bucket, prop_id,
+			sum(sum_value) AS sum_value, sum(count_value) AS count_value,
+			min(min_value) AS min_value, max(max_value) AS max_value, last(last_value, bucket) AS last_value
+		FROM l8tsdb_hourly GROUP BY day_bucket, prop_id WITH NO DATA`)
+	if err != nil {
+		return err
+	}
+	err = this.setRefresh(hourlyTier.table, refreshStart(3*day, this.config.RawRetention, hour), hour, 30*time.Minute)
+	if err != nil {
+		return err
+	}
+	return this.setRefresh(dailyTier.table, refreshStart(7*day, this.config.HourlyRetention, day), day, hour)
+}
+
+// refreshStart returns how far back a continuous aggregate of the given
+// period is refreshed: the window, shortened to end one period before the
+// retention of the source, but covering at least three periods.
+func refreshStart(window, retention, period time.Duration) time.Duration {
+	if retention > 0 && retention-period < window {
+		window = retention - period
+	}
+	if window < 3*period {
+		window = 3 * period
+	}
+	return window
+}
+
+// setRefresh adds the refresh policy of a continuous aggregate that has none.
+func (this *Tsdb) setRefresh(view string, start, end, schedule time.Duration) error {
+	_, err := this.db.Exec("SELECT add_continuous_aggregate_policy('"+view+"', "+
+		"start_offset => make_interval(secs => $1), end_offset => make_interval(secs => $2), "+
+		"schedule_interval => make_interval(secs => $3), if_not_exists => true)",
+		start.Seconds(), end.Seconds(), schedule.Seconds())
+	return err
+}
+
+// setRetention adds the retention policy of a hypertable or continuous
+// aggregate that has none; a negative retention keeps its data forever.
+func (this *Tsdb) setRetention(table string, retention time.Duration) error {
+	if retention < 0 {
+		return nil
+	}
+	_, err := this.db.Exec("SELECT add_retention_policy('"+table+"', make_interval(secs => $1), if_not_exists => true)",
+		retention.Seconds())
+	return err
+}
@@ -0,0 +1,219 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8orm/go/orm/stmt"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// DeleteRelational removes records matching a query from the database.
+// Child table rows are removed by ParentKey prefix before the root rows.
+// Root types in soft delete mode only have their root rows stamped, and root
+// types in history mode have the deleted rows archived first.
+func (this *Relational) DeleteRelational(query ifs.IQuery) error {
+	return this.DeleteRelationalContext(context.Background(), query)
+}
+
+// DeleteRelationalContext is DeleteRelational bounded by ctx.
+func (this *Relational) DeleteRelationalContext(ctx context.Context, query ifs.IQuery) error {
+	_, err := this.DeleteKeys(ctx, query)
+	return err
+}
+
+// DeleteKeys is DeleteRelationalContext returning the RecKeys of the deleted,
+// or tombstoned, root rows, for backends maintaining caches of them.
+func (this *Relational) DeleteKeys(ctx context.Context, query ifs.IQuery) (rootKeys []string, err error) {
+	query = this.SoftDeleteQuery(query)
+	data, err := convert.NewRelationsDataForQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	rootTableName := query.RootType().TypeName
+	rootNode, ok := this.res.Introspector().NodeByTypeName(rootTableName)
+	if !ok {
+		return nil, errors.New("root table not found " + rootTableName)
+	}
+	err = this.VerifyTables(ctx, rootNode)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := this.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	rootStatement := stmt.NewStatement(rootNode, data.Tables[rootTableName].Columns, query, this.res.Registry(), this.dialect).WithContext(ctx)
+	keysSql, keysArgs := rootStatement.Query2RecKeysSql(query, rootTableName)
+	rows, err := tx.QueryContext(ctx, keysSql, keysArgs...)
+	if err != nil {
+		return nil, err
+	}
+	rootKeys = make([]string, 0)
+	for rows.Next() {
+		var recKey string
+		err = rows.Scan(&recKey)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		// Root rows have an empty ParentKey, so the child prefix is the RecKey.
+		rootKeys = append(rootKeys, recKey)
+	}
+	rows.Close()
+
+	if len(rootKeys) == 0 {
+		return rootKeys, nil
+	}
+
+	now := time.Now().Unix()
+	if this.IsSoftDelete(rootTableName) {
+		err = this.tombstone(ctx, tx, rootNode, rootKeys, now)
+		return rootKeys, err
+	}
+	if this.isHistory(rootTableName) {
+		err = this.history.Archive(ctx, tx, rootNode, rootKeys, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for tableName, table := range data.Tables {
+		if strings.EqualFold(tableName, rootTableName) {
+			continue
+		}
+		node, ok := this.res.Introspector().NodeByTypeName(tableName)
+		if !ok {
+			err = errors.New("table not found " + tableName)
+			return nil, err
+		}
+		statement := stmt.NewStatement(node, table.Columns, query, this.res.Registry(), this.dialect).WithContext(ctx)
+		deleteStmt, args, e := statement.DeleteByKeysStatement(tx, rootKeys)
+		if e != nil {
+			err = e
+			return nil, err
+		}
+		if deleteStmt == nil {
+			continue
+		}
+		_, err = deleteStmt.ExecContext(ctx, args...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rootDeleteStmt, args, err := rootStatement.DeleteRootsStatement(tx, rootKeys)
+	if err != nil {
+		return nil, err
+	}
+	_, err = rootDeleteStmt.ExecContext(ctx, args...)
+	return rootKeys, err
+}
+
+// deleteChildRows removes the child table rows stored under the given root
+// keys, inside the caller's transaction. A PUT uses it to replace the whole
+// object graph instead of leaving rows for removed slice or map elements.
+func (this *Relational) deleteChildRows(ctx context.Context, tx *sql.Tx, rootNode *l8reflect.L8Node, rootKeys []string) error {
+	if len(rootKeys) == 0 {
+		return nil
+	}
+	tables := make(map[string]bool)
+	CollectTables(rootNode, tables)
+	for tableName := range tables {
+		if tableName == rootNode.TypeName {
+			continue
+		}
+		node, ok := this.res.Introspector().NodeByTypeName(tableName)
+		if !ok {
+			return errors.New("table not found " + tableName)
+		}
+		statement := stmt.NewStatement(node, nil, nil, this.res.Registry(), this.dialect).WithContext(ctx)
+		deleteStmt, args, err := statement.DeleteByKeysStatement(tx, rootKeys)
+		if err != nil {
+			return err
+		}
+		_, err = deleteStmt.ExecContext(ctx, args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteCollections removes the stored rows of the slice and map fields that a
+// PATCH with a field mask replaces, inside the caller's transaction.
+func (this *Relational) deleteCollections(ctx context.Context, tx *sql.Tx, rootNode *l8reflect.L8Node, collections []convert.Collection) error {
+	if len(collections) == 0 {
+		return nil
+	}
+	parentKeys := make([]string, len(collections))
+	fieldNames := make([]string, len(collections))
+	for i, collection := range collections {
+		parentKeys[i] = collection.ParentKey
+		fieldNames[i] = collection.FieldName
+	}
+	tables := make(map[string]bool)
+	CollectTables(rootNode, tables)
+	for tableName := range tables {
+		if tableName == rootNode.TypeName {
+			continue
+		}
+		node, ok := this.res.Introspector().NodeByTypeName(tableName)
+		if !ok {
+			return errors.New("table not found " + tableName)
+		}
+		statement := stmt.NewStatement(node, nil, nil, this.res.Registry(), this.dialect).WithContext(ctx)
+		deleteStmt, args, err := statement.DeleteCollectionsStatement(tx, parentKeys, fieldNames)
+		if err != nil {
+			return err
+		}
+		_, err = deleteStmt.ExecContext(ctx, args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes records matching the query.
+func (this *Relational) Delete(q ifs.IQuery, resources ifs.IResources) error {
+	return this.DeleteContext(context.Background(), q, resources)
+}
+
+// DeleteContext is Delete bounded by ctx.
+func (this *Relational) DeleteContext(ctx context.Context, q ifs.IQuery, resources ifs.IResources) error {
+	return this.DeleteRelationalContext(ctx, q)
+}
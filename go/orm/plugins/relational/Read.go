@@ -0,0 +1,377 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8orm/go/orm/stmt"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
+	"github.com/saichler/l8utils/go/utils/cache"
+)
+
+// ReadRelational executes a query and returns raw relational data.
+// It fetches data from all tables in the query's type hierarchy and
+// returns the results as L8OrmRData along with metadata (record counts).
+func (this *Relational) ReadRelational(query ifs.IQuery) (*l8orms.L8OrmRData, *l8api.L8MetaData, error) {
+	return this.ReadRelationalContext(context.Background(), query)
+}
+
+// ReadRelationalContext is ReadRelational bounded by ctx.
+func (this *Relational) ReadRelationalContext(ctx context.Context, query ifs.IQuery) (*l8orms.L8OrmRData, *l8api.L8MetaData, error) {
+	query = this.SoftDeleteQuery(query)
+	data, err := convert.NewRelationsDataForQuery(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	rootNode, ok := this.res.Introspector().NodeByTypeName(query.RootType().TypeName)
+	if ok {
+		err = this.VerifyTables(ctx, rootNode)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tx, err := this.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Commit()
+
+	var rootTableStatement *stmt.Statement
+
+	for tableName, table := range data.Tables {
+		node, ok := this.res.Introspector().NodeByTypeName(tableName)
+		if !ok {
+			return nil, nil, errors.New("table not found " + data.RootTypeName)
+		}
+		statement := stmt.NewStatement(node, table.Columns, query, this.res.Registry(), this.dialect).WithContext(ctx)
+		st, args, err := statement.SelectStatement(tx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if st == nil {
+			continue
+		}
+
+		if strings.EqualFold(tableName, query.RootType().TypeName) {
+			rootTableStatement = statement
+		}
+
+		rows, err := st.QueryContext(ctx, args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		dataRow, err := ReadRows(rows, statement)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, row := range dataRow {
+			AddRowToTable(table, row)
+		}
+	}
+	if rootTableStatement == nil {
+		return data, nil, nil
+	}
+	return data, rootTableStatement.MetaData(tx), nil
+}
+
+// Read executes a query and returns the results as Go objects.
+// Tombstones of soft delete types are skipped unless the query is WithDeleted.
+// Aggregate queries are answered from the aggregate SQL, paginated queries
+// fetch the page's RecKeys first and then the rows for just that page.
+func (this *Relational) Read(q ifs.IQuery, resources ifs.IResources) ifs.IElements {
+	return this.ReadContext(context.Background(), q, resources)
+}
+
+// ReadContext is Read bounded by ctx.
+func (this *Relational) ReadContext(ctx context.Context, q ifs.IQuery, resources ifs.IResources) ifs.IElements {
+	q = this.SoftDeleteQuery(q)
+	if q.IsAggregate() {
+		return this.readAggregate(ctx, q)
+	}
+	if q.Limit() > 0 {
+		recKeys, _, metadata, err := this.ReadRecKeys(ctx, q, "")
+		if err != nil {
+			return object.NewError(err.Error())
+		}
+		return this.ReadByRecKeys(ctx, q, pageKeys(recKeys, q.Page(), q.Limit()), metadata, resources)
+	}
+	relData, metadata, err := this.ReadRelationalContext(ctx, q)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	return this.populateTsFields(convert.ConvertFrom(object.New(nil, relData), metadata, resources), resources)
+}
+
+// pageKeys returns the subset of record keys for the requested page.
+func pageKeys(recKeys []string, page, limit int32) []string {
+	start := int(page * limit)
+	if start >= len(recKeys) {
+		return []string{}
+	}
+	end := start + int(limit)
+	if end > len(recKeys) {
+		end = len(recKeys)
+	}
+	return recKeys[start:end]
+}
+
+// ReadRecKeys fetches the sorted RecKeys of all root rows matching the query,
+// together with the total count metadata. When sortField is set, the values
+// of that root column are returned alongside the keys.
+func (this *Relational) ReadRecKeys(ctx context.Context, query ifs.IQuery, sortField string) ([]string, []interface{}, *l8api.L8MetaData, error) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	node, ok := this.res.Introspector().NodeByTypeName(query.RootType().TypeName)
+	if !ok {
+		return nil, nil, nil, errors.New("table not found " + query.RootType().TypeName)
+	}
+
+	err := this.VerifyTables(ctx, node)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tx, err := this.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer tx.Commit()
+
+	statement := stmt.NewStatement(node, nil, query, this.res.Registry(), this.dialect).WithContext(ctx)
+	var sqlStr string
+	var args []interface{}
+	if sortField == "" {
+		sqlStr, args = statement.Query2RecKeysSql(query, query.RootType().TypeName)
+	} else {
+		sqlStr, args = statement.Query2SortedRecKeysSql(query, query.RootType().TypeName, sortField)
+	}
+	rows, err := tx.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	recKeys := make([]string, 0)
+	var sortValues []interface{}
+	for rows.Next() {
+		var recKey string
+		var sortValue interface{}
+		if sortField == "" {
+			err = rows.Scan(&recKey)
+		} else {
+			err = rows.Scan(&recKey, &sortValue)
+			sortValues = append(sortValues, sortValue)
+		}
+		if err != nil {
+			rows.Close()
+			return nil, nil, nil, err
+		}
+		recKeys = append(recKeys, recKey)
+	}
+	rows.Close()
+
+	return recKeys, sortValues, statement.MetaData(tx), nil
+}
+
+// ReadByRecKeys fetches full row data for a page of root RecKeys, in the
+// order of the keys. Child tables are read with a ParentKey prefix match on
+// the page's root keys, so the cost of a page follows the page size and not
+// the table size.
+func (this *Relational) ReadByRecKeys(ctx context.Context, query ifs.IQuery, recKeys []string, metadata *l8api.L8MetaData, resources ifs.IResources) ifs.IElements {
+	if len(recKeys) == 0 {
+		return object.NewQueryResult(nil, metadata)
+	}
+
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	data, err := convert.NewRelationsDataForQuery(query)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+
+	tx, err := this.db.BeginTx(ctx, nil)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	defer tx.Commit()
+
+	recKeyOrder := make(map[string]int)
+	for i, key := range recKeys {
+		recKeyOrder[key] = i
+	}
+
+	for tableName, table := range data.Tables {
+		node, ok := this.res.Introspector().NodeByTypeName(tableName)
+		if !ok {
+			return object.NewError("table not found " + tableName)
+		}
+		statement := stmt.NewStatement(node, table.Columns, query, this.res.Registry(), this.dialect).WithContext(ctx)
+
+		if !strings.EqualFold(tableName, query.RootType().TypeName) {
+			sqlStr, args := statement.Query2SqlByParentKeys(tableName, recKeys)
+			rows, err := tx.QueryContext(ctx, sqlStr, args...)
+			if err != nil {
+				return object.NewError(err.Error())
+			}
+			dataRow, err := ReadRows(rows, statement)
+			if err != nil {
+				return object.NewError(err.Error())
+			}
+			for _, row := range dataRow {
+				AddRowToTable(table, row)
+			}
+			continue
+		}
+
+		sqlStr, args := statement.Query2SqlByRecKeys(tableName, recKeys)
+		rows, err := tx.QueryContext(ctx, sqlStr, args...)
+		if err != nil {
+			return object.NewError(err.Error())
+		}
+		dataRow, err := ReadRows(rows, statement)
+		if err != nil {
+			return object.NewError(err.Error())
+		}
+		sortedRows := make([]*l8orms.L8OrmRow, len(recKeys))
+		for _, row := range dataRow {
+			if idx, ok := recKeyOrder[row.RecKey]; ok {
+				sortedRows[idx] = row
+			}
+		}
+		for _, row := range sortedRows {
+			if row != nil {
+				AddRowToTable(table, row)
+			}
+		}
+	}
+
+	return this.populateTsFields(convert.ConvertFrom(object.New(nil, data), metadata, resources), resources)
+}
+
+// readAggregate executes an aggregate query and returns the results packed
+// into L8MetaData.KeyCount.Counts. The elements slice is empty.
+func (this *Relational) readAggregate(ctx context.Context, q ifs.IQuery) ifs.IElements {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	rootNode, ok := this.res.Introspector().NodeByTypeName(q.RootType().TypeName)
+	if !ok {
+		return object.NewError("table not found " + q.RootType().TypeName)
+	}
+	err := this.VerifyTables(ctx, rootNode)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+
+	statement := stmt.NewStatement(rootNode, nil, q, this.res.Registry(), this.dialect).WithContext(ctx)
+	sqlStr, args, ok := statement.AggregateSql(q)
+	if !ok {
+		return object.NewError("failed to generate aggregate SQL")
+	}
+
+	rows, err := this.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	defer rows.Close()
+
+	colNames := make([]string, 0)
+	colNames = append(colNames, q.GroupBy()...)
+	for _, agg := range q.Aggregates() {
+		colNames = append(colNames, agg.Alias)
+	}
+
+	groups := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		scanVals := make([]interface{}, len(colNames))
+		scanPtrs := make([]interface{}, len(colNames))
+		for i := range scanVals {
+			scanPtrs[i] = &scanVals[i]
+		}
+		if err := rows.Scan(scanPtrs...); err != nil {
+			return object.NewError(err.Error())
+		}
+		group := make(map[string]interface{})
+		for i, name := range colNames {
+			group[name] = scanVals[i]
+		}
+		groups = append(groups, group)
+	}
+
+	metadata := &l8api.L8MetaData{
+		KeyCount: &l8api.L8Count{
+			Counts: make(map[string]float64),
+		},
+	}
+	cache.PackAggregateResults(groups, q.Aggregates(), q.GroupBy(), metadata)
+	return object.NewQueryResult([]interface{}{}, metadata)
+}
+
+// ReadRows scans all rows from a SQL result set into L8OrmRow structures,
+// and closes the rows.
+func ReadRows(rows *sql.Rows, statement *stmt.Statement) ([]*l8orms.L8OrmRow, error) {
+	defer rows.Close()
+	result := make([]*l8orms.L8OrmRow, 0)
+	for rows.Next() {
+		row, err := statement.Row(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+// nameOfField extracts the field name from a RecKey by removing the bracketed portion.
+func nameOfField(recKey string) string {
+	index := strings.Index(recKey, "[")
+	if index == -1 {
+		return recKey
+	}
+	return recKey[0:index]
+}
+
+// AddRowToTable adds a row to the table's nested structure.
+// It initializes any missing intermediate structures (InstanceRows, AttributeRows).
+func AddRowToTable(table *l8orms.L8OrmTable, row *l8orms.L8OrmRow) {
+	fldName := nameOfField(row.RecKey)
+	if table.InstanceRows == nil {
+		table.InstanceRows = make(map[string]*l8orms.L8OrmInstanceRows)
+	}
+	if table.InstanceRows[row.ParentKey] == nil {
+		table.InstanceRows[row.ParentKey] = &l8orms.L8OrmInstanceRows{}
+	}
+	if table.InstanceRows[row.ParentKey].AttributeRows == nil {
+		table.InstanceRows[row.ParentKey].AttributeRows = make(map[string]*l8orms.L8OrmAttributeRows)
+	}
+	if table.InstanceRows[row.ParentKey].AttributeRows[fldName] == nil {
+		table.InstanceRows[row.ParentKey].AttributeRows[fldName] = &l8orms.L8OrmAttributeRows{}
+	}
+	attrRows := table.InstanceRows[row.ParentKey].AttributeRows[fldName]
+	attrRows.Rows = append(attrRows.Rows, row)
+}
@@ -0,0 +1,26 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package relational
+
+import (
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// populateTsFields fills the time series fields of read elements with the
+// latest points from the time series store.
+func (this *Relational) populateTsFields(result ifs.IElements, resources ifs.IResources) ifs.IElements {
+	return convert.PopulateTsFields(result, resources, this.tsdb.GetTSDBLatestBatch)
+}
@@ -0,0 +1,282 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package relational implements the IORM, IORMRelational and ITSDB interfaces
+// on a database/sql connection. Reads, writes, deletes and soft deletes are
+// shared and generated through a stmt.IDialect; each backend plugin embeds a
+// Relational and provides the DDL creating and migrating its tables. Backend
+// specific features, such as the pagination index, bulk writes and history of
+// the PostgreSQL plugin, are built on top of it.
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/stmt"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
+	"github.com/saichler/l8types/go/types/l8notify"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// ISchema creates and migrates the tables of a backend.
+type ISchema interface {
+	// VerifyTable creates the table of a type if it does not exist, or
+	// reconciles the existing table with the current proto definition.
+	VerifyTable(ctx context.Context, tableName string, root bool) error
+}
+
+// IHistory is implemented by the schema of backends keeping the history of
+// root types. Writes and deletes of a type in history mode archive the rows
+// they replace, and writes stamp the rows they write, in their transaction.
+type IHistory interface {
+	// IsHistory reports whether a root type is in history mode.
+	IsHistory(typeName string) bool
+
+	// VerifyHistoryTable creates the history table of a table, or reconciles
+	// the existing one with the current proto definition.
+	VerifyHistoryTable(ctx context.Context, tableName string) error
+
+	// Archive copies the current rows of the given root elements, in every
+	// table of the type hierarchy, into the history tables as valid until now.
+	Archive(ctx context.Context, tx *sql.Tx, rootNode *l8reflect.L8Node, rootKeys []string, now int64) error
+
+	// Stamp marks the current rows of the given root elements, in every table
+	// of the type hierarchy, as valid from now.
+	Stamp(ctx context.Context, tx *sql.Tx, rootNode *l8reflect.L8Node, rootKeys []string, now int64) error
+}
+
+// Relational implements the IORM, IORMRelational and ITSDB interfaces on top of
+// a database/sql connection. Database operations are serialized with a mutex,
+// as SQLite allows a single writer at a time, unless SetSerialized turns it off.
+type Relational struct {
+	db        *sql.DB         // Database connection
+	verifyed  map[string]bool // Tracks verified/created tables
+	verifyMtx *sync.RWMutex   // Guards table verification and verifyed
+	mtx       sync.Locker     // Serializes database operations
+	res       ifs.IResources  // Layer 8 resources (introspector, registry, etc.)
+	batchSize int             // Maximum elements per write batch
+	dialect   stmt.IDialect   // Backend specific SQL syntax
+	schema    ISchema         // Backend specific DDL
+	history   IHistory        // Backend history, nil when the schema keeps none
+
+	maxKeyLength int // Longest ParentKey or RecKey, in characters, the key columns hold; 0 when unbounded
+
+	versionField string   // Root attribute holding the row version, "" when disabled
+	softDelete   sync.Map // Root type names in soft delete mode
+
+	tsdb common.ITSDB
+}
+
+// noLock is the lock of a Relational whose operations are not serialized.
+type noLock struct{}
+
+func (noLock) Lock()   {}
+func (noLock) Unlock() {}
+
+// New creates a relational ORM on the given database connection, generating
+// SQL with the dialect and creating tables with the schema. A schema that
+// implements IHistory keeps the history of the types in history mode. The
+// time series store usually shares the same connection.
+func New(db *sql.DB, resourcs ifs.IResources, dialect stmt.IDialect, schema ISchema, tsdb common.ITSDB) *Relational {
+	history, _ := schema.(IHistory)
+	return &Relational{
+		db:        db,
+		verifyed:  make(map[string]bool),
+		verifyMtx: &sync.RWMutex{},
+		mtx:       &sync.Mutex{},
+		res:       resourcs,
+		batchSize: 500,
+		dialect:   dialect,
+		schema:    schema,
+		history:   history,
+		tsdb:      tsdb,
+	}
+}
+
+// SetSerialized sets whether database operations are serialized, which they
+// are by default. Backends whose writes are isolated by their transactions
+// turn it off, so requests run concurrently on the connection pool. Call it
+// before the first operation.
+func (this *Relational) SetSerialized(serialized bool) {
+	if serialized {
+		this.mtx = &sync.Mutex{}
+	} else {
+		this.mtx = noLock{}
+	}
+}
+
+// DB returns the database connection.
+func (this *Relational) DB() *sql.DB {
+	return this.db
+}
+
+// Resources returns the Layer 8 resources.
+func (this *Relational) Resources() ifs.IResources {
+	return this.res
+}
+
+// BatchSize returns the maximum number of elements written per batch.
+func (this *Relational) BatchSize() int {
+	return this.batchSize
+}
+
+// SetMaxKeyLength sets the longest ParentKey or RecKey, in characters, the key
+// columns of the backend hold. Writes with a longer key fail before anything
+// is written, instead of being rejected or truncated by the database.
+func (this *Relational) SetMaxKeyLength(length int) {
+	this.maxKeyLength = length
+}
+
+// StampType returns the column type of the Unix time stamps kept by the plugin.
+func (this *Relational) StampType() string {
+	return this.dialect.TypeName(&l8reflect.L8Node{TypeName: "int64"})
+}
+
+// ColumnType returns the DDL type of an attribute column. With versioning
+// enabled, the version column of a root table is NOT NULL with a default of 0,
+// so rows written before versioning was enabled start at version 0.
+func (this *Relational) ColumnType(attrName string, attr *l8reflect.L8Node, root bool) string {
+	typeName := this.dialect.TypeName(attr)
+	if root && attrName == this.versionField {
+		return typeName + " NOT NULL DEFAULT 0"
+	}
+	return typeName
+}
+
+// CollectTables recursively collects all table names needed for a type hierarchy.
+// It traverses nested struct attributes to find all related table types.
+func CollectTables(node *l8reflect.L8Node, tables map[string]bool) {
+	tables[node.TypeName] = true
+	if node.Attributes != nil {
+		for _, attr := range node.Attributes {
+			if attr.IsStruct {
+				if common.IsTimeSeriesType(attr.TypeName) {
+					continue
+				}
+				_, ok := tables[attr.TypeName]
+				if !ok {
+					CollectTables(attr, tables)
+				}
+			}
+		}
+	}
+}
+
+// isHistory reports whether a root type is in history mode.
+func (this *Relational) isHistory(typeName string) bool {
+	return this.history != nil && this.history.IsHistory(typeName)
+}
+
+// VerifyTables ensures all required tables exist in the database.
+// It checks each table in the type hierarchy and creates missing tables,
+// and their history tables for root types in history mode.
+// Each table is verified once. Verification is serialized by verifyMtx, so
+// concurrent first uses of a type wait for its tables instead of racing to
+// create them, while calls for verified tables only take the read lock.
+func (this *Relational) VerifyTables(ctx context.Context, rootNode *l8reflect.L8Node) error {
+	tables := make(map[string]bool)
+	CollectTables(rootNode, tables)
+	history := this.isHistory(rootNode.TypeName)
+
+	this.verifyMtx.RLock()
+	verified := this.tablesVerified(tables, history)
+	this.verifyMtx.RUnlock()
+	if verified {
+		return nil
+	}
+
+	this.verifyMtx.Lock()
+	defer this.verifyMtx.Unlock()
+	for tableName, _ := range tables {
+		_, ok := this.verifyed[tableName]
+		if !ok {
+			err := this.schema.VerifyTable(ctx, tableName, tableName == rootNode.TypeName)
+			if err != nil {
+				return err
+			}
+			this.verifyed[tableName] = true
+		}
+	}
+	if !history {
+		return nil
+	}
+	for tableName := range tables {
+		historyName := common.HistoryTable(tableName)
+		if this.verifyed[historyName] {
+			continue
+		}
+		err := this.history.VerifyHistoryTable(ctx, tableName)
+		if err != nil {
+			return err
+		}
+		this.verifyed[historyName] = true
+	}
+	return nil
+}
+
+// tablesVerified reports whether all the tables, and their history tables
+// when history is true, were already verified. The caller holds verifyMtx.
+func (this *Relational) tablesVerified(tables map[string]bool, history bool) bool {
+	for tableName := range tables {
+		if !this.verifyed[tableName] {
+			return false
+		}
+		if history && !this.verifyed[common.HistoryTable(tableName)] {
+			return false
+		}
+	}
+	return true
+}
+
+// EnableVersioning turns on optimistic concurrency for root types that have an
+// int64 attribute with the given name. Call it before the first write.
+func (this *Relational) EnableVersioning(field string) {
+	this.versionField = field
+}
+
+// VersionField returns the version attribute name, or "" when versioning is disabled.
+func (this *Relational) VersionField() string {
+	return this.versionField
+}
+
+func (this *Relational) AddTSDB(notifications []*l8notify.L8TSDBNotification) error {
+	return this.tsdb.AddTSDB(notifications)
+}
+
+func (this *Relational) GetTSDB(propertyId string, start, end int64) ([]*l8api.L8TimeSeriesPoint, error) {
+	return this.tsdb.GetTSDB(propertyId, start, end)
+}
+
+func (this *Relational) GetTSDBBuckets(propertyId string, start, end, bucket int64, function string) ([]*l8api.L8TimeSeriesPoint, error) {
+	return this.tsdb.GetTSDBBuckets(propertyId, start, end, bucket, function)
+}
+
+func (this *Relational) GetTSDBLatestBatch(propertyIds []string, limit int) (map[string][]*l8api.L8TimeSeriesPoint, error) {
+	return this.tsdb.GetTSDBLatestBatch(propertyIds, limit)
+}
+
+func (this *Relational) GetTSDBBatch(propertyIds []string, start, end, bucket int64, function string) (map[string][]*l8api.L8TimeSeriesPoint, error) {
+	return this.tsdb.GetTSDBBatch(propertyIds, start, end, bucket, function)
+}
+
+// Close closes the time series store and the database connection.
+func (this *Relational) Close() error {
+	this.tsdb.Close()
+	return this.db.Close()
+}
@@ -0,0 +1,147 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/stmt"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// EnableSoftDelete turns on soft delete mode for a root type. It must be called
+// before the type's tables are first used, so they are created with the stamp column.
+func (this *Relational) EnableSoftDelete(typeName string) {
+	this.softDelete.Store(typeName, true)
+}
+
+// IsSoftDelete reports whether a root type is in soft delete mode.
+func (this *Relational) IsSoftDelete(typeName string) bool {
+	_, ok := this.softDelete.Load(typeName)
+	return ok
+}
+
+// SoftDeleteQuery marks queries over soft delete root types, so the statement
+// builders exclude tombstoned rows.
+func (this *Relational) SoftDeleteQuery(query ifs.IQuery) ifs.IQuery {
+	if this.IsSoftDelete(query.RootType().TypeName) {
+		return common.SoftDeleteQuery(query)
+	}
+	return query
+}
+
+// tombstone stamps the live root rows with the given keys with the deletion
+// time, inside the caller's transaction. Child rows are kept so the element
+// can be restored.
+func (this *Relational) tombstone(ctx context.Context, tx *sql.Tx, rootNode *l8reflect.L8Node, rootKeys []string, now int64) error {
+	statement := stmt.NewStatement(rootNode, nil, nil, this.res.Registry(), this.dialect)
+	sqlStr, args := statement.SoftDeleteByKeysSql(rootKeys, now)
+	_, err := tx.ExecContext(ctx, sqlStr, args...)
+	return err
+}
+
+// restoreWritten clears the stamps of the root rows being written, so that
+// writing a deleted element again brings it back.
+func (this *Relational) restoreWritten(ctx context.Context, tx *sql.Tx, rootNode *l8reflect.L8Node, rootKeys []string) error {
+	if len(rootKeys) == 0 {
+		return nil
+	}
+	statement := stmt.NewStatement(rootNode, nil, nil, this.res.Registry(), this.dialect)
+	sqlStr, args := statement.RestoreByKeysSql(rootKeys)
+	_, err := tx.ExecContext(ctx, sqlStr, args...)
+	return err
+}
+
+// Restore clears the tombstones of the deleted root rows matching the query.
+func (this *Relational) Restore(query ifs.IQuery) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	rootNode, ok := this.res.Introspector().NodeByTypeName(query.RootType().TypeName)
+	if !ok {
+		return errors.New("root table not found " + query.RootType().TypeName)
+	}
+	err := this.VerifyTables(context.Background(), rootNode)
+	if err != nil {
+		return err
+	}
+	statement := stmt.NewStatement(rootNode, nil, query, this.res.Registry(), this.dialect)
+	sqlStr, args := statement.Query2RestoreSql(query)
+	_, err = this.db.Exec(sqlStr, args...)
+	return err
+}
+
+// Purge physically removes the root rows of the type that were soft deleted
+// age or more ago, together with their child rows, in one transaction.
+func (this *Relational) Purge(typeName string, age time.Duration) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	rootNode, ok := this.res.Introspector().NodeByTypeName(typeName)
+	if !ok {
+		return errors.New("root table not found " + typeName)
+	}
+	err := this.VerifyTables(context.Background(), rootNode)
+	if err != nil {
+		return err
+	}
+
+	tx, err := this.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	before := time.Now().Add(-age).Unix()
+	statement := stmt.NewStatement(rootNode, nil, nil, this.res.Registry(), this.dialect)
+	keysSql, keysArgs := statement.TombstoneKeysSql(before)
+	rows, err := tx.Query(keysSql, keysArgs...)
+	if err != nil {
+		return err
+	}
+	rootKeys := make([]string, 0)
+	for rows.Next() {
+		var recKey string
+		err = rows.Scan(&recKey)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		rootKeys = append(rootKeys, recKey)
+	}
+	rows.Close()
+	if len(rootKeys) == 0 {
+		return nil
+	}
+
+	err = this.deleteChildRows(context.Background(), tx, rootNode, rootKeys)
+	if err != nil {
+		return err
+	}
+	purgeSql, purgeArgs := statement.PurgeSql(before)
+	_, err = tx.Exec(purgeSql, purgeArgs...)
+	return err
+}
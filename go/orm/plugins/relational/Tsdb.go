@@ -0,0 +1,261 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package relational
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/stmt"
+	"github.com/saichler/l8types/go/types/l8api"
+	"github.com/saichler/l8types/go/types/l8notify"
+)
+
+// Tsdb implements the ITSDB interface on a plain table of a database without
+// time series support. It keeps the same narrow (stamp, prop_id, value) schema
+// as the TimescaleDB implementation, with stamps stored as Unix seconds.
+type Tsdb struct {
+	db       *sql.DB
+	mtx      *sync.Mutex
+	verified bool
+	ownsDb   bool
+	dialect  stmt.IDialect // Backend specific SQL syntax
+	ddl      []string      // Statements creating the l8tsdb table and its index if they don't exist
+}
+
+// NewTsdb creates a new TSDB instance whose table is created by the given DDL
+// statements. If ownsDb is true, Close() will close the database connection.
+func NewTsdb(db *sql.DB, ownsDb bool, dialect stmt.IDialect, ddl ...string) *Tsdb {
+	return &Tsdb{
+		db:      db,
+		mtx:     &sync.Mutex{},
+		ownsDb:  ownsDb,
+		dialect: dialect,
+		ddl:     ddl,
+	}
+}
+
+// verifyTable creates the l8tsdb table and index if they don't exist.
+func (this *Tsdb) verifyTable() error {
+	for _, ddl := range this.ddl {
+		if _, err := this.db.Exec(ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTSDB writes time series notifications to the database in a single transaction.
+func (this *Tsdb) AddTSDB(notifications []*l8notify.L8TSDBNotification) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+
+	if !this.verified {
+		if err := this.verifyTable(); err != nil {
+			return err
+		}
+		this.verified = true
+	}
+
+	tx, err := this.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	insert, err := tx.Prepare("INSERT INTO l8tsdb (stamp, prop_id, value) VALUES (" +
+		this.dialect.Placeholder(1) + ", " + this.dialect.Placeholder(2) + ", " + this.dialect.Placeholder(3) + ")")
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	for _, n := range notifications {
+		if n == nil || n.Point == nil {
+			continue
+		}
+		_, err = insert.Exec(n.Point.Stamp, n.PropertyId, n.Point.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTSDB retrieves time series data points for a property within a time range.
+func (this *Tsdb) GetTSDB(propertyId string, start, end int64) ([]*l8api.L8TimeSeriesPoint, error) {
+	if err := this.ensureTable(); err != nil {
+		return nil, err
+	}
+	rows, err := this.db.Query(
+		"SELECT stamp, value FROM l8tsdb WHERE prop_id = "+this.dialect.Placeholder(1)+
+			" AND stamp BETWEEN "+this.dialect.Placeholder(2)+" AND "+this.dialect.Placeholder(3)+" ORDER BY stamp",
+		propertyId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return scanPoints(rows)
+}
+
+// GetTSDBBuckets aggregates the data points of a property within a time range
+// per bucket of the given seconds, the buckets aligned on the Unix epoch and
+// the range widened to whole buckets. The points are aggregated in memory, as neither SQLite nor MySQL has a last
+// aggregate.
+func (this *Tsdb) GetTSDBBuckets(propertyId string, start, end, bucket int64, function string) ([]*l8api.L8TimeSeriesPoint, error) {
+	function, err := common.TsdbBucketArgs(bucket, function)
+	if err != nil {
+		return nil, err
+	}
+	start, end = common.BucketRange(start, end, bucket)
+	points, err := this.GetTSDB(propertyId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return common.BucketPoints(points, bucket, function), nil
+}
+
+// GetTSDBLatest retrieves the most recent N data points for a property, ordered chronologically.
+func (this *Tsdb) GetTSDBLatest(propertyId string, limit int) ([]*l8api.L8TimeSeriesPoint, error) {
+	if err := this.ensureTable(); err != nil {
+		return nil, err
+	}
+	rows, err := this.db.Query(
+		"SELECT stamp, value FROM ("+
+			"SELECT stamp, value FROM l8tsdb WHERE prop_id = "+this.dialect.Placeholder(1)+
+			" ORDER BY stamp DESC LIMIT "+this.dialect.Placeholder(2)+
+			") sub ORDER BY stamp",
+		propertyId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanPoints(rows)
+}
+
+// GetTSDBLatestBatch retrieves the most recent limit points of each of the
+// properties, or of each property matching a pattern, ordered chronologically
+// and grouped by property ID, in a single query.
+func (this *Tsdb) GetTSDBLatestBatch(propertyIds []string, limit int) (map[string][]*l8api.L8TimeSeriesPoint, error) {
+	if len(propertyIds) == 0 {
+		return map[string][]*l8api.L8TimeSeriesPoint{}, nil
+	}
+	if err := this.ensureTable(); err != nil {
+		return nil, err
+	}
+	filter, args := stmt.TsdbPropertyFilter(this.dialect, propertyIds)
+	rows, err := this.db.Query(
+		"SELECT prop_id, stamp_epoch, value FROM ("+
+			"SELECT prop_id, stamp AS stamp_epoch, value, "+
+			"row_number() OVER (PARTITION BY prop_id ORDER BY stamp DESC) AS row_num "+
+			"FROM l8tsdb WHERE "+filter+
+			") sub WHERE row_num <= "+this.dialect.Placeholder(len(args)+1)+" ORDER BY prop_id, stamp_epoch",
+		append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ScanPropertyPoints(rows)
+}
+
+// GetTSDBBatch retrieves the points of each of the properties, or of each
+// property matching a pattern, between start and end, grouped by property ID,
+// in a single query. With a bucket or a function, the points are aggregated
+// per bucket in memory, like GetTSDBBuckets does.
+func (this *Tsdb) GetTSDBBatch(propertyIds []string, start, end, bucket int64, function string) (map[string][]*l8api.L8TimeSeriesPoint, error) {
+	bucketed := bucket != 0 || function != ""
+	if bucketed {
+		var err error
+		if function, err = common.TsdbBucketArgs(bucket, function); err != nil {
+			return nil, err
+		}
+		start, end = common.BucketRange(start, end, bucket)
+	}
+	if len(propertyIds) == 0 {
+		return map[string][]*l8api.L8TimeSeriesPoint{}, nil
+	}
+	if err := this.ensureTable(); err != nil {
+		return nil, err
+	}
+	filter, args := stmt.TsdbPropertyFilter(this.dialect, propertyIds)
+	rows, err := this.db.Query(
+		"SELECT prop_id, stamp, value FROM l8tsdb WHERE "+filter+
+			" AND stamp BETWEEN "+this.dialect.Placeholder(len(args)+1)+" AND "+this.dialect.Placeholder(len(args)+2)+
+			" ORDER BY prop_id, stamp",
+		append(args, start, end)...)
+	if err != nil {
+		return nil, err
+	}
+	points, err := stmt.ScanPropertyPoints(rows)
+	if err != nil || !bucketed {
+		return points, err
+	}
+	for propertyId, series := range points {
+		points[propertyId] = common.BucketPoints(series, bucket, function)
+	}
+	return points, nil
+}
+
+// ensureTable creates the table on first use so reads against an empty
+// database return no points instead of a missing table error.
+func (this *Tsdb) ensureTable() error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.verified {
+		return nil
+	}
+	if err := this.verifyTable(); err != nil {
+		return err
+	}
+	this.verified = true
+	return nil
+}
+
+// scanPoints reads (stamp, value) rows into time series points.
+func scanPoints(rows *sql.Rows) ([]*l8api.L8TimeSeriesPoint, error) {
+	defer rows.Close()
+	var points []*l8api.L8TimeSeriesPoint
+	for rows.Next() {
+		p := &l8api.L8TimeSeriesPoint{}
+		if err := rows.Scan(&p.Stamp, &p.Value); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// SetRetention removes data points older than the given number of seconds.
+// Retention is applied on demand, callers may schedule it.
+func (this *Tsdb) SetRetention(seconds int64) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	_, err := this.db.Exec("DELETE FROM l8tsdb WHERE stamp < "+this.dialect.Placeholder(1), time.Now().Unix()-seconds)
+	return err
+}
+
+// Close releases the database connection if this instance owns it.
+func (this *Tsdb) Close() error {
+	if this.ownsDb {
+		return this.db.Close()
+	}
+	return nil
+}
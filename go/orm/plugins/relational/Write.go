@@ -0,0 +1,256 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package relational
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8orm/go/orm/stmt"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// WriteRelational persists relational data to the database.
+// It verifies all required tables exist, then writes all rows within a transaction.
+// POST/PUT use the stmt upsert, PATCH uses the COALESCE update. PUT first
+// deletes the child rows of the written roots, replacing the whole object. A
+// PATCH with a field mask first deletes the rows of the listed slice and map
+// fields, and inserts their rows, replacing them.
+// With versioning enabled, root rows are checked and their version incremented
+// in the same transaction; a stale version fails with a VersionConflictError.
+// POST/PUT of a soft deleted element clears its tombstone. For history types,
+// the current rows of the written elements are archived first.
+func (this *Relational) WriteRelational(action ifs.Action, data *l8orms.L8OrmRData) error {
+	return this.WriteRelationalContext(context.Background(), action, data)
+}
+
+// WriteRelationalContext is WriteRelational bounded by ctx.
+func (this *Relational) WriteRelationalContext(ctx context.Context, action ifs.Action, data *l8orms.L8OrmRData) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	rootNode, ok := this.res.Introspector().NodeByTypeName(data.RootTypeName)
+	if !ok {
+		return errors.New("Cannot find node for root type name " + data.RootTypeName)
+	}
+	err := this.VerifyTables(ctx, rootNode)
+	if err != nil {
+		return err
+	}
+	return this.writeData(ctx, action, rootNode, data)
+}
+
+// writeData writes all table data within a single database transaction.
+func (this *Relational) writeData(ctx context.Context, action ifs.Action, rootNode *l8reflect.L8Node, data *l8orms.L8OrmRData) error {
+	err := this.checkKeyLengths(data)
+	if err != nil {
+		return err
+	}
+	tx, err := this.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	rootKeys := convert.RootKeys(data)
+	now := time.Now().Unix()
+	err = this.PrepareWrite(ctx, tx, action, rootNode, rootKeys, now)
+	if err != nil {
+		return err
+	}
+	var replaced []convert.Collection
+	if action == ifs.PATCH {
+		replaced = convert.ReplacedCollections(data, rootNode)
+		err = this.deleteCollections(ctx, tx, rootNode, replaced)
+		if err != nil {
+			return err
+		}
+	}
+
+	for tableName, table := range data.Tables {
+		node, ok := this.res.Introspector().NodeByTypeName(tableName)
+		if !ok {
+			err = errors.New("No node was found for " + tableName)
+			return err
+		}
+		statement := stmt.NewStatement(node, table.Columns, nil, this.res.Registry(), this.dialect).WithContext(ctx)
+
+		var sqlStmt, insertStmt *sql.Stmt
+		if action == ifs.PATCH {
+			sqlStmt, err = statement.UpdateStatement(tx)
+		} else {
+			sqlStmt, err = statement.InsertStatement(tx)
+		}
+		if err != nil {
+			return err
+		}
+		// Rows of the replaced slices and maps were deleted, so they are inserted
+		var inserter *stmt.Statement
+		if len(replaced) > 0 && tableName != data.RootTypeName {
+			inserter = stmt.NewStatement(node, table.Columns, nil, this.res.Registry(), this.dialect).WithContext(ctx)
+			insertStmt, err = inserter.InsertStatement(tx)
+			if err != nil {
+				return err
+			}
+		}
+		versioned := tableName == data.RootTypeName && statement.IsVersioned(this.versionField)
+
+		for _, instRows := range table.InstanceRows {
+			for _, attrRows := range instRows.AttributeRows {
+				for _, row := range attrRows.Rows {
+					if versioned {
+						e := statement.CheckVersion(tx, row, this.versionField)
+						if e != nil {
+							err = e
+							return err
+						}
+					}
+					rowStatement, rowStmt, rowAction := statement, sqlStmt, action
+					if insertStmt != nil && convert.InCollections(row, replaced) {
+						rowStatement, rowStmt, rowAction = inserter, insertStmt, ifs.POST
+					}
+					args, e := rowStatement.RowValues(rowAction, row)
+					if e != nil {
+						err = e
+						return err
+					}
+					_, e = rowStmt.ExecContext(ctx, args...)
+					if e != nil {
+						err = e
+						return err
+					}
+				}
+			}
+		}
+	}
+	if this.isHistory(rootNode.TypeName) {
+		err = this.history.Stamp(ctx, tx, rootNode, rootKeys, now)
+	}
+	return err
+}
+
+// PrepareWrite does the work keyed by the written root elements before their
+// rows are written, inside the caller's transaction: archiving the current
+// rows of history types, deleting the child rows a PUT replaces and clearing
+// the tombstones of soft deleted elements written again.
+func (this *Relational) PrepareWrite(ctx context.Context, tx *sql.Tx, action ifs.Action, rootNode *l8reflect.L8Node, rootKeys []string, now int64) error {
+	if this.isHistory(rootNode.TypeName) {
+		err := this.history.Archive(ctx, tx, rootNode, rootKeys, now)
+		if err != nil {
+			return err
+		}
+	}
+	if action == ifs.PUT {
+		err := this.deleteChildRows(ctx, tx, rootNode, rootKeys)
+		if err != nil {
+			return err
+		}
+	}
+	if action != ifs.PATCH && this.IsSoftDelete(rootNode.TypeName) {
+		return this.restoreWritten(ctx, tx, rootNode, rootKeys)
+	}
+	return nil
+}
+
+// checkKeyLengths fails when a row of the data has a ParentKey or RecKey
+// longer than the key columns hold.
+func (this *Relational) checkKeyLengths(data *l8orms.L8OrmRData) error {
+	if this.maxKeyLength <= 0 {
+		return nil
+	}
+	for tableName, table := range data.Tables {
+		for _, instRows := range table.InstanceRows {
+			for _, attrRows := range instRows.AttributeRows {
+				for _, row := range attrRows.Rows {
+					for _, key := range []string{row.ParentKey, row.RecKey} {
+						if length := utf8.RuneCountInString(key); length > this.maxKeyLength {
+							return errors.New("Key of " + tableName + " is " + strconv.Itoa(length) +
+								" characters long, the key columns hold up to " + strconv.Itoa(this.maxKeyLength))
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Write converts Go objects to relational data and persists them to the database,
+// processing large element sets in batches of batchSize elements.
+func (this *Relational) Write(action ifs.Action, elems ifs.IElements, resources ifs.IResources) error {
+	return this.WriteContext(context.Background(), action, elems, resources)
+}
+
+// WriteContext is Write bounded by ctx. Each batch is written in its own
+// transaction, so batches committed before ctx expires are kept.
+func (this *Relational) WriteContext(ctx context.Context, action ifs.Action, elems ifs.IElements, resources ifs.IResources) error {
+	_, err := this.WriteKeys(ctx, action, elems, resources)
+	return err
+}
+
+// WriteKeys is WriteContext returning the RecKeys of the written root rows,
+// for backends maintaining caches of them.
+func (this *Relational) WriteKeys(ctx context.Context, action ifs.Action, elems ifs.IElements, resources ifs.IResources) ([]string, error) {
+	elements := elems.Elements()
+	written := make([]string, 0, len(elements))
+	for start := 0; start < len(elements); start += this.batchSize {
+		end := start + this.batchSize
+		if end > len(elements) {
+			end = len(elements)
+		}
+
+		batchElems := elems
+		if start > 0 || end < len(elements) {
+			batchSlice := make([]interface{}, end-start)
+			for i := start; i < end; i++ {
+				batchSlice[i-start] = elements[i]
+			}
+			batchElems = object.New(nil, batchSlice)
+			if mask := convert.FieldMaskOf(elems); mask != nil {
+				batchElems = convert.NewFieldMask(batchElems, mask...)
+			}
+		}
+
+		relData := convert.ConvertTo(action, batchElems, resources)
+		if relData.Error() != nil {
+			return written, relData.Error()
+		}
+		data := relData.Element().(*l8orms.L8OrmRData)
+		if err := this.WriteRelationalContext(ctx, action, data); err != nil {
+			return written, err
+		}
+		written = append(written, convert.RootKeys(data)...)
+		if len(data.TsData) > 0 {
+			if err := this.tsdb.AddTSDB(data.TsData); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,206 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sqlite provides an embedded SQLite implementation of the IORM,
+// IORMRelational and ITSDB interfaces. It uses the same ParentKey/RecKey table
+// layout as the PostgreSQL plugin, so relational data written by one backend
+// reads back the same way from the other. The plugin does not import a driver;
+// callers open the *sql.DB with the SQLite driver of their choice.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	strings2 "strings"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/plugins/relational"
+	"github.com/saichler/l8orm/go/orm/stmt"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+	"github.com/saichler/l8utils/go/utils/strings"
+)
+
+// tsdbDDL creates the time series table and its index.
+var tsdbDDL = []string{`CREATE TABLE IF NOT EXISTS l8tsdb (
+		stamp    INTEGER NOT NULL,
+		prop_id  TEXT    NOT NULL,
+		value    REAL    NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_l8tsdb_prop_stamp ON l8tsdb (prop_id, stamp DESC)`}
+
+// Sqlite implements the IORM, IORMRelational and ITSDB interfaces on top of an
+// embedded SQLite database file. The relational plugin does the reads and
+// writes with the SQLite dialect; Sqlite creates and migrates the tables.
+type Sqlite struct {
+	*relational.Relational
+}
+
+// NewSqlite creates a new SQLite ORM instance with the given database connection.
+// The time series store shares the same connection and database file.
+func NewSqlite(db *sql.DB, resourcs ifs.IResources) *Sqlite {
+	sqlite := &Sqlite{}
+	sqlite.Relational = relational.New(db, resourcs, stmt.Sqlite, sqlite,
+		relational.NewTsdb(db, false, stmt.Sqlite, tsdbDDL...))
+	return sqlite
+}
+
+// VerifyTable checks if a table exists and creates it if not.
+// If the table already exists, its columns are reconciled with the current
+// proto definition. SQLite table names are case-insensitive, so the lookup
+// in sqlite_master uses NOCASE collation.
+func (this *Sqlite) VerifyTable(ctx context.Context, tableName string, root bool) error {
+	var count int
+	err := this.DB().QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=$1 COLLATE NOCASE",
+		tableName).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		err = this.createTable(ctx, tableName, root)
+	} else {
+		err = this.migrateTable(ctx, tableName, root)
+	}
+	if err != nil || root {
+		return err
+	}
+	return this.createParentKeyIndex(ctx, tableName)
+}
+
+// createParentKeyIndex creates the index that serves ParentKey prefix matches
+// on a child table, used to load the child rows of a page of root rows.
+// Key prefixes are matched with the case-sensitive GLOB, which a BINARY index
+// serves. The NOCASE index created for LIKE by earlier versions is dropped.
+func (this *Sqlite) createParentKeyIndex(ctx context.Context, tableName string) error {
+	_, err := this.DB().ExecContext(ctx, "DROP INDEX IF EXISTS "+tableName+"_parentkey_idx;")
+	if err != nil {
+		return err
+	}
+	q := strings.New("CREATE INDEX IF NOT EXISTS ", tableName, "_parentkey_bin_idx ON ", tableName, " (ParentKey);")
+	_, err = this.DB().ExecContext(ctx, q.String())
+	return err
+}
+
+// migrateTable compares the live table columns against the current proto
+// definition and adds any missing columns via ALTER TABLE ADD COLUMN.
+// Like the PostgreSQL plugin it is purely additive.
+func (this *Sqlite) migrateTable(ctx context.Context, tableName string, root bool) error {
+	node, ok := this.Resources().Introspector().NodeByTypeName(tableName)
+	if !ok {
+		return errors.New("Cannot find node for table " + tableName)
+	}
+
+	rows, err := this.DB().QueryContext(ctx, "PRAGMA table_info("+tableName+")")
+	if err != nil {
+		return err
+	}
+	liveColumns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var colName, colType string
+		var defaultValue interface{}
+		if scanErr := rows.Scan(&cid, &colName, &colType, &notNull, &defaultValue, &pk); scanErr != nil {
+			rows.Close()
+			return scanErr
+		}
+		liveColumns[strings2.ToLower(colName)] = true
+	}
+	rows.Close()
+
+	missing := make([]string, 0)
+	missingTypes := make(map[string]string)
+	for attrName, attr := range node.Attributes {
+		if attr.IsStruct {
+			continue
+		}
+		if common.IsTimeSeriesType(attr.TypeName) {
+			continue
+		}
+		if liveColumns[strings2.ToLower(attrName)] {
+			continue
+		}
+		missing = append(missing, attrName)
+		missingTypes[attrName] = this.ColumnType(attrName, attr, root)
+	}
+	if root && this.IsSoftDelete(tableName) && !liveColumns[strings2.ToLower(common.DeletedAtColumn)] {
+		missing = append(missing, common.DeletedAtColumn)
+		missingTypes[common.DeletedAtColumn] = this.StampType()
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	this.Resources().Logger().Info("Migrating table ", tableName, ": adding columns ", missing)
+
+	for _, attrName := range missing {
+		alterQ := strings.New("ALTER TABLE ", tableName, " ADD COLUMN ", attrName, " ", missingTypes[attrName], ";")
+		_, err = this.DB().ExecContext(ctx, alterQ.String())
+		if err != nil {
+			return err
+		}
+	}
+	return this.createIndexes(ctx, tableName, node)
+}
+
+// createTable generates and executes DDL to create a table for the given type.
+// The layout matches the PostgreSQL plugin: ParentKey and RecKey text columns
+// forming the primary key, followed by one column per non-struct attribute.
+func (this *Sqlite) createTable(ctx context.Context, tableName string, root bool) error {
+	node, ok := this.Resources().Introspector().NodeByTypeName(tableName)
+	if !ok {
+		return errors.New("Cannot find node for table " + tableName)
+	}
+	q := strings.New("create table ", tableName, " (\n")
+	q.Add("ParentKey text,\n")
+	q.Add("RecKey text,\n")
+	for attrName, attr := range node.Attributes {
+		if attr.IsStruct {
+			continue
+		}
+		q.Add(attrName)
+		q.Add(" ")
+		q.Add(this.ColumnType(attrName, attr, root))
+		q.Add(",\n")
+	}
+	if root && this.IsSoftDelete(tableName) {
+		q.Add(common.DeletedAtColumn, " ", this.StampType(), ",\n")
+	}
+	q.Add("CONSTRAINT ", tableName, "_key PRIMARY KEY (ParentKey, RecKey)\n);")
+	_, err := this.DB().ExecContext(ctx, q.String())
+	if err != nil {
+		return err
+	}
+	return this.createIndexes(ctx, tableName, node)
+}
+
+// createIndexes creates the non-unique indexes for decorated fields.
+// IF NOT EXISTS keeps the call safe for both creation and migration.
+func (this *Sqlite) createIndexes(ctx context.Context, tableName string, node *l8reflect.L8Node) error {
+	nonUniqueFields, err := this.Resources().Introspector().Decorators().Fields(node, l8reflect.L8DecoratorType_NonUnique)
+	if err != nil || nonUniqueFields == nil {
+		return nil
+	}
+	for _, fieldName := range nonUniqueFields {
+		indexQ := strings.New("CREATE INDEX IF NOT EXISTS ", tableName, "_", fieldName, "_idx ON ", tableName, " (", fieldName, ");")
+		_, err = this.DB().ExecContext(ctx, indexQ.String())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
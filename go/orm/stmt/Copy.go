@@ -0,0 +1,66 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package stmt
+
+import (
+	"github.com/saichler/l8utils/go/utils/strings"
+)
+
+// stagingSeqColumn orders the rows of a staging table by load order.
+const stagingSeqColumn = "L8Seq"
+
+// StagingTable returns the name of the temporary table that bulk loads of
+// this table are staged in.
+func (this *Statement) StagingTable() string {
+	return this.node.TypeName + "_l8stage"
+}
+
+// CreateStagingSql generates the DDL of the staging table: a temporary table
+// with the columns of this table plus a load sequence, dropped at commit.
+func (this *Statement) CreateStagingSql() string {
+	return strings.New("CREATE TEMP TABLE IF NOT EXISTS ", this.dialect.Quote(this.StagingTable()),
+		" (LIKE ", this.dialect.Quote(this.node.TypeName), " INCLUDING DEFAULTS, ",
+		this.dialect.Quote(stagingSeqColumn), " bigserial) ON COMMIT DROP").String()
+}
+
+// CopySql generates the COPY FROM STDIN statement streaming rows into the
+// staging table. Its columns are in the order of the RowValues values.
+func (this *Statement) CopySql() string {
+	if this.fields == nil {
+		this.fields, this.values = fieldsOf(this.node)
+	}
+	return strings.New("COPY ", this.dialect.Quote(this.StagingTable()),
+		" (", this.columnList(this.fields), ") FROM STDIN").String()
+}
+
+// MergeSql generates the upsert merging the staging table into this table.
+// When the load holds the same row more than once, the last one wins, as it
+// does when the rows are written one by one.
+func (this *Statement) MergeSql() string {
+	fields, _ := fieldsOf(this.node)
+	columns := this.columnList(fields)
+	keys := this.columnList([]string{"ParentKey", "RecKey"})
+	updates := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field != "ParentKey" && field != "RecKey" {
+			updates = append(updates, field)
+		}
+	}
+	merge := strings.New("INSERT INTO ", this.dialect.Quote(this.node.TypeName), " (", columns, ")")
+	merge.Add(" SELECT DISTINCT ON (", keys, ") ", columns, " FROM ", this.dialect.Quote(this.StagingTable()))
+	merge.Add(" ORDER BY ", keys, ",", this.dialect.Quote(stagingSeqColumn), " DESC")
+	merge.Add(this.dialect.Upsert(updates))
+	return merge.String()
+}
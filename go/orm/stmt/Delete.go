@@ -20,40 +20,42 @@ import (
 	"github.com/saichler/l8utils/go/utils/strings"
 )
 
-// DeleteStatement generates and prepares a DELETE SQL statement.
-// For child tables, it deletes by ParentKey pattern matching.
+// DeleteStatement generates and prepares a DELETE SQL statement, returning it
+// with the bind arguments to execute it with.
+// For child tables, it deletes the rows whose ParentKey starts with parentKeyPattern.
 // For root tables, it uses the query criteria for filtering.
-func (this *Statement) DeleteStatement(tx *sql.Tx, parentKeyPattern string) (*sql.Stmt, error) {
+func (this *Statement) DeleteStatement(tx *sql.Tx, parentKeyPattern string) (*sql.Stmt, []interface{}, error) {
+	args := this.newBindArgs()
 	del := strings.New("DELETE FROM ")
-	del.Add(this.node.TypeName)
+	del.Add(this.dialect.Quote(this.node.TypeName))
 
 	// If parentKeyPattern is provided, delete by ParentKey pattern (for child tables)
 	if parentKeyPattern != "" {
-		del.Add(" WHERE ParentKey LIKE '")
-		del.Add(parentKeyPattern)
-		del.Add("%'")
+		del.Add(" WHERE ", args.bindPrefix("ParentKey", parentKeyPattern))
 	} else if this.query != nil && this.query.Criteria() != nil {
 		// For root table, use the query criteria
-		ok, whereClause := expression(this.query.Criteria(), this.query.RootType().TypeName)
+		ok, whereClause := this.expression(this.query.Criteria(), this.query.RootType().TypeName, args)
 		if ok {
 			del.Add(" WHERE ")
 			del.Add(whereClause)
 		}
 	}
 	del.Add(";")
-	return tx.Prepare(del.String())
+	st, err := tx.PrepareContext(this.context(), del.String())
+	return st, args.values, err
 }
 
 // DeleteByKeysStatement generates a DELETE statement that removes records
-// where ParentKey matches any of the provided key patterns.
-// Used for cascading deletes in child tables.
-func (this *Statement) DeleteByKeysStatement(tx *sql.Tx, keys []string) (*sql.Stmt, error) {
+// where ParentKey starts with any of the provided keys, returning it with the
+// bind arguments holding the key patterns. Used for cascading deletes in child tables.
+func (this *Statement) DeleteByKeysStatement(tx *sql.Tx, keys []string) (*sql.Stmt, []interface{}, error) {
 	if len(keys) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 
+	args := this.newBindArgs()
 	del := strings.New("DELETE FROM ")
-	del.Add(this.node.TypeName)
+	del.Add(this.dialect.Quote(this.node.TypeName))
 	del.Add(" WHERE ")
 
 	first := true
@@ -62,31 +64,74 @@ func (this *Statement) DeleteByKeysStatement(tx *sql.Tx, keys []string) (*sql.St
 			del.Add(" OR ")
 		}
 		first = false
-		del.Add("ParentKey LIKE '")
-		del.Add(key)
-		del.Add("%'")
+		del.Add(args.bindPrefix("ParentKey", key))
 	}
 
 	del.Add(";")
-	return tx.Prepare(del.String())
+	st, err := tx.PrepareContext(this.context(), del.String())
+	return st, args.values, err
 }
 
-// Query2DeleteSql generates a DELETE SQL string from a query.
-// Applies the query's criteria as a WHERE clause for the root table.
-func (this *Statement) Query2DeleteSql(query ifs.IQuery, typeName string) (string, bool) {
+// DeleteCollectionsStatement generates a DELETE statement that removes the
+// rows of slice or map fields, returning it with the bind arguments. The field
+// fieldNames[i] of the row keyed parentKeys[i] owns the rows with that
+// ParentKey whose RecKey starts with the field name, and the rows below them.
+// A PATCH with a field mask uses it to replace the listed slice and map fields.
+func (this *Statement) DeleteCollectionsStatement(tx *sql.Tx, parentKeys, fieldNames []string) (*sql.Stmt, []interface{}, error) {
+	if len(parentKeys) == 0 {
+		return nil, nil, nil
+	}
+
+	args := this.newBindArgs()
+	del := strings.New("DELETE FROM ")
+	del.Add(this.dialect.Quote(this.node.TypeName))
+	del.Add(" WHERE ")
+
+	for i, parentKey := range parentKeys {
+		if i > 0 {
+			del.Add(" OR ")
+		}
+		del.Add("(", this.dialect.Quote("ParentKey"), "=", args.bind(parentKey))
+		del.Add(" AND ", args.bindPrefix("RecKey", fieldNames[i]+"["), ")")
+		del.Add(" OR ", args.bindPrefix("ParentKey", parentKey+fieldNames[i]+"["))
+	}
+
+	del.Add(";")
+	st, err := tx.PrepareContext(this.context(), del.String())
+	return st, args.values, err
+}
+
+// DeleteRootsStatement generates a DELETE statement that removes the root rows
+// with the given RecKeys, returning it with the bind arguments holding the keys.
+// Deleting by the keys read beforehand, rather than by the criteria, keeps
+// criteria on child tables valid after the child rows are gone.
+func (this *Statement) DeleteRootsStatement(tx *sql.Tx, recKeys []string) (*sql.Stmt, []interface{}, error) {
+	args := this.newBindArgs()
+	del := strings.New("DELETE FROM ")
+	del.Add(this.dialect.Quote(this.node.TypeName))
+	del.Add(where(this.elementConditions(recKeys, true, args)))
+	del.Add(";")
+	st, err := tx.PrepareContext(this.context(), del.String())
+	return st, args.values, err
+}
+
+// Query2DeleteSql generates a DELETE SQL string from a query, together with its
+// bind arguments. Applies the query's criteria as a WHERE clause for the root table.
+func (this *Statement) Query2DeleteSql(query ifs.IQuery, typeName string) (string, []interface{}, bool) {
+	args := this.newBindArgs()
 	del := strings.New("DELETE FROM ")
-	del.Add(typeName)
+	del.Add(this.dialect.Quote(typeName))
 
 	if query.Criteria() == nil {
-		return del.String(), true
+		return del.String(), args.values, true
 	}
 
 	if typeName == query.RootType().TypeName {
-		ok, str := expression(query.Criteria(), query.RootType().TypeName)
+		ok, str := this.expression(query.Criteria(), query.RootType().TypeName, args)
 		if ok {
 			del.Add(" WHERE ")
 			del.Add(str)
 		}
 	}
-	return del.String(), true
+	return del.String(), args.values, true
 }
@@ -0,0 +1,351 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package stmt
+
+import (
+	"fmt"
+	"strconv"
+	strings2 "strings"
+
+	"github.com/saichler/l8types/go/types/l8reflect"
+	"github.com/saichler/l8utils/go/utils/strings"
+)
+
+// IDialect isolates the backend specific parts of the generated SQL, so that
+// every backend shares the same statement builders and criteria translation.
+// Supporting a new backend means implementing this interface.
+type IDialect interface {
+	// Placeholder returns the bind parameter marker for a 1-based position.
+	Placeholder(position int) string
+	// Quote returns a table or column name as a quoted identifier.
+	Quote(identifier string) string
+	// Upsert returns the clause appended to an INSERT so that a row with an
+	// existing (ParentKey, RecKey) has the given fields replaced.
+	Upsert(fields []string) string
+	// LimitOffset returns the clause restricting a result to a page of rows.
+	// A limit of 0 means no restriction.
+	LimitOffset(limit, offset int32) string
+	// TypeName returns the column type for a non-struct attribute.
+	TypeName(node *l8reflect.L8Node) string
+	// Like returns the pattern matching operator, surrounded by spaces.
+	Like(caseInsensitive bool) string
+	// Concat returns the expression concatenating two string expressions.
+	Concat(left, right string) string
+	// EscapeKey escapes a literal for a key pattern matched with KeyMatch, so
+	// its characters match themselves.
+	EscapeKey(literal string) string
+	// EscapeKeyExpr returns the expression escaping a string expression for a
+	// key pattern, like EscapeKey does for a literal.
+	EscapeKeyExpr(expr string) string
+	// KeyWildcard returns the key pattern matching any characters.
+	KeyWildcard() string
+	// KeyMatch returns the case-sensitive condition that a key column matches
+	// a key pattern.
+	KeyMatch(column, pattern string) string
+}
+
+// PostgresDialect generates PostgreSQL syntax.
+type PostgresDialect struct{}
+
+// SqliteDialect generates SQLite syntax. SQLite accepts the PostgreSQL
+// placeholders and upsert, so only quoting, types and LIKE differ.
+type SqliteDialect struct {
+	PostgresDialect
+}
+
+// MySQLDialect generates MySQL and MariaDB syntax.
+type MySQLDialect struct{}
+
+// Postgres is the dialect for PostgreSQL.
+var Postgres IDialect = &PostgresDialect{}
+
+// Sqlite is the dialect for SQLite.
+var Sqlite IDialect = &SqliteDialect{}
+
+// MySQL is the dialect for MySQL and MariaDB.
+var MySQL IDialect = &MySQLDialect{}
+
+// Placeholder returns $n.
+func (this *PostgresDialect) Placeholder(position int) string {
+	return "$" + strconv.Itoa(position)
+}
+
+// Quote lowercases the identifier before quoting it. Tables are created with
+// unquoted names, which PostgreSQL folds to lowercase, so the quoted form
+// refers to the same table and columns.
+func (this *PostgresDialect) Quote(identifier string) string {
+	return "\"" + strings2.ToLower(identifier) + "\""
+}
+
+// Upsert returns an ON CONFLICT clause updating the fields from EXCLUDED.
+// A table without attribute columns has nothing to update, so the conflict is ignored.
+func (this *PostgresDialect) Upsert(fields []string) string {
+	if len(fields) == 0 {
+		return " ON CONFLICT (ParentKey,RecKey) DO NOTHING"
+	}
+	conflict := strings.New(" ON CONFLICT (ParentKey,RecKey) DO UPDATE SET ")
+	for i, field := range fields {
+		if i > 0 {
+			conflict.Add(",")
+		}
+		conflict.Add(this.Quote(field), "=EXCLUDED.", this.Quote(field))
+	}
+	return conflict.String()
+}
+
+// LimitOffset returns LIMIT n OFFSET m.
+func (this *PostgresDialect) LimitOffset(limit, offset int32) string {
+	return limitOffset(limit, offset)
+}
+
+// TypeName maps Go types to PostgreSQL column types.
+// Maps and slices are stored as text (serialized), and enums default to integer.
+func (this *PostgresDialect) TypeName(node *l8reflect.L8Node) string {
+	if node.IsMap || node.IsSlice {
+		return "text"
+	}
+	switch node.TypeName {
+	case "string":
+		return "text"
+	case "int32":
+		return "integer"
+	case "int64":
+		return "bigint"
+	case "float64":
+		return "float8"
+	case "float32":
+		return "real"
+	case "bool":
+		return "boolean"
+	}
+	//default to enum for now - @TODO - reflect find what is the kind
+	return "integer"
+}
+
+// Like returns LIKE, or ILIKE for a case-insensitive match.
+func (this *PostgresDialect) Like(caseInsensitive bool) string {
+	if caseInsensitive {
+		return " ILIKE "
+	}
+	return " LIKE "
+}
+
+// Concat returns the || concatenation, which SQLite shares.
+func (this *PostgresDialect) Concat(left, right string) string {
+	return "(" + left + "||" + right + ")"
+}
+
+// keyEscaper escapes the LIKE wildcards and the '!' escape character of a key.
+var keyEscaper = strings2.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// EscapeKey escapes the LIKE wildcards with '!', a character that is not an
+// escape in string literals of any backend, unlike the backslash in MySQL.
+func (this *PostgresDialect) EscapeKey(literal string) string {
+	return keyEscaper.Replace(literal)
+}
+
+// EscapeKeyExpr escapes the LIKE wildcards of an expression with '!'.
+func (this *PostgresDialect) EscapeKeyExpr(expr string) string {
+	return likeEscapeExpr(expr)
+}
+
+// KeyWildcard returns %.
+func (this *PostgresDialect) KeyWildcard() string {
+	return "%"
+}
+
+// KeyMatch returns a LIKE with '!' as escape character. The ParentKey index
+// uses text_pattern_ops, so a prefix pattern is served by it.
+func (this *PostgresDialect) KeyMatch(column, pattern string) string {
+	return column + " LIKE " + pattern + " ESCAPE '!'"
+}
+
+// Quote quotes the identifier as is, SQLite identifiers are case-insensitive.
+func (this *SqliteDialect) Quote(identifier string) string {
+	return "\"" + identifier + "\""
+}
+
+// Upsert returns an ON CONFLICT clause using SQLite's quoting.
+func (this *SqliteDialect) Upsert(fields []string) string {
+	if len(fields) == 0 {
+		return " ON CONFLICT (ParentKey,RecKey) DO NOTHING"
+	}
+	conflict := strings.New(" ON CONFLICT (ParentKey,RecKey) DO UPDATE SET ")
+	for i, field := range fields {
+		if i > 0 {
+			conflict.Add(",")
+		}
+		conflict.Add(this.Quote(field), "=excluded.", this.Quote(field))
+	}
+	return conflict.String()
+}
+
+// TypeName maps Go types to SQLite column types.
+// SQLite uses type affinity, so the declared names only need to select the
+// right affinity; maps and slices are stored as serialized text.
+func (this *SqliteDialect) TypeName(node *l8reflect.L8Node) string {
+	if node.IsMap || node.IsSlice {
+		return "text"
+	}
+	switch node.TypeName {
+	case "string":
+		return "text"
+	case "int32", "int64":
+		return "integer"
+	case "float64", "float32":
+		return "real"
+	case "bool":
+		return "boolean"
+	}
+	return "integer"
+}
+
+// Like returns LIKE for both cases. SQLite's LIKE ignores case for ASCII
+// characters unless the case_sensitive_like pragma is set.
+func (this *SqliteDialect) Like(caseInsensitive bool) string {
+	return " LIKE "
+}
+
+// globEscaper escapes the GLOB wildcards of a key by enclosing them in brackets.
+var globEscaper = strings2.NewReplacer("[", "[[]", "*", "[*]", "?", "[?]")
+
+// EscapeKey escapes the GLOB wildcards of the literal.
+func (this *SqliteDialect) EscapeKey(literal string) string {
+	return globEscaper.Replace(literal)
+}
+
+// EscapeKeyExpr escapes the GLOB wildcards of an expression, the brackets
+// first so the ones added for the other wildcards are kept.
+func (this *SqliteDialect) EscapeKeyExpr(expr string) string {
+	return "replace(replace(replace(" + expr + ",'[','[[]'),'*','[*]'),'?','[?]')"
+}
+
+// KeyWildcard returns *.
+func (this *SqliteDialect) KeyWildcard() string {
+	return "*"
+}
+
+// KeyMatch returns a GLOB, which unlike SQLite's LIKE is case-sensitive and
+// can use the binary ParentKey index for a prefix pattern.
+func (this *SqliteDialect) KeyMatch(column, pattern string) string {
+	return column + " GLOB " + pattern
+}
+
+// Placeholder returns ?, MySQL binds parameters by order of appearance.
+func (this *MySQLDialect) Placeholder(position int) string {
+	return "?"
+}
+
+// Quote quotes the identifier with backticks.
+func (this *MySQLDialect) Quote(identifier string) string {
+	return "`" + identifier + "`"
+}
+
+// Upsert returns an ON DUPLICATE KEY UPDATE clause updating the fields from
+// the inserted VALUES, a form accepted by both MySQL and MariaDB.
+func (this *MySQLDialect) Upsert(fields []string) string {
+	if len(fields) == 0 {
+		return " ON DUPLICATE KEY UPDATE RecKey=RecKey"
+	}
+	dup := strings.New(" ON DUPLICATE KEY UPDATE ")
+	for i, field := range fields {
+		if i > 0 {
+			dup.Add(",")
+		}
+		dup.Add(this.Quote(field), "=VALUES(", this.Quote(field), ")")
+	}
+	return dup.String()
+}
+
+// LimitOffset returns LIMIT n OFFSET m.
+func (this *MySQLDialect) LimitOffset(limit, offset int32) string {
+	return limitOffset(limit, offset)
+}
+
+// TypeName maps Go types to MySQL column types.
+// Strings, maps and slices are stored as TEXT, and enums default to INT.
+func (this *MySQLDialect) TypeName(node *l8reflect.L8Node) string {
+	if node.IsMap || node.IsSlice {
+		return "TEXT"
+	}
+	switch node.TypeName {
+	case "string":
+		return "TEXT"
+	case "int32":
+		return "INT"
+	case "int64":
+		return "BIGINT"
+	case "float64":
+		return "DOUBLE"
+	case "float32":
+		return "FLOAT"
+	case "bool":
+		return "BOOLEAN"
+	}
+	return "INT"
+}
+
+// Like returns LIKE for a case-insensitive match under the default collation,
+// and LIKE BINARY so a case-sensitive match behaves as it does on PostgreSQL.
+func (this *MySQLDialect) Like(caseInsensitive bool) string {
+	if caseInsensitive {
+		return " LIKE "
+	}
+	return " LIKE BINARY "
+}
+
+// Concat returns CONCAT(), since || is a logical OR under the default sql_mode.
+func (this *MySQLDialect) Concat(left, right string) string {
+	return "CONCAT(" + left + "," + right + ")"
+}
+
+// EscapeKey escapes the LIKE wildcards with '!'.
+func (this *MySQLDialect) EscapeKey(literal string) string {
+	return keyEscaper.Replace(literal)
+}
+
+// EscapeKeyExpr escapes the LIKE wildcards of an expression with '!'.
+func (this *MySQLDialect) EscapeKeyExpr(expr string) string {
+	return likeEscapeExpr(expr)
+}
+
+// KeyWildcard returns %.
+func (this *MySQLDialect) KeyWildcard() string {
+	return "%"
+}
+
+// KeyMatch returns a LIKE with '!' as escape character. The key columns use
+// the utf8mb4_bin collation, so it is case-sensitive and, unlike LIKE BINARY,
+// a prefix pattern can use the index of the column.
+func (this *MySQLDialect) KeyMatch(column, pattern string) string {
+	return column + " LIKE " + pattern + " ESCAPE '!'"
+}
+
+// likeEscapeExpr escapes the LIKE wildcards of an expression with '!', the
+// escape character itself first so the ones added for the wildcards are kept.
+func likeEscapeExpr(expr string) string {
+	return "replace(replace(replace(" + expr + ",'!','!!'),'%','!%'),'_','!_')"
+}
+
+// limitOffset builds the standard LIMIT/OFFSET clause shared by the dialects.
+func limitOffset(limit, offset int32) string {
+	if limit <= 0 {
+		return ""
+	}
+	if offset > 0 {
+		return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+	}
+	return fmt.Sprintf(" LIMIT %d", limit)
+}
@@ -0,0 +1,111 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package stmt
+
+import (
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8utils/go/utils/strings"
+)
+
+// ArchiveSql generates an INSERT copying the current rows of the given root
+// elements into the history table, valid from their stamp until validTo,
+// together with its bind arguments. Root tables select the rows by RecKey,
+// child tables by ParentKey prefix.
+func (this *Statement) ArchiveSql(rootKeys []string, root bool, validTo int64) (string, []interface{}) {
+	args := this.newBindArgs()
+	fields, _ := fieldsOf(this.node)
+	columns := this.columnList(fields)
+	validFrom := this.dialect.Quote(common.ValidFromColumn)
+	ins := strings.New("INSERT INTO ", this.dialect.Quote(common.HistoryTable(this.node.TypeName)),
+		" (", columns, ",", validFrom, ",", this.dialect.Quote(common.ValidToColumn), ")")
+	ins.Add(" SELECT ", columns, ",COALESCE(", validFrom, ",0),", args.bind(validTo))
+	ins.Add(" FROM ", this.dialect.Quote(this.node.TypeName))
+	ins.Add(where(this.elementConditions(rootKeys, root, args)))
+	return ins.String(), args.values
+}
+
+// StampSql generates an UPDATE setting the valid-from time of the current rows
+// of the given root elements, together with its bind arguments.
+func (this *Statement) StampSql(rootKeys []string, root bool, validFrom int64) (string, []interface{}) {
+	args := this.newBindArgs()
+	upd := strings.New("UPDATE ", this.dialect.Quote(this.node.TypeName),
+		" SET ", this.dialect.Quote(common.ValidFromColumn), "=", args.bind(validFrom))
+	upd.Add(where(this.elementConditions(rootKeys, root, args)))
+	return upd.String(), args.values
+}
+
+// elementConditions selects the rows of the given root elements: the root rows
+// by RecKey, or the child rows whose ParentKey starts with one of the keys.
+func (this *Statement) elementConditions(rootKeys []string, root bool, args *bindArgs) []string {
+	keys := strings.New("(")
+	if root {
+		keys.Add(this.dialect.Quote("RecKey"), " IN (")
+		for i, key := range rootKeys {
+			if i > 0 {
+				keys.Add(",")
+			}
+			keys.Add(args.bind(key))
+		}
+		keys.Add("))")
+		return []string{this.dialect.Quote("ParentKey") + "=" + args.bind(""), keys.String()}
+	}
+	for i, key := range rootKeys {
+		if i > 0 {
+			keys.Add(" OR ")
+		}
+		keys.Add(args.bindPrefix("ParentKey", key))
+	}
+	keys.Add(")")
+	return []string{keys.String()}
+}
+
+// Query2AsOfSql generates a SELECT of the table's rows as they stood at the
+// given Unix time, together with its bind arguments. Current rows valid since
+// then are combined with the history rows valid at that time. On the root
+// table the query criteria, sort and paging apply; soft delete queries skip
+// rows that were already tombstoned at that time. Nested criteria match the
+// child rows valid at that time, from the child tables and their history.
+func (this *Statement) Query2AsOfSql(query ifs.IQuery, typeName string, asOf int64) (string, []interface{}) {
+	args := this.newBindArgs()
+	args.asOf = &asOf
+	if this.fields == nil {
+		this.fields, this.values = fieldsOf(this.node)
+	}
+	columns := this.columnList(this.fields)
+	validFrom := this.dialect.Quote(common.ValidFromColumn)
+
+	live := this.criteria(query, typeName, args)
+	root := live != nil
+	if root && common.ExcludesDeleted(query) {
+		deletedAt := this.dialect.Quote(common.DeletedAtColumn)
+		live = append(live, "("+deletedAt+" IS NULL OR "+deletedAt+">"+args.bind(asOf)+")")
+	}
+	live = append(live, "COALESCE("+validFrom+",0)<="+args.bind(asOf))
+
+	history := this.criteria(query, typeName, args)
+	history = append(history, validFrom+"<="+args.bind(asOf),
+		this.dialect.Quote(common.ValidToColumn)+">"+args.bind(asOf))
+
+	sel := strings.New("SELECT ", columns, " FROM ", this.dialect.Quote(typeName), where(live))
+	// The history table is aliased to the live table's name, which nested criteria refer to.
+	sel.Add(" UNION ALL SELECT ", columns, " FROM ", this.dialect.Quote(common.HistoryTable(typeName)), " ", this.dialect.Quote(typeName), where(history))
+	if root {
+		// A UNION can only be ordered by its columns, so nested sort keys are left out.
+		sel.Add(this.orderBy(query, nil))
+		sel.Add(this.dialect.LimitOffset(query.Limit(), query.Page()*query.Limit()))
+	}
+	return sel.String(), args.values
+}
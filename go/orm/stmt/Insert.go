@@ -17,7 +17,6 @@ package stmt
 import (
 	"database/sql"
 	"github.com/saichler/l8utils/go/utils/strings"
-	"strconv"
 )
 
 // InsertStatement returns a prepared INSERT statement with upsert capability.
@@ -32,47 +31,38 @@ func (this *Statement) InsertStatement(tx *sql.Tx) (*sql.Stmt, error) {
 	return this.insertStmt, nil
 }
 
-// createInsertStatement generates and prepares an INSERT SQL statement with ON CONFLICT handling.
-// When a record with the same (ParentKey, RecKey) exists, it updates all other columns.
+// createInsertStatement generates and prepares an INSERT SQL statement with the
+// dialect's upsert clause. When a record with the same (ParentKey, RecKey) exists,
+// it updates all other columns.
 func (this *Statement) createInsertStatement(tx *sql.Tx) error {
-	insertInto := strings.New("insert into ", this.node.TypeName)
+	insertInto := strings.New("insert into ", this.dialect.Quote(this.node.TypeName))
 	if this.fields == nil {
 		this.fields, this.values = fieldsOf(this.node)
 	}
 	fields := strings.New(" (")
 	values := strings.New(" values (")
-	conflict := strings.New("ON CONFLICT (ParentKey,RecKey) DO UPDATE SET ")
+	updates := make([]string, 0, len(this.fields))
 	first := true
-	firstConflict := true
 	for _, field := range this.fields {
 		if !first {
 			fields.Add(",")
 			values.Add(",")
 		}
 		first = false
-		fields.Add(field)
-		values.Add("$")
-		values.Add(strconv.Itoa(this.values[field]))
+		fields.Add(this.dialect.Quote(field))
+		values.Add(this.dialect.Placeholder(this.values[field]))
 		if field != "ParentKey" && field != "RecKey" {
-			if !firstConflict {
-				conflict.Add(",")
-			}
-			firstConflict = false
-			conflict.Add(field)
-			conflict.Add("=")
-			conflict.Add("$")
-			conflict.Add(strconv.Itoa(this.values[field]))
-			conflict.Add(" ")
+			updates = append(updates, field)
 		}
 	}
-	fields.Add(") ")
-	values.Add(") ")
+	fields.Add(")")
+	values.Add(")")
 	insertInto.Add(fields.String())
 	insertInto.Add(values.String())
-	insertInto.Add(conflict.String())
+	insertInto.Add(this.dialect.Upsert(updates))
 	insertInto.Add(";")
 
-	st, err := tx.Prepare(insertInto.String())
+	st, err := tx.PrepareContext(this.context(), insertInto.String())
 	if err != nil {
 		return err
 	}
@@ -30,7 +30,7 @@ func (this *Statement) MetaData(tx *sql.Tx) *l8api.L8MetaData {
 	metadata.KeyCount = &l8api.L8Count{}
 	metadata.KeyCount.Counts = make(map[string]float64)
 	totalRecords := 0
-	rows, err := stmt.Query()
+	rows, err := stmt.QueryContext(this.context(), this.countArgs...)
 	if err != nil {
 		return nil
 	}
@@ -59,11 +59,12 @@ func (this *Statement) metadataStatement(tx *sql.Tx) (*sql.Stmt, error) {
 
 // createMetadataStatement generates and prepares a COUNT SQL statement.
 func (this *Statement) createMetadataStatement(tx *sql.Tx) error {
-	sql := this.Query2CountSql(this.query, this.node.TypeName)
-	st, err := tx.Prepare(sql)
+	sql, args := this.Query2CountSql(this.query, this.node.TypeName)
+	st, err := tx.PrepareContext(this.context(), sql)
 	if err != nil {
 		return err
 	}
 	this.metaDataStmt = st
+	this.countArgs = args
 	return nil
 }
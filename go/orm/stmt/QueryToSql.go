@@ -16,116 +16,148 @@ package stmt
 
 import (
 	"bytes"
-	"fmt"
+	"github.com/saichler/l8orm/go/orm/common"
 	"github.com/saichler/l8types/go/ifs"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
-// Query2CountSql generates a SELECT COUNT(*) SQL string from a query.
-// Applies the query's criteria as a WHERE clause if present.
-func (this *Statement) Query2CountSql(query ifs.IQuery, typeName string) string {
+// bindArgs collects the bind parameter values of a generated SQL string, in
+// order of appearance, and returns the dialect's placeholder for each.
+type bindArgs struct {
+	dialect IDialect
+	values  []interface{}
+	asOf    *int64 // Time of an as-of query, whose nested criteria read the child rows valid then
+}
+
+// newBindArgs creates an empty argument list for the statement's dialect.
+func (this *Statement) newBindArgs() *bindArgs {
+	return &bindArgs{dialect: this.dialect, values: make([]interface{}, 0)}
+}
+
+// bind adds a value and returns its placeholder.
+func (this *bindArgs) bind(value interface{}) string {
+	this.values = append(this.values, value)
+	return this.dialect.Placeholder(len(this.values))
+}
+
+// bindPrefix binds a key prefix and returns the condition selecting the rows
+// whose key column starts with it. The prefix is escaped, so its characters
+// match themselves and never act as wildcards.
+func (this *bindArgs) bindPrefix(column, prefix string) string {
+	pattern := this.bind(this.dialect.EscapeKey(prefix) + this.dialect.KeyWildcard())
+	return this.dialect.KeyMatch(this.dialect.Quote(column), pattern)
+}
+
+// Query2CountSql generates a SELECT COUNT(*) SQL string from a query, together
+// with its bind arguments. Applies the query's criteria as a WHERE clause if present.
+func (this *Statement) Query2CountSql(query ifs.IQuery, typeName string) (string, []interface{}) {
+	args := this.newBindArgs()
 	buff := bytes.Buffer{}
 	buff.WriteString("SELECT COUNT(*) FROM ")
-	buff.WriteString(typeName)
+	buff.WriteString(this.dialect.Quote(typeName))
+	buff.WriteString(this.rootWhere(query, typeName, args))
+	return buff.String(), args.values
+}
+
+// rootWhere returns the WHERE clause of a select on the query's root table:
+// the query criteria and, for soft delete queries, the exclusion of tombstoned
+// rows. It is empty for other tables and when there is nothing to filter.
+func (this *Statement) rootWhere(query ifs.IQuery, typeName string, args *bindArgs) string {
+	conditions := this.criteria(query, typeName, args)
+	if conditions != nil && common.ExcludesDeleted(query) {
+		conditions = append(conditions, this.dialect.Quote(common.DeletedAtColumn)+" IS NULL")
+	}
+	return where(conditions)
+}
 
-	if query != nil && query.Criteria() != nil && typeName == query.RootType().TypeName {
-		ok, str := expression(query.Criteria(), query.RootType().TypeName)
+// criteria returns the query criteria as a list of conditions for the query's
+// root table, empty when the query has none, and nil for other tables.
+func (this *Statement) criteria(query ifs.IQuery, typeName string, args *bindArgs) []string {
+	if query == nil || typeName != query.RootType().TypeName {
+		return nil
+	}
+	conditions := make([]string, 0, 3)
+	if query.Criteria() != nil {
+		ok, str := this.expression(query.Criteria(), typeName, args)
 		if ok {
-			buff.WriteString(" WHERE ")
-			buff.WriteString(str)
+			conditions = append(conditions, str)
 		}
 	}
-	return buff.String()
+	return conditions
 }
 
-// Query2Sql generates a SELECT SQL string from a query object.
-// It handles column projections, aggregate functions, WHERE criteria,
-// GROUP BY, HAVING, ORDER BY, LIMIT, and OFFSET clauses.
+// where joins conditions into a WHERE clause, empty when there are none.
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// Query2Sql generates a SELECT SQL string from a query object, together with
+// its bind arguments. It handles column projections, aggregate functions, WHERE
+// criteria, GROUP BY, HAVING, ORDER BY, LIMIT, and OFFSET clauses.
 // Returns false if the query doesn't select any columns for this table.
-func (this *Statement) Query2Sql(query ifs.IQuery, typeName string) (string, bool) {
+func (this *Statement) Query2Sql(query ifs.IQuery, typeName string) (string, []interface{}, bool) {
 	// Delegate to aggregate SQL builder when aggregate functions are present
 	if len(query.Aggregates()) > 0 {
 		return this.query2AggregateSql(query, typeName)
 	}
 
+	args := this.newBindArgs()
 	buff := bytes.Buffer{}
 	if query.Properties() == nil || len(query.Properties()) == 0 {
 		buff.WriteString("Select ")
 		if this.fields == nil {
 			this.fields, this.values = fieldsOf(this.node)
 		}
-		first := true
-		for _, fieldName := range this.fields {
-			if !first {
-				buff.WriteString(",")
-			}
-			first = false
-			buff.WriteString(fieldName)
-		}
+		buff.WriteString(this.columnList(this.fields))
 	} else {
-		buff.WriteString("Select ParentKey,RecKey")
 		this.fields = []string{"ParentKey", "RecKey"}
 		for _, prop := range query.Properties() {
-			buff.WriteString(",")
 			if prop.Node().Parent.TypeName == typeName {
 				this.fields = append(this.fields, prop.Node().FieldName)
-				buff.WriteString(prop.Node().FieldName)
 			}
 		}
 		if len(this.fields) == 2 {
-			return "", false
+			return "", nil, false
 		}
+		buff.WriteString("Select ")
+		buff.WriteString(this.columnList(this.fields))
 	}
 	buff.WriteString(" from ")
-	buff.WriteString(typeName)
+	buff.WriteString(this.dialect.Quote(typeName))
 
 	if query.Criteria() == nil {
-		return buff.String(), true
+		buff.WriteString(this.rootWhere(query, typeName, args))
+		return buff.String(), args.values, true
 	}
 
 	if typeName == query.RootType().TypeName {
-		ok, str := expression(query.Criteria(), query.RootType().TypeName)
-		if ok {
-			buff.WriteString(" where ")
-			buff.WriteString(str)
-		}
+		buff.WriteString(this.rootWhere(query, typeName, args))
 
 		// Add ORDER BY clause if SortBy is specified
-		if query.SortBy() != "" {
-			buff.WriteString(" ORDER BY ")
-			buff.WriteString(query.SortBy())
-			if query.Descending() {
-				buff.WriteString(" DESC")
-			} else {
-				buff.WriteString(" ASC")
-			}
-		}
+		buff.WriteString(this.orderBy(query, args))
 
-		// Add LIMIT clause if Limit is specified
-		if query.Limit() > 0 {
-			buff.WriteString(fmt.Sprintf(" LIMIT %d", query.Limit()))
-		}
-
-		// Add OFFSET clause for pagination (Page starts from 0)
-		if query.Page() > 0 && query.Limit() > 0 {
-			offset := query.Page() * query.Limit()
-			buff.WriteString(fmt.Sprintf(" OFFSET %d", offset))
-		}
+		// Add LIMIT and OFFSET for pagination (Page starts from 0)
+		buff.WriteString(this.dialect.LimitOffset(query.Limit(), query.Page()*query.Limit()))
 	}
-	return buff.String(), true
+	return buff.String(), args.values, true
 }
 
-// AggregateSql generates an aggregate SQL string for the query's root type.
-// This is the public entry point used by ReadAggregate.
-func (this *Statement) AggregateSql(query ifs.IQuery) (string, bool) {
+// AggregateSql generates an aggregate SQL string for the query's root type,
+// together with its bind arguments. This is the public entry point used by ReadAggregate.
+func (this *Statement) AggregateSql(query ifs.IQuery) (string, []interface{}, bool) {
 	return this.query2AggregateSql(query, query.RootType().TypeName)
 }
 
 // query2AggregateSql generates a SELECT SQL string for aggregate queries.
 // It builds the SELECT clause with aggregate functions and group-by fields,
 // then appends WHERE, GROUP BY, HAVING, ORDER BY, LIMIT, and OFFSET clauses.
-func (this *Statement) query2AggregateSql(query ifs.IQuery, typeName string) (string, bool) {
+func (this *Statement) query2AggregateSql(query ifs.IQuery, typeName string) (string, []interface{}, bool) {
+	args := this.newBindArgs()
 	buff := bytes.Buffer{}
 	buff.WriteString("Select ")
 
@@ -137,7 +169,7 @@ func (this *Statement) query2AggregateSql(query ifs.IQuery, typeName string) (st
 			buff.WriteString(",")
 		}
 		first = false
-		buff.WriteString(gb)
+		buff.WriteString(this.dialect.Quote(gb))
 	}
 
 	// Add aggregate functions to SELECT
@@ -148,22 +180,20 @@ func (this *Statement) query2AggregateSql(query ifs.IQuery, typeName string) (st
 		first = false
 		buff.WriteString(strings.ToUpper(agg.Function))
 		buff.WriteString("(")
-		buff.WriteString(agg.Field)
+		if agg.Field == "*" {
+			buff.WriteString(agg.Field)
+		} else {
+			buff.WriteString(this.dialect.Quote(agg.Field))
+		}
 		buff.WriteString(") AS ")
 		buff.WriteString(agg.Alias)
 	}
 
 	buff.WriteString(" from ")
-	buff.WriteString(typeName)
+	buff.WriteString(this.dialect.Quote(typeName))
 
 	// Add WHERE clause
-	if query.Criteria() != nil && typeName == query.RootType().TypeName {
-		ok, str := expression(query.Criteria(), query.RootType().TypeName)
-		if ok {
-			buff.WriteString(" where ")
-			buff.WriteString(str)
-		}
-	}
+	buff.WriteString(this.rootWhere(query, typeName, args))
 
 	// Add GROUP BY clause
 	if len(query.GroupBy()) > 0 {
@@ -172,13 +202,13 @@ func (this *Statement) query2AggregateSql(query ifs.IQuery, typeName string) (st
 			if i > 0 {
 				buff.WriteString(",")
 			}
-			buff.WriteString(gb)
+			buff.WriteString(this.dialect.Quote(gb))
 		}
 	}
 
 	// Add HAVING clause
 	if query.Having() != nil && typeName == query.RootType().TypeName {
-		ok, str := expression(query.Having(), query.RootType().TypeName)
+		ok, str := this.expression(query.Having(), query.RootType().TypeName, args)
 		if ok {
 			buff.WriteString(" HAVING ")
 			buff.WriteString(str)
@@ -186,45 +216,83 @@ func (this *Statement) query2AggregateSql(query ifs.IQuery, typeName string) (st
 	}
 
 	// Add ORDER BY clause
-	if query.SortBy() != "" {
-		buff.WriteString(" ORDER BY ")
-		buff.WriteString(query.SortBy())
-		if query.Descending() {
-			buff.WriteString(" DESC")
+	buff.WriteString(this.orderBy(query, args))
+
+	// Add LIMIT and OFFSET clauses
+	buff.WriteString(this.dialect.LimitOffset(query.Limit(), query.Page()*query.Limit()))
+
+	return buff.String(), args.values, true
+}
+
+// orderBy returns the ORDER BY clause for the query's sort keys, or an empty
+// string when the query is not sorted. Keys on attributes of nested structs
+// order by a subquery on the child table, binding its key patterns to args.
+// With nil args, as for a UNION that can only be ordered by its own columns,
+// those keys are left out.
+func (this *Statement) orderBy(query ifs.IQuery, args *bindArgs) string {
+	keys := common.SortKeys(query)
+	items := make([]string, 0, len(keys))
+	for _, key := range keys {
+		column := this.sortColumn(key.Field, query.RootType().TypeName, args)
+		if column == "" {
+			continue
+		}
+		if key.Descending {
+			items = append(items, column+" DESC")
 		} else {
-			buff.WriteString(" ASC")
+			items = append(items, column+" ASC")
 		}
 	}
-
-	// Add LIMIT clause
-	if query.Limit() > 0 {
-		buff.WriteString(fmt.Sprintf(" LIMIT %d", query.Limit()))
+	if len(items) == 0 {
+		return ""
 	}
+	return " ORDER BY " + strings.Join(items, ",")
+}
 
-	// Add OFFSET clause
-	if query.Page() > 0 && query.Limit() > 0 {
-		offset := query.Page() * query.Limit()
-		buff.WriteString(fmt.Sprintf(" OFFSET %d", offset))
+// sortColumn returns the expression a sort key field orders the rows of
+// typeName by. A field that does not resolve to an attribute, such as an
+// aggregate alias, is used as a column name as is.
+func (this *Statement) sortColumn(field, typeName string, args *bindArgs) string {
+	attr, path, ok := common.SortAttribute(this.node, field)
+	if !ok {
+		return this.dialect.Quote(field)
 	}
-
-	return buff.String(), true
+	if len(path) == 0 {
+		return this.dialect.Quote(attr.FieldName)
+	}
+	if args == nil {
+		return ""
+	}
+	buff := bytes.Buffer{}
+	buff.WriteString("(SELECT ")
+	buff.WriteString(this.dialect.Quote(attr.FieldName))
+	buff.WriteString(" FROM ")
+	buff.WriteString(this.dialect.Quote(attr.Parent.TypeName))
+	buff.WriteString(" ")
+	buff.WriteString(this.dialect.Quote(childAlias))
+	buff.WriteString(" WHERE ")
+	buff.WriteString(this.childRowConditions(typeName, path, args))
+	buff.WriteString(this.dialect.LimitOffset(1, 0))
+	buff.WriteString(")")
+	return buff.String()
 }
 
-// expression converts an IExpression to a SQL WHERE clause fragment.
-// It recursively processes the expression tree, combining conditions with operators.
-func expression(exp ifs.IExpression, typeName string) (bool, string) {
+// expression converts an IExpression to a SQL WHERE clause fragment, binding
+// its literal values to args. It recursively processes the expression tree,
+// combining conditions with operators.
+func (this *Statement) expression(exp ifs.IExpression, typeName string, args *bindArgs) (bool, string) {
 	if isNil(exp) {
 		return false, ""
 	}
 
 	buff := bytes.Buffer{}
-	condOK, condStr := condition(exp.Condition(), typeName)
+	condOK, condStr := this.condition(exp.Condition(), typeName, args)
 	if condOK {
 		buff.WriteString("(")
 		buff.WriteString(condStr)
 	}
 
-	nextOK, nextStr := expression(exp.Next(), typeName)
+	nextOK, nextStr := this.expression(exp.Next(), typeName, args)
 	if nextOK {
 		if !condOK {
 			buff.WriteString("(")
@@ -240,16 +308,16 @@ func expression(exp ifs.IExpression, typeName string) (bool, string) {
 
 // condition converts an ICondition to a SQL condition string.
 // It combines comparators with logical operators (AND/OR).
-func condition(cond ifs.ICondition, typeName string) (bool, string) {
+func (this *Statement) condition(cond ifs.ICondition, typeName string, args *bindArgs) (bool, string) {
 	if isNil(cond) {
 		return false, ""
 	}
 	result := bytes.Buffer{}
-	okCond, exp1 := comparator(cond.Comparator(), typeName)
+	okCond, exp1 := this.comparator(cond.Comparator(), typeName, args)
 	if okCond {
 		result.WriteString(exp1)
 	}
-	okNext, exp2 := condition(cond.Next(), typeName)
+	okNext, exp2 := this.condition(cond.Next(), typeName, args)
 	if okNext {
 		result.WriteString(cond.Operator())
 		result.WriteString(exp2)
@@ -258,8 +326,11 @@ func condition(cond ifs.ICondition, typeName string) (bool, string) {
 }
 
 // comparator converts an IComparator to a SQL comparison expression.
-// It handles string quoting based on property types.
-func comparator(comp ifs.IComparator, typeName string) (bool, string) {
+// Properties of the table are written as quoted column names and literals are
+// bound as parameters, converted to the type of the property they are compared
+// with. Comparators on child tables of the table become EXISTS subqueries, and
+// comparators on other tables bind nothing and report false.
+func (this *Statement) comparator(comp ifs.IComparator, typeName string, args *bindArgs) (bool, string) {
 	if isNil(comp) {
 		return false, ""
 	}
@@ -286,37 +357,158 @@ func comparator(comp ifs.IComparator, typeName string) (bool, string) {
 		}
 	}
 
+	if !leftOK && !rightOK {
+		return this.nestedComparator(comp, typeName, args)
+	}
+
 	buff := bytes.Buffer{}
 	if leftString && !rightString {
-		buff.WriteString(comp.Left())
-		rightValue := stripQuotes(comp.Right())
-		convertedValue, hasWildcard := convertWildcard(rightValue)
+		buff.WriteString(this.dialect.Quote(comp.LeftProperty().Node().FieldName))
+		convertedValue, hasWildcard := convertWildcard(stripQuotes(comp.Right()))
 		if hasWildcard && comp.Operator() == "=" {
-			buff.WriteString(" LIKE ")
+			buff.WriteString(this.dialect.Like(false))
 		} else {
 			buff.WriteString(comp.Operator())
 		}
-		buff.WriteString("'")
-		buff.WriteString(convertedValue)
-		buff.WriteString("'")
+		buff.WriteString(args.bind(convertedValue))
 	} else if !leftString && rightString {
-		leftValue := stripQuotes(comp.Left())
-		convertedValue, hasWildcard := convertWildcard(leftValue)
-		buff.WriteString("'")
-		buff.WriteString(convertedValue)
-		buff.WriteString("'")
+		convertedValue, hasWildcard := convertWildcard(stripQuotes(comp.Left()))
+		buff.WriteString(args.bind(convertedValue))
 		if hasWildcard && comp.Operator() == "=" {
-			buff.WriteString(" LIKE ")
+			buff.WriteString(this.dialect.Like(false))
 		} else {
 			buff.WriteString(comp.Operator())
 		}
-		buff.WriteString(comp.Right())
+		buff.WriteString(this.dialect.Quote(comp.RightProperty().Node().FieldName))
 	} else {
-		buff.WriteString(comp.Left())
+		if leftOK {
+			buff.WriteString(this.dialect.Quote(comp.LeftProperty().Node().FieldName))
+		} else {
+			buff.WriteString(args.bind(literalValue(comp.RightProperty(), comp.Left())))
+		}
 		buff.WriteString(comp.Operator())
-		buff.WriteString(comp.Right())
+		if rightOK {
+			buff.WriteString(this.dialect.Quote(comp.RightProperty().Node().FieldName))
+		} else {
+			buff.WriteString(args.bind(literalValue(comp.LeftProperty(), comp.Right())))
+		}
 	}
-	return leftOK || rightOK, buff.String()
+	return true, buff.String()
+}
+
+// childAlias names the child table inside a nested criteria subquery, so the
+// outer table stays addressable by its own name even when both share a type.
+const childAlias = "L8Child"
+
+// nestedComparator translates a comparator on the attributes of a child table
+// of typeName into an EXISTS subquery over the child rows stored under the
+// outer row at the attribute's path. Each comparator gets its own subquery, so
+// two conditions may be met by different child rows of the same element.
+// Comparators whose properties are not all in one child table report false.
+func (this *Statement) nestedComparator(comp ifs.IComparator, typeName string, args *bindArgs) (bool, string) {
+	prop, other := comp.LeftProperty(), comp.RightProperty()
+	if isNil(prop) {
+		prop, other = other, prop
+	}
+	if isNil(prop) {
+		return false, ""
+	}
+	path, ok := common.NestedPath(prop.Node(), typeName)
+	if !ok {
+		return false, ""
+	}
+	tableName := prop.Node().Parent.TypeName
+	if !isNil(other) && other.Node().Parent.TypeName != tableName {
+		return false, ""
+	}
+
+	if args.asOf == nil {
+		return true, this.childExists(comp, tableName, tableName, typeName, path, args, "")
+	}
+	// At a past time, the child rows valid then are the current rows valid
+	// since and the archived rows valid at that time.
+	validFrom := this.dialect.Quote(childAlias) + "." + this.dialect.Quote(common.ValidFromColumn)
+	validTo := this.dialect.Quote(childAlias) + "." + this.dialect.Quote(common.ValidToColumn)
+	live := this.childExists(comp, tableName, tableName, typeName, path, args,
+		"COALESCE("+validFrom+",0)<="+args.bind(*args.asOf))
+	history := this.childExists(comp, tableName, common.HistoryTable(tableName), typeName, path, args,
+		validFrom+"<="+args.bind(*args.asOf)+" AND "+validTo+">"+args.bind(*args.asOf))
+	return true, "(" + live + " OR " + history + ")"
+}
+
+// childExists returns the EXISTS subquery over the rows of the child table
+// stored under the outer row of typeName at the path that meet the comparator
+// and the extra condition, if any.
+func (this *Statement) childExists(comp ifs.IComparator, tableName, table, typeName string, path []string, args *bindArgs, extra string) string {
+	_, cond := this.comparator(comp, tableName, args)
+	buff := bytes.Buffer{}
+	buff.WriteString("EXISTS (SELECT 1 FROM ")
+	buff.WriteString(this.dialect.Quote(table))
+	buff.WriteString(" ")
+	buff.WriteString(this.dialect.Quote(childAlias))
+	buff.WriteString(" WHERE ")
+	buff.WriteString(cond)
+	buff.WriteString(" AND ")
+	buff.WriteString(this.childRowConditions(typeName, path, args))
+	if extra != "" {
+		buff.WriteString(" AND ")
+		buff.WriteString(extra)
+	}
+	buff.WriteString(")")
+	return buff.String()
+}
+
+// childRowConditions returns the conditions that select, inside a subquery on
+// a child table, the rows stored under the outer row of typeName at the given
+// field path, as returned by common.NestedPath.
+func (this *Statement) childRowConditions(typeName string, path []string, args *bindArgs) string {
+	parentKey := this.dialect.Quote("ParentKey")
+	outerKey := this.dialect.Quote(typeName) + "." + this.dialect.Quote("RecKey")
+	buff := bytes.Buffer{}
+	if len(path) == 1 {
+		// Rows of a direct child table are keyed by the outer RecKey itself.
+		buff.WriteString(parentKey)
+		buff.WriteString("=")
+		buff.WriteString(outerKey)
+	} else {
+		// The outer RecKey is escaped in SQL, so its characters are not wildcards.
+		levels := bytes.Buffer{}
+		for _, field := range path[:len(path)-1] {
+			levels.WriteString(this.dialect.EscapeKey(field + "["))
+			levels.WriteString(this.dialect.KeyWildcard())
+			levels.WriteString(this.dialect.EscapeKey("]"))
+		}
+		pattern := this.dialect.Concat(this.dialect.EscapeKeyExpr(outerKey), args.bind(levels.String()))
+		buff.WriteString(this.dialect.KeyMatch(parentKey, pattern))
+	}
+	buff.WriteString(" AND ")
+	buff.WriteString(args.bindPrefix("RecKey", path[len(path)-1]+"["))
+	return buff.String()
+}
+
+// literalValue converts a literal to the Go type of the property it is compared
+// with, so that it binds as a number or bool where the column is one. Literals
+// that do not parse, such as enum names, bind as strings.
+func literalValue(prop ifs.IProperty, literal string) interface{} {
+	literal = stripQuotes(literal)
+	if isNil(prop) {
+		return literal
+	}
+	switch prop.Node().TypeName {
+	case "float64", "float32":
+		if f, err := strconv.ParseFloat(literal, 64); err == nil {
+			return f
+		}
+	case "bool":
+		if b, err := strconv.ParseBool(literal); err == nil {
+			return b
+		}
+	default:
+		if n, err := strconv.ParseInt(literal, 10, 64); err == nil {
+			return n
+		}
+	}
+	return literal
 }
 
 // isNil checks if an interface value is nil, including nil interface values.
@@ -347,81 +539,84 @@ func convertWildcard(value string) (string, bool) {
 	return value, false
 }
 
-// Query2RecKeysSql generates SQL to fetch only RecKeys without LIMIT/OFFSET.
-// Used by the primary index cache to fetch all matching RecKeys for pagination.
-// The full result set is cached, and individual pages are served from cache.
-func (this *Statement) Query2RecKeysSql(query ifs.IQuery, typeName string) string {
+// Query2RecKeysSql generates SQL to fetch only RecKeys without LIMIT/OFFSET,
+// together with its bind arguments. Used by the primary index cache to fetch
+// all matching RecKeys for pagination. The full result set is cached, and
+// individual pages are served from cache.
+func (this *Statement) Query2RecKeysSql(query ifs.IQuery, typeName string) (string, []interface{}) {
+	args := this.newBindArgs()
 	buff := bytes.Buffer{}
-	buff.WriteString("SELECT RecKey FROM ")
-	buff.WriteString(typeName)
-
-	if query.Criteria() != nil && typeName == query.RootType().TypeName {
-		ok, str := expression(query.Criteria(), query.RootType().TypeName)
-		if ok {
-			buff.WriteString(" WHERE ")
-			buff.WriteString(str)
-		}
-	}
+	buff.WriteString("SELECT ")
+	buff.WriteString(this.dialect.Quote("RecKey"))
+	buff.WriteString(" FROM ")
+	buff.WriteString(this.dialect.Quote(typeName))
+	buff.WriteString(this.rootWhere(query, typeName, args))
 
 	// Add ORDER BY (always include, no LIMIT/OFFSET)
-	if query.SortBy() != "" {
-		buff.WriteString(" ORDER BY ")
-		buff.WriteString(query.SortBy())
-		if query.Descending() {
-			buff.WriteString(" DESC")
-		} else {
-			buff.WriteString(" ASC")
-		}
-	}
+	buff.WriteString(this.orderBy(query, args))
 
-	return buff.String()
+	return buff.String(), args.values
 }
 
-// Query2SqlByRecKeys generates SQL to fetch rows by specific RecKeys.
-// Used by the primary index to fetch full data for a page of cached RecKeys.
-func (this *Statement) Query2SqlByRecKeys(typeName string, recKeys []string) string {
+// Query2SortedRecKeysSql is Query2RecKeysSql also selecting the root column the
+// keys are sorted by, so a cached key list can keep new keys in order.
+func (this *Statement) Query2SortedRecKeysSql(query ifs.IQuery, typeName, sortField string) (string, []interface{}) {
+	args := this.newBindArgs()
 	buff := bytes.Buffer{}
 	buff.WriteString("SELECT ")
+	buff.WriteString(this.columnList([]string{"RecKey", sortField}))
+	buff.WriteString(" FROM ")
+	buff.WriteString(this.dialect.Quote(typeName))
+	buff.WriteString(this.rootWhere(query, typeName, args))
+	buff.WriteString(this.orderBy(query, args))
+	return buff.String(), args.values
+}
 
+// Query2SqlByParentKeys generates SQL to fetch the child rows stored under the
+// given root keys, with a ParentKey prefix match per key, together with its
+// bind arguments. Used to fetch the child rows of a page of root rows.
+func (this *Statement) Query2SqlByParentKeys(typeName string, rootKeys []string) (string, []interface{}) {
+	args := this.newBindArgs()
 	if this.fields == nil {
 		this.fields, this.values = fieldsOf(this.node)
 	}
-	first := true
-	for _, fieldName := range this.fields {
-		if !first {
-			buff.WriteString(",")
-		}
-		first = false
-		buff.WriteString(fieldName)
+	buff := bytes.Buffer{}
+	buff.WriteString("SELECT ")
+	buff.WriteString(this.columnList(this.fields))
+	buff.WriteString(" FROM ")
+	buff.WriteString(this.dialect.Quote(typeName))
+	buff.WriteString(where(this.elementConditions(rootKeys, false, args)))
+	return buff.String(), args.values
+}
+
+// Query2SqlByRecKeys generates SQL to fetch rows by specific RecKeys, which are
+// bound as parameters. Used by the primary index to fetch full data for a page
+// of cached RecKeys.
+func (this *Statement) Query2SqlByRecKeys(typeName string, recKeys []string) (string, []interface{}) {
+	args := this.newBindArgs()
+	buff := bytes.Buffer{}
+	buff.WriteString("SELECT ")
+
+	if this.fields == nil {
+		this.fields, this.values = fieldsOf(this.node)
 	}
+	buff.WriteString(this.columnList(this.fields))
 
 	buff.WriteString(" FROM ")
-	buff.WriteString(typeName)
-	buff.WriteString(" WHERE RecKey IN (")
+	buff.WriteString(this.dialect.Quote(typeName))
+	buff.WriteString(" WHERE ")
+	buff.WriteString(this.dialect.Quote("RecKey"))
+	buff.WriteString(" IN (")
 
-	first = true
+	first := true
 	for _, key := range recKeys {
 		if !first {
 			buff.WriteString(",")
 		}
 		first = false
-		buff.WriteString("'")
-		buff.WriteString(escapeSQL(key))
-		buff.WriteString("'")
+		buff.WriteString(args.bind(key))
 	}
 	buff.WriteString(")")
 
-	return buff.String()
-}
-
-// escapeSQL escapes single quotes in SQL string values by doubling them.
-func escapeSQL(s string) string {
-	result := bytes.Buffer{}
-	for _, c := range s {
-		if c == '\'' {
-			result.WriteRune('\'')
-		}
-		result.WriteRune(c)
-	}
-	return result.String()
+	return buff.String(), args.values
 }
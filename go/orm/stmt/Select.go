@@ -22,16 +22,17 @@ import (
 	"reflect"
 )
 
-// SelectStatement returns a prepared SELECT statement for this table.
-// The statement is created lazily and cached for subsequent calls.
-func (this *Statement) SelectStatement(tx *sql.Tx) (*sql.Stmt, error) {
+// SelectStatement returns a prepared SELECT statement for this table, with the
+// bind arguments to query it with. The statement is created lazily and cached
+// for subsequent calls.
+func (this *Statement) SelectStatement(tx *sql.Tx) (*sql.Stmt, []interface{}, error) {
 	if this.selectStmt == nil {
 		err := this.createSelectStatement(tx)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
-	return this.selectStmt, nil
+	return this.selectStmt, this.selectArgs, nil
 }
 
 // createSelectStatement generates and prepares the SELECT SQL statement.
@@ -40,28 +41,22 @@ func (this *Statement) SelectStatement(tx *sql.Tx) (*sql.Stmt, error) {
 func (this *Statement) createSelectStatement(tx *sql.Tx) error {
 	var sel *strings.String
 	if this.query != nil {
-		s, ok := this.Query2Sql(this.query, this.node.TypeName)
+		s, args, ok := this.Query2Sql(this.query, this.node.TypeName)
 		if !ok {
 			return nil
 		}
 		sel = strings.New(s)
+		this.selectArgs = args
 	} else {
 		sel = strings.New("Select ")
 		if this.fields == nil {
 			this.fields, this.values = fieldsOf(this.node)
 		}
-		first := true
-		for _, fieldName := range this.fields {
-			if !first {
-				sel.Add(",")
-			}
-			first = false
-			sel.Add(fieldName)
-		}
-		sel.Add(" from ").Add(this.node.TypeName)
+		sel.Add(this.columnList(this.fields))
+		sel.Add(" from ").Add(this.dialect.Quote(this.node.TypeName))
 		sel.Add(";")
 	}
-	st, err := tx.Prepare(sel.String())
+	st, err := tx.PrepareContext(this.context(), sel.String())
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,93 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package stmt
+
+import (
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8utils/go/utils/strings"
+)
+
+// Query2RestoreSql generates an UPDATE clearing the stamp of the tombstoned
+// root rows that match the query criteria, together with its bind arguments.
+func (this *Statement) Query2RestoreSql(query ifs.IQuery) (string, []interface{}) {
+	args := this.newBindArgs()
+	column := this.dialect.Quote(common.DeletedAtColumn)
+	upd := strings.New("UPDATE ", this.dialect.Quote(this.node.TypeName), " SET ", column, "=NULL")
+	conditions := this.criteria(query, this.node.TypeName, args)
+	upd.Add(where(append(conditions, column+" IS NOT NULL")))
+	return upd.String(), args.values
+}
+
+// RestoreByKeysSql generates an UPDATE clearing the stamp of the root rows
+// with the given RecKeys, so that writing an element again brings it back.
+func (this *Statement) RestoreByKeysSql(recKeys []string) (string, []interface{}) {
+	args := this.newBindArgs()
+	column := this.dialect.Quote(common.DeletedAtColumn)
+	upd := strings.New("UPDATE ", this.dialect.Quote(this.node.TypeName), " SET ", column, "=NULL WHERE ")
+	upd.Add(this.dialect.Quote("ParentKey"), "=", args.bind(""), " AND ", column, " IS NOT NULL AND ")
+	upd.Add(this.recKeysIn(recKeys, args))
+	return upd.String(), args.values
+}
+
+// SoftDeleteByKeysSql generates an UPDATE stamping the live root rows with the
+// given RecKeys with the deletion time, together with its bind arguments.
+func (this *Statement) SoftDeleteByKeysSql(recKeys []string, deletedAt int64) (string, []interface{}) {
+	args := this.newBindArgs()
+	column := this.dialect.Quote(common.DeletedAtColumn)
+	upd := strings.New("UPDATE ", this.dialect.Quote(this.node.TypeName), " SET ", column, "=", args.bind(deletedAt), " WHERE ")
+	upd.Add(this.dialect.Quote("ParentKey"), "=", args.bind(""), " AND ", column, " IS NULL AND ")
+	upd.Add(this.recKeysIn(recKeys, args))
+	return upd.String(), args.values
+}
+
+// recKeysIn returns the condition selecting the rows with the given RecKeys.
+func (this *Statement) recKeysIn(recKeys []string, args *bindArgs) string {
+	in := strings.New(this.dialect.Quote("RecKey"), " IN (")
+	for i, key := range recKeys {
+		if i > 0 {
+			in.Add(",")
+		}
+		in.Add(args.bind(key))
+	}
+	in.Add(")")
+	return in.String()
+}
+
+// TombstoneKeysSql generates a SELECT of the RecKeys of the root rows deleted
+// at or before the given Unix time, together with its bind arguments.
+func (this *Statement) TombstoneKeysSql(before int64) (string, []interface{}) {
+	args := this.newBindArgs()
+	sel := strings.New("SELECT ", this.dialect.Quote("RecKey"), " FROM ", this.dialect.Quote(this.node.TypeName))
+	sel.Add(this.tombstonesBefore(before, args))
+	return sel.String(), args.values
+}
+
+// PurgeSql generates a DELETE of the root rows deleted at or before the given Unix
+// time, together with its bind arguments.
+func (this *Statement) PurgeSql(before int64) (string, []interface{}) {
+	args := this.newBindArgs()
+	del := strings.New("DELETE FROM ", this.dialect.Quote(this.node.TypeName))
+	del.Add(this.tombstonesBefore(before, args))
+	return del.String(), args.values
+}
+
+// tombstonesBefore returns the WHERE clause selecting the root rows deleted
+// at or before the given Unix time.
+func (this *Statement) tombstonesBefore(before int64, args *bindArgs) string {
+	column := this.dialect.Quote(common.DeletedAtColumn)
+	return strings.New(" WHERE ", this.dialect.Quote("ParentKey"), "=", args.bind(""),
+		" AND ", column, " IS NOT NULL AND ", column, "<=", args.bind(before)).String()
+}
@@ -13,12 +13,14 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// Package stmt provides SQL statement builders for PostgreSQL database operations.
+// Package stmt provides SQL statement builders for the relational ORM plugins.
 // It generates SELECT, INSERT, UPDATE, and DELETE statements from query objects
 // and L8 reflection metadata, handling column mapping and value serialization.
+// Backend specific syntax is supplied by an IDialect.
 package stmt
 
 import (
+	"context"
 	"database/sql"
 	"github.com/saichler/l8orm/go/types/l8orms"
 	"reflect"
@@ -38,6 +40,12 @@ type Statement struct {
 	registy ifs.IRegistry       // Type registry for deserialization
 	node    *l8reflect.L8Node   // Type metadata for the table
 	query   ifs.IQuery          // Query for filtering and projection
+	dialect IDialect            // Backend specific SQL syntax
+	ctx     context.Context     // Context bounding the statement's database calls
+
+	updateArgs []int            // Field positions in UPDATE placeholder order
+	selectArgs []interface{}    // Bind arguments of the SELECT statement
+	countArgs  []interface{}    // Bind arguments of the COUNT statement
 
 	insertStmt   *sql.Stmt      // Cached prepared INSERT statement
 	selectStmt   *sql.Stmt      // Cached prepared SELECT statement
@@ -45,13 +53,32 @@ type Statement struct {
 	metaDataStmt *sql.Stmt      // Cached prepared COUNT statement
 }
 
-// NewStatement creates a new Statement for the given type node and column schema.
-func NewStatement(node *l8reflect.L8Node, columns map[string]int32, query ifs.IQuery, registy ifs.IRegistry) *Statement {
-	return &Statement{node: node, columns: columns, registy: registy, query: query}
+// NewStatement creates a new Statement for the given type node and column schema,
+// generating SQL in the syntax of the given dialect.
+func NewStatement(node *l8reflect.L8Node, columns map[string]int32, query ifs.IQuery, registy ifs.IRegistry, dialect IDialect) *Statement {
+	return &Statement{node: node, columns: columns, registy: registy, query: query, dialect: dialect}
+}
+
+// WithContext sets the context used to prepare and run the statement's SQL,
+// so a cancelled or expired context aborts the database call.
+func (this *Statement) WithContext(ctx context.Context) *Statement {
+	this.ctx = ctx
+	return this
+}
+
+// context returns the statement's context, or the background context if none was set.
+func (this *Statement) context() context.Context {
+	if this.ctx == nil {
+		return context.Background()
+	}
+	return this.ctx
 }
 
 // RowValues extracts the parameter values from a row for SQL statement execution.
-// For PATCH actions, zero values are converted to nil to trigger COALESCE behavior.
+// For PATCH actions, columns missing from the row are passed as nil so COALESCE
+// keeps the stored value, while columns present in the row are written as is,
+// zero values included. Conversion leaves out zero values unless a field mask
+// lists them. The values are ordered to match the UPDATE statement's placeholders.
 func (this *Statement) RowValues(action ifs.Action, row *l8orms.L8OrmRow) ([]interface{}, error) {
 	result := make([]interface{}, len(this.values))
 	result[0] = row.ParentKey
@@ -71,25 +98,18 @@ func (this *Statement) RowValues(action ifs.Action, row *l8orms.L8OrmRow) ([]int
 		if err != nil {
 			return nil, err
 		}
-		if action == ifs.PATCH && isZeroValue(val) {
-			result[fieldPos-1] = nil
-		} else {
-			result[fieldPos-1] = val
+		result[fieldPos-1] = val
+	}
+	if action == ifs.PATCH && this.updateArgs != nil {
+		ordered := make([]interface{}, len(this.updateArgs))
+		for i, pos := range this.updateArgs {
+			ordered[i] = result[pos-1]
 		}
+		return ordered, nil
 	}
 	return result, nil
 }
 
-// isZeroValue checks if a value is the zero value for its type.
-// Used by PATCH operations to determine which fields to skip.
-func isZeroValue(val interface{}) bool {
-	if val == nil {
-		return true
-	}
-	v := reflect.ValueOf(val)
-	return v.IsZero()
-}
-
 // fieldsOf extracts the ordered field list and position map from a node.
 // ParentKey and RecKey are always first (positions 1 and 2), followed by attributes.
 func fieldsOf(node *l8reflect.L8Node) ([]string, map[string]int) {
@@ -107,6 +127,18 @@ func fieldsOf(node *l8reflect.L8Node) ([]string, map[string]int) {
 	return fields, values
 }
 
+// columnList returns the quoted, comma separated list of the given fields.
+func (this *Statement) columnList(fields []string) string {
+	list := strings.New()
+	for i, field := range fields {
+		if i > 0 {
+			list.Add(",")
+		}
+		list.Add(this.dialect.Quote(field))
+	}
+	return list.String()
+}
+
 // getValueForPostgres deserializes a byte array and converts it to a PostgreSQL-compatible value.
 // Slices and maps are serialized to a string format with type prefixes.
 func getValueForPostgres(data []byte, r ifs.IRegistry) (interface{}, error) {
@@ -0,0 +1,65 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package stmt
+
+import (
+	"database/sql"
+	strings2 "strings"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8types/go/types/l8api"
+)
+
+// TsdbPropertyFilter returns the condition selecting the l8tsdb rows of the
+// given property IDs and patterns, with its bind arguments. Patterns are
+// matched with the dialect's case-sensitive key match, the rest of the
+// pattern escaped so only the wildcards match any characters.
+func TsdbPropertyFilter(dialect IDialect, propertyIds []string) (string, []interface{}) {
+	args := &bindArgs{dialect: dialect, values: make([]interface{}, 0, len(propertyIds))}
+	var exact, patterns []string
+	for _, propertyId := range propertyIds {
+		if !strings2.Contains(propertyId, common.TsdbWildcard) {
+			exact = append(exact, args.bind(propertyId))
+			continue
+		}
+		parts := strings2.Split(propertyId, common.TsdbWildcard)
+		for i, part := range parts {
+			parts[i] = dialect.EscapeKey(part)
+		}
+		pattern := args.bind(strings2.Join(parts, dialect.KeyWildcard()))
+		patterns = append(patterns, dialect.KeyMatch("prop_id", pattern))
+	}
+	conditions := patterns
+	if len(exact) > 0 {
+		conditions = append([]string{"prop_id IN (" + strings2.Join(exact, ", ") + ")"}, patterns...)
+	}
+	return "(" + strings2.Join(conditions, " OR ") + ")", args.values
+}
+
+// ScanPropertyPoints reads (prop_id, stamp, value) rows into time series
+// points grouped by property ID, and closes the rows.
+func ScanPropertyPoints(rows *sql.Rows) (map[string][]*l8api.L8TimeSeriesPoint, error) {
+	defer rows.Close()
+	points := make(map[string][]*l8api.L8TimeSeriesPoint)
+	for rows.Next() {
+		var propertyId string
+		p := &l8api.L8TimeSeriesPoint{}
+		if err := rows.Scan(&propertyId, &p.Stamp, &p.Value); err != nil {
+			return nil, err
+		}
+		points[propertyId] = append(points[propertyId], p)
+	}
+	return points, rows.Err()
+}
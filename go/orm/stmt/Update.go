@@ -17,7 +17,6 @@ package stmt
 import (
 	"database/sql"
 	"github.com/saichler/l8utils/go/utils/strings"
-	"strconv"
 )
 
 // UpdateStatement returns a prepared UPDATE statement for PATCH operations.
@@ -34,13 +33,15 @@ func (this *Statement) UpdateStatement(tx *sql.Tx) (*sql.Stmt, error) {
 
 // createUpdateStatement generates and prepares an UPDATE SQL statement with COALESCE.
 // COALESCE ensures that NULL parameter values preserve existing column values,
-// enabling partial updates where only non-null fields are modified.
+// enabling partial updates where only non-null fields are modified. Placeholders
+// are numbered in order of appearance and RowValues orders the values to match.
 func (this *Statement) createUpdateStatement(tx *sql.Tx) error {
 	if this.fields == nil {
 		this.fields, this.values = fieldsOf(this.node)
 	}
 
-	update := strings.New("UPDATE ", this.node.TypeName, " SET ")
+	update := strings.New("UPDATE ", this.dialect.Quote(this.node.TypeName), " SET ")
+	this.updateArgs = make([]int, 0, len(this.fields))
 	first := true
 
 	for _, field := range this.fields {
@@ -51,12 +52,16 @@ func (this *Statement) createUpdateStatement(tx *sql.Tx) error {
 			update.Add(", ")
 		}
 		first = false
-		update.Add(field, "=COALESCE($", strconv.Itoa(this.values[field]), ", ", field, ")")
+		this.updateArgs = append(this.updateArgs, this.values[field])
+		update.Add(this.dialect.Quote(field), "=COALESCE(", this.dialect.Placeholder(len(this.updateArgs)), ", ", this.dialect.Quote(field), ")")
 	}
 
-	update.Add(" WHERE ParentKey=$1 AND RecKey=$2;")
+	this.updateArgs = append(this.updateArgs, this.values["ParentKey"])
+	update.Add(" WHERE ", this.dialect.Quote("ParentKey"), "=", this.dialect.Placeholder(len(this.updateArgs)))
+	this.updateArgs = append(this.updateArgs, this.values["RecKey"])
+	update.Add(" AND ", this.dialect.Quote("RecKey"), "=", this.dialect.Placeholder(len(this.updateArgs)), ";")
 
-	st, err := tx.Prepare(update.String())
+	st, err := tx.PrepareContext(this.context(), update.String())
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,125 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package stmt
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8utils/go/utils/strings"
+)
+
+// IsVersioned reports whether the table has the int64 version attribute.
+func (this *Statement) IsVersioned(field string) bool {
+	if field == "" {
+		return false
+	}
+	attr, ok := this.node.Attributes[field]
+	return ok && !attr.IsStruct && attr.TypeName == "int64"
+}
+
+// CheckVersion applies optimistic concurrency to a root row inside the write
+// transaction. A non-zero version carried by the row must match the stored
+// version. The stored version is then incremented with a conditional UPDATE,
+// so a concurrent writer that got there first causes a conflict too, and the
+// row is changed to carry the new version for the following upsert or update.
+// Rows that do not exist yet start at version 1.
+func (this *Statement) CheckVersion(tx *sql.Tx, row *l8orms.L8OrmRow, field string) error {
+	col, ok := this.columns[field]
+	if !ok {
+		return errors.New("Version field " + field + " is not a column of " + this.node.TypeName)
+	}
+	version, err := this.versionOf(row, col)
+	if err != nil {
+		return err
+	}
+
+	sel := strings.New("SELECT ", this.dialect.Quote(field), " FROM ", this.dialect.Quote(this.node.TypeName),
+		" WHERE ", this.dialect.Quote("ParentKey"), "=", this.dialect.Placeholder(1),
+		" AND ", this.dialect.Quote("RecKey"), "=", this.dialect.Placeholder(2))
+	var stored sql.NullInt64
+	err = tx.QueryRowContext(this.context(), sel.String(), row.ParentKey, row.RecKey).Scan(&stored)
+	if err == sql.ErrNoRows {
+		return setVersion(row, col, 1)
+	}
+	if err != nil {
+		return err
+	}
+	if version != 0 && version != stored.Int64 {
+		return this.conflict(row, version, stored.Int64)
+	}
+
+	next := stored.Int64 + 1
+	upd := strings.New("UPDATE ", this.dialect.Quote(this.node.TypeName),
+		" SET ", this.dialect.Quote(field), "=", this.dialect.Placeholder(1),
+		" WHERE ", this.dialect.Quote("ParentKey"), "=", this.dialect.Placeholder(2),
+		" AND ", this.dialect.Quote("RecKey"), "=", this.dialect.Placeholder(3),
+		" AND COALESCE(", this.dialect.Quote(field), ",0)=", this.dialect.Placeholder(4))
+	result, err := tx.ExecContext(this.context(), upd.String(), next, row.ParentKey, row.RecKey, stored.Int64)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return this.conflict(row, version, stored.Int64)
+	}
+	return setVersion(row, col, next)
+}
+
+// versionOf decodes the version carried by the row, 0 when it carries none.
+func (this *Statement) versionOf(row *l8orms.L8OrmRow, col int32) (int64, error) {
+	data, ok := row.ColumnValues[col]
+	if !ok || len(data) == 0 {
+		return 0, nil
+	}
+	obj := object.NewDecode(data, 0, this.registy)
+	val, err := obj.Get()
+	if err != nil {
+		return 0, err
+	}
+	v := reflect.ValueOf(val)
+	if v.Kind() != reflect.Int64 {
+		return 0, nil
+	}
+	return v.Int(), nil
+}
+
+// conflict builds the VersionConflictError for the row.
+func (this *Statement) conflict(row *l8orms.L8OrmRow, version, stored int64) error {
+	return &common.VersionConflictError{
+		TypeName: this.node.TypeName,
+		Key:      row.ParentKey + row.RecKey,
+		Version:  version,
+		Stored:   stored,
+	}
+}
+
+// setVersion stores the version in the row's column values.
+func setVersion(row *l8orms.L8OrmRow, col int32, version int64) error {
+	obj := object.NewEncode()
+	err := obj.Add(version)
+	if err != nil {
+		return err
+	}
+	row.ColumnValues[col] = obj.Data()
+	return nil
+}
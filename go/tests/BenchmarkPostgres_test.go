@@ -0,0 +1,114 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tests
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/plugins/postgres"
+	"github.com/saichler/l8reflect/go/tests/utils"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// benchClients are the numbers of concurrent clients each benchmark runs with.
+var benchClients = []int{1, 2, 4, 8, 16}
+
+// BenchmarkPostgresConcurrentReads reads single elements by key from a growing
+// number of concurrent clients. The ops/s metric should grow with the clients
+// until the connection pool or the database is saturated.
+func BenchmarkPostgresConcurrentReads(b *testing.B) {
+	p, recs, res, done := benchPostgres(b)
+	defer done()
+
+	queries := make([]ifs.IQuery, len(recs))
+	for i, rec := range recs {
+		q, _ := object.NewQuery("select * from testproto where mystring="+rec.MyString, res)
+		queries[i], _ = q.Query(res)
+	}
+	for _, clients := range benchClients {
+		b.Run("clients-"+strconv.Itoa(clients), func(b *testing.B) {
+			runClients(b, clients, func(i int) bool {
+				elems := p.Read(queries[i%len(queries)], res)
+				return elems.Error() == nil && len(elems.Elements()) == 1
+			})
+		})
+	}
+}
+
+// BenchmarkPostgresConcurrentWrites replaces elements from a growing number of
+// concurrent clients, each client writing its own elements.
+func BenchmarkPostgresConcurrentWrites(b *testing.B) {
+	p, recs, res, done := benchPostgres(b)
+	defer done()
+
+	for _, clients := range benchClients {
+		b.Run("clients-"+strconv.Itoa(clients), func(b *testing.B) {
+			runClients(b, clients, func(i int) bool {
+				return p.Write(ifs.PUT, object.New(nil, recs[i%len(recs)]), res) == nil
+			})
+		})
+	}
+}
+
+// benchPostgres opens a clean database and writes the elements the benchmarks
+// work on. The returned function cleans the database up.
+func benchPostgres(b *testing.B) (*postgres.Postgres, []*testtypes.TestProto, ifs.IResources, func()) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	recs := make([]*testtypes.TestProto, 64)
+	for i := 0; i < len(recs); i++ {
+		recs[i] = utils.CreateTestModelInstance(i)
+	}
+	err := p.Write(ifs.POST, object.New(nil, recs), res)
+	if err != nil {
+		b.Fatal("Error writing records", err)
+	}
+	return p, recs, res, func() { cleanup(db) }
+}
+
+// runClients splits b.N operations between the given number of concurrent
+// clients and reports the throughput as ops/s. Client c runs the operations
+// c, c+clients, c+2*clients and so on, so clients work on different elements.
+func runClients(b *testing.B, clients int, op func(i int) bool) {
+	var wg sync.WaitGroup
+	var failed sync.Once
+	b.ResetTimer()
+	start := time.Now()
+	for c := 0; c < clients; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			for i := c; i < b.N; i += clients {
+				if !op(i) {
+					failed.Do(func() { b.Error("operation failed") })
+					return
+				}
+			}
+		}(c)
+	}
+	wg.Wait()
+	b.StopTimer()
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "ops/s")
+}
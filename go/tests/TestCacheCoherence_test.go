@@ -0,0 +1,318 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/persist"
+	"github.com/saichler/l8orm/go/orm/plugins/memory"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8reflect/go/tests/utils"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// failingOrm wraps an IORM and rejects its writes while fail is set, and its
+// reads while failReads is set.
+type failingOrm struct {
+	common.IORM
+	fail      bool
+	failReads bool
+}
+
+func (this *failingOrm) ReadContext(ctx context.Context, q ifs.IQuery, res ifs.IResources) ifs.IElements {
+	if this.failReads {
+		return object.NewError("read rejected")
+	}
+	return this.IORM.ReadContext(ctx, q, res)
+}
+
+func (this *failingOrm) Write(action ifs.Action, elems ifs.IElements, res ifs.IResources) error {
+	return this.WriteContext(context.Background(), action, elems, res)
+}
+
+func (this *failingOrm) WriteContext(ctx context.Context, action ifs.Action, elems ifs.IElements, res ifs.IResources) error {
+	if this.fail {
+		return errors.New("write rejected")
+	}
+	return this.IORM.WriteContext(ctx, action, elems, res)
+}
+
+// getByKey looks up a TestProto by primary key through the service handler,
+// returning nil when it is not found.
+func getByKey(h ifs.IServiceHandler, key string, nic ifs.IVNic) *testtypes.TestProto {
+	resp := h.Get(object.New(nil, &testtypes.TestProto{MyString: key}), nic)
+	if resp == nil || resp.Error() != nil {
+		return nil
+	}
+	for _, elem := range resp.Elements() {
+		if found, ok := elem.(*testtypes.TestProto); ok && found != nil && found.MyString == key {
+			return found
+		}
+	}
+	return nil
+}
+
+// TestCacheCoherence verifies that the service cache only takes the elements
+// the database accepted, and that missing keys are briefly cached.
+func TestCacheCoherence(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	mem := memory.NewMemory(nic.Resources())
+	orm := &failingOrm{IORM: mem}
+
+	serviceName := "ormcoh"
+	persist.Activate(serviceName, 0, &testtypes.TestProto{}, &testtypes.TestProtoList{}, nic,
+		orm, nil, true, "MyString")
+	h, ok := nic.Resources().Services().ServiceHandler(serviceName, 0)
+	if !ok {
+		Log.Fail(t, "Service not found")
+		return
+	}
+
+	stored := utils.CreateTestModelInstance(1)
+	nic.Resources().Registry().Register(stored)
+	resp := h.Post(object.New(nil, stored), nic)
+	if resp.Error() != nil {
+		Log.Fail(t, "Post ", resp.Error())
+		return
+	}
+
+	// A rejected PUT leaves the cached element as stored
+	changed := utils.CreateTestModelInstance(1)
+	changed.MyInt32 = stored.MyInt32 + 1000
+	orm.fail = true
+	resp = h.Put(object.New(nil, changed), nic)
+	if resp.Error() == nil {
+		Log.Fail(t, "Expected the PUT to fail")
+		return
+	}
+	found := getByKey(h, stored.MyString, nic)
+	if found == nil || found.MyInt32 != stored.MyInt32 {
+		Log.Fail(t, "Expected the cache to keep the stored element after a failed PUT")
+		return
+	}
+
+	// A rejected POST of a new key is not cached
+	added := utils.CreateTestModelInstance(2)
+	resp = h.Post(object.New(nil, added), nic)
+	if resp.Error() == nil {
+		Log.Fail(t, "Expected the POST to fail")
+		return
+	}
+	if getByKey(h, added.MyString, nic) != nil {
+		Log.Fail(t, "Expected a failed POST not to be cached")
+		return
+	}
+
+	// The missing key was cached by the lookup, and forgotten by the write
+	orm.fail = false
+	resp = h.Post(object.New(nil, added), nic)
+	if resp.Error() != nil {
+		Log.Fail(t, "Post ", resp.Error())
+		return
+	}
+	if getByKey(h, added.MyString, nic) == nil {
+		Log.Fail(t, "Expected the POST to forget the missing key")
+		return
+	}
+
+	// A missing key is answered without the database until it expires
+	missing := utils.CreateTestModelInstance(3)
+	if getByKey(h, missing.MyString, nic) != nil {
+		Log.Fail(t, "Expected the key to be missing")
+		return
+	}
+	err := mem.Write(ifs.POST, object.New(nil, missing), nic.Resources())
+	if err != nil {
+		Log.Fail(t, "Write ", err)
+		return
+	}
+	if getByKey(h, missing.MyString, nic) != nil {
+		Log.Fail(t, "Expected the missing key to be cached")
+		return
+	}
+	time.Sleep(persist.DefaultNegativeTTL + time.Second)
+	if getByKey(h, missing.MyString, nic) == nil {
+		Log.Fail(t, "Expected the missing key to expire")
+		return
+	}
+}
+
+// deleteByKey deletes a TestProto directly in the ORM, behind the service cache.
+func deleteByKey(orm common.IORM, key string, res ifs.IResources) error {
+	q, err := object.NewQuery("select * from testproto where mystring="+key, res)
+	if err != nil {
+		return err
+	}
+	query, err := q.Query(res)
+	if err != nil {
+		return err
+	}
+	return orm.Delete(query, res)
+}
+
+// waitFor polls the condition until it holds or the timeout passes.
+func waitFor(timeout time.Duration, condition func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
+}
+
+// TestCacheReload verifies that a whole type change published by another
+// replica reloads the cache in the background, and that a reload which could
+// not read every page keeps the cached elements.
+func TestCacheReload(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	mem := memory.NewMemory(nic.Resources())
+	orm := &failingOrm{IORM: mem}
+
+	serviceName := "ormreload"
+	persist.Activate(serviceName, 0, &testtypes.TestProto{}, &testtypes.TestProtoList{}, nic,
+		orm, nil, true, "MyString")
+	h, ok := nic.Resources().Services().ServiceHandler(serviceName, 0)
+	if !ok {
+		Log.Fail(t, "Service not found")
+		return
+	}
+	res := nic.Resources()
+
+	recs := make([]*testtypes.TestProto, 4)
+	for i := range recs {
+		recs[i] = utils.CreateTestModelInstance(i + 1)
+	}
+	res.Registry().Register(recs[0])
+	for _, rec := range recs[:3] {
+		resp := h.Post(object.New(nil, rec), nic)
+		if resp.Error() != nil {
+			Log.Fail(t, "Post ", resp.Error())
+			return
+		}
+	}
+
+	// Change the database behind the cache, as another replica would
+	if err := deleteByKey(mem, recs[0].MyString, res); err != nil {
+		Log.Fail(t, "Delete ", err)
+		return
+	}
+	if err := mem.Write(ifs.POST, object.New(nil, recs[3]), res); err != nil {
+		Log.Fail(t, "Write ", err)
+		return
+	}
+	if getByKey(h, recs[0].MyString, nic) == nil {
+		Log.Fail(t, "Expected the deleted element to still be cached")
+		return
+	}
+
+	// Relational data that is not marked as an invalidation is not a change
+	h.Get(object.New(nil, &l8orms.L8OrmRData{RootTypeName: "TestProto"}), nic)
+	time.Sleep(100 * time.Millisecond)
+	if getByKey(h, recs[0].MyString, nic) == nil {
+		Log.Fail(t, "Expected unmarked relational data not to reload the cache")
+		return
+	}
+
+	// The change is delivered as a GET, which returns before the reload
+	change := &l8orms.L8OrmRData{RootTypeName: "TestProto", Invalidation: true}
+	resp := h.Get(object.New(nil, change), nic)
+	if resp.Error() != nil {
+		Log.Fail(t, "Change ", resp.Error())
+		return
+	}
+	if !waitFor(5*time.Second, func() bool { return getByKey(h, recs[0].MyString, nic) == nil }) {
+		Log.Fail(t, "Expected the reload to remove the deleted element from the cache")
+		return
+	}
+
+	// The element written behind the cache was loaded by the reload
+	if err := deleteByKey(mem, recs[3].MyString, res); err != nil {
+		Log.Fail(t, "Delete ", err)
+		return
+	}
+	if getByKey(h, recs[3].MyString, nic) == nil {
+		Log.Fail(t, "Expected the reload to cache the element written behind the cache")
+		return
+	}
+
+	// A reload that fails to read keeps the elements it did not reach
+	if err := deleteByKey(mem, recs[1].MyString, res); err != nil {
+		Log.Fail(t, "Delete ", err)
+		return
+	}
+	orm.failReads = true
+	resp = h.Get(object.New(nil, change), nic)
+	if resp.Error() != nil {
+		Log.Fail(t, "Change ", resp.Error())
+		return
+	}
+	time.Sleep(time.Second)
+	if getByKey(h, recs[1].MyString, nic) == nil || getByKey(h, recs[2].MyString, nic) == nil {
+		Log.Fail(t, "Expected a failed reload to keep the cached elements")
+		return
+	}
+	orm.failReads = false
+}
+
+// TestCacheReloadDeActivate verifies that deactivating a service waits for a
+// running cache reload to stop before closing the ORM.
+func TestCacheReloadDeActivate(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	res := nic.Resources()
+	orm := &closingOrm{IORM: memory.NewMemory(res)}
+	if _, err := warmUpElements(orm, 10, res); err != nil {
+		Log.Fail(t, "Write ", err)
+		return
+	}
+
+	serviceName := "ormreloadd"
+	persist.Activate(serviceName, 0, &testtypes.TestProto{}, &testtypes.TestProtoList{}, nic,
+		orm, nil, true, "MyString")
+	h, ok := res.Services().ServiceHandler(serviceName, 0)
+	if !ok {
+		Log.Fail(t, "Service not found")
+		return
+	}
+	if counts, done := waitForWarmUp(h, nic); !done {
+		Log.Fail(t, "Expected the warm-up to be done, counts ", counts)
+		return
+	}
+
+	resp := h.Get(object.New(nil, &l8orms.L8OrmRData{RootTypeName: "TestProto", Invalidation: true}), nic)
+	if resp.Error() != nil {
+		Log.Fail(t, "Change ", resp.Error())
+		return
+	}
+	if err := h.DeActivate(); err != nil {
+		Log.Fail(t, "DeActivate ", err)
+		return
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&orm.lateReads); n != 0 {
+		Log.Fail(t, "Expected no reload read after the ORM was closed, got:", n)
+		return
+	}
+}
@@ -0,0 +1,352 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tests
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/persist"
+	"github.com/saichler/l8orm/go/orm/plugins/memory"
+	"github.com/saichler/l8reflect/go/tests/utils"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// closingOrm wraps an IORM with slow reads, and counts the reads that reach
+// it once it is closed.
+type closingOrm struct {
+	common.IORM
+	closed    int32
+	lateReads int32
+}
+
+func (this *closingOrm) ReadContext(ctx context.Context, q ifs.IQuery, res ifs.IResources) ifs.IElements {
+	time.Sleep(20 * time.Millisecond)
+	if atomic.LoadInt32(&this.closed) == 1 {
+		atomic.AddInt32(&this.lateReads, 1)
+	}
+	return this.IORM.ReadContext(ctx, q, res)
+}
+
+func (this *closingOrm) Close() error {
+	atomic.StoreInt32(&this.closed, 1)
+	return this.IORM.Close()
+}
+
+// gatedOrm wraps an IORM and holds the result of its first read until gate
+// is closed, telling reading once the read was done.
+type gatedOrm struct {
+	common.IORM
+	once    sync.Once
+	reading chan struct{}
+	gate    chan struct{}
+}
+
+func (this *gatedOrm) ReadContext(ctx context.Context, q ifs.IQuery, res ifs.IResources) ifs.IElements {
+	result := this.IORM.ReadContext(ctx, q, res)
+	this.once.Do(func() {
+		close(this.reading)
+		<-this.gate
+	})
+	return result
+}
+
+// warmUpElements writes count TestProto elements directly in the ORM, with
+// MyInt32 set to their index.
+func warmUpElements(orm common.IORM, count int, res ifs.IResources) ([]*testtypes.TestProto, error) {
+	res.Introspector().Decorators().AddPrimaryKeyDecorator(&testtypes.TestProto{}, "MyString")
+	recs := make([]*testtypes.TestProto, count)
+	for i := range recs {
+		recs[i] = utils.CreateTestModelInstance(i)
+		recs[i].MyInt32 = int32(i)
+	}
+	res.Registry().Register(recs[0])
+	return recs, orm.Write(ifs.POST, object.New(nil, recs), res)
+}
+
+// queryService runs a GSQL query through the service handler and returns the
+// elements found together with the metadata counts of the result.
+func queryService(h ifs.IServiceHandler, gsql string, nic ifs.IVNic) ([]*testtypes.TestProto, map[string]float64) {
+	q, err := object.NewQuery(gsql, nic.Resources())
+	if err != nil {
+		return nil, nil
+	}
+	resp := h.Get(q, nic)
+	if resp == nil || resp.Error() != nil {
+		return nil, nil
+	}
+	found := make([]*testtypes.TestProto, 0)
+	for _, elem := range resp.Elements() {
+		if tp, ok := elem.(*testtypes.TestProto); ok && tp != nil {
+			found = append(found, tp)
+		}
+	}
+	var counts map[string]float64
+	if resp.Metadata() != nil && resp.Metadata().KeyCount != nil {
+		counts = resp.Metadata().KeyCount.Counts
+	}
+	return found, counts
+}
+
+// waitForWarmUp waits until the service reports its warm-up done, and returns
+// the metadata counts of the last query.
+func waitForWarmUp(h ifs.IServiceHandler, nic ifs.IVNic) (map[string]float64, bool) {
+	var counts map[string]float64
+	done := waitFor(10*time.Second, func() bool {
+		_, counts = queryService(h, "select * from testproto", nic)
+		return counts != nil && counts["WarmUpDone"] == 1
+	})
+	return counts, done
+}
+
+// TestCacheWarmUp verifies that the warm-up loads every element over several
+// pages, reports its progress in the metadata counts, and that queries are
+// then answered from the cache.
+func TestCacheWarmUp(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	res := nic.Resources()
+	mem := memory.NewMemory(res)
+	recs, err := warmUpElements(mem, 25, res)
+	if err != nil {
+		Log.Fail(t, "Write ", err)
+		return
+	}
+
+	serviceName := "ormwarm"
+	persist.ActivateWithWarmUp(serviceName, 0, &testtypes.TestProto{}, &testtypes.TestProtoList{}, nic,
+		mem, nil, persist.WarmUp{PageSize: 4}, "MyString")
+	h, ok := res.Services().ServiceHandler(serviceName, 0)
+	if !ok {
+		Log.Fail(t, "Service not found")
+		return
+	}
+
+	counts, done := waitForWarmUp(h, nic)
+	if !done {
+		Log.Fail(t, "Expected the warm-up to be done, counts ", counts)
+		return
+	}
+	if counts["WarmUpLoaded"] != float64(len(recs)) {
+		Log.Fail(t, "Expected ", len(recs), " elements loaded, got ", counts["WarmUpLoaded"])
+		return
+	}
+
+	// With the database emptied behind the cache, the query is answered from the cache
+	q, _ := object.NewQuery("select * from testproto", res)
+	query, _ := q.Query(res)
+	if err = mem.Delete(query, res); err != nil {
+		Log.Fail(t, "Delete ", err)
+		return
+	}
+	found, _ := queryService(h, "select * from testproto", nic)
+	keys := make(map[string]bool)
+	for _, tp := range found {
+		keys[tp.MyString] = true
+	}
+	for _, rec := range recs {
+		if !keys[rec.MyString] {
+			Log.Fail(t, "Expected the warm-up to load ", rec.MyString)
+			return
+		}
+	}
+	if len(found) != len(recs) {
+		Log.Fail(t, "Expected ", len(recs), " cached elements, got ", len(found))
+		return
+	}
+}
+
+// TestCacheWarmUpPredicate verifies that a warm-up with a predicate loads only
+// the selected elements, and that queries are still answered from the database.
+func TestCacheWarmUpPredicate(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	res := nic.Resources()
+	mem := memory.NewMemory(res)
+	recs, err := warmUpElements(mem, 25, res)
+	if err != nil {
+		Log.Fail(t, "Write ", err)
+		return
+	}
+
+	serviceName := "ormwarmp"
+	persist.ActivateWithWarmUp(serviceName, 0, &testtypes.TestProto{}, &testtypes.TestProtoList{}, nic,
+		mem, nil, persist.WarmUp{PageSize: 3, Predicate: "myint32<10"}, "MyString")
+	h, ok := res.Services().ServiceHandler(serviceName, 0)
+	if !ok {
+		Log.Fail(t, "Service not found")
+		return
+	}
+
+	counts, done := waitForWarmUp(h, nic)
+	if !done {
+		Log.Fail(t, "Expected the warm-up to be done, counts ", counts)
+		return
+	}
+	if counts["WarmUpLoaded"] != 10 {
+		Log.Fail(t, "Expected 10 elements loaded, got ", counts["WarmUpLoaded"])
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto", res)
+	query, _ := q.Query(res)
+	if err = mem.Delete(query, res); err != nil {
+		Log.Fail(t, "Delete ", err)
+		return
+	}
+	for _, rec := range recs {
+		cached := getByKey(h, rec.MyString, nic) != nil
+		if cached != (rec.MyInt32 < 10) {
+			Log.Fail(t, "Element ", rec.MyInt32, " cached ", cached)
+			return
+		}
+	}
+	found, _ := queryService(h, "select * from testproto", nic)
+	if len(found) != 0 {
+		Log.Fail(t, "Expected the query to be answered from the database, got ", len(found))
+		return
+	}
+}
+
+// TestCacheWarmUpChanges verifies that writes during the warm-up make it read
+// the pages again, so no element is skipped.
+func TestCacheWarmUpChanges(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	res := nic.Resources()
+	mem := memory.NewMemory(res)
+	recs, err := warmUpElements(mem, 200, res)
+	if err != nil {
+		Log.Fail(t, "Write ", err)
+		return
+	}
+
+	serviceName := "ormwarmc"
+	persist.ActivateWithWarmUp(serviceName, 0, &testtypes.TestProto{}, &testtypes.TestProtoList{}, nic,
+		mem, nil, persist.WarmUp{PageSize: 2}, "MyString")
+	h, ok := res.Services().ServiceHandler(serviceName, 0)
+	if !ok {
+		Log.Fail(t, "Service not found")
+		return
+	}
+
+	// Deleting the first elements while the pages are read shifts the later ones
+	for _, rec := range recs[:5] {
+		resp := h.Delete(object.New(nil, rec), nic)
+		if resp.Error() != nil {
+			Log.Fail(t, "Delete ", resp.Error())
+			return
+		}
+	}
+
+	counts, done := waitForWarmUp(h, nic)
+	if !done {
+		Log.Fail(t, "Expected the warm-up to be done, counts ", counts)
+		return
+	}
+	q, _ := object.NewQuery("select * from testproto", res)
+	query, _ := q.Query(res)
+	if err = mem.Delete(query, res); err != nil {
+		Log.Fail(t, "Delete ", err)
+		return
+	}
+	for _, rec := range recs[5:] {
+		if getByKey(h, rec.MyString, nic) == nil {
+			Log.Fail(t, "Expected the warm-up to load ", rec.MyString)
+			return
+		}
+	}
+}
+
+// TestCacheWarmUpDeActivate verifies that deactivating a service waits for
+// its warm-up to stop before closing the ORM.
+func TestCacheWarmUpDeActivate(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	res := nic.Resources()
+	orm := &closingOrm{IORM: memory.NewMemory(res)}
+	if _, err := warmUpElements(orm, 100, res); err != nil {
+		Log.Fail(t, "Write ", err)
+		return
+	}
+
+	serviceName := "ormwarmd"
+	persist.ActivateWithWarmUp(serviceName, 0, &testtypes.TestProto{}, &testtypes.TestProtoList{}, nic,
+		orm, nil, persist.WarmUp{PageSize: 2}, "MyString")
+	h, ok := res.Services().ServiceHandler(serviceName, 0)
+	if !ok {
+		Log.Fail(t, "Service not found")
+		return
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if err := h.DeActivate(); err != nil {
+		Log.Fail(t, "DeActivate ", err)
+		return
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&orm.lateReads); n != 0 {
+		Log.Fail(t, "Expected no warm-up read after the ORM was closed, got:", n)
+		return
+	}
+}
+
+// TestCacheWarmUpWritten verifies that the warm-up does not cache an element
+// of a page read before the element was deleted.
+func TestCacheWarmUpWritten(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	res := nic.Resources()
+	orm := &gatedOrm{IORM: memory.NewMemory(res), reading: make(chan struct{}), gate: make(chan struct{})}
+	recs, err := warmUpElements(orm, 5, res)
+	if err != nil {
+		Log.Fail(t, "Write ", err)
+		return
+	}
+
+	serviceName := "ormwarmw"
+	persist.ActivateWithWarmUp(serviceName, 0, &testtypes.TestProto{}, &testtypes.TestProtoList{}, nic,
+		orm, nil, persist.WarmUp{PageSize: 10}, "MyString")
+	h, ok := res.Services().ServiceHandler(serviceName, 0)
+	if !ok {
+		Log.Fail(t, "Service not found")
+		return
+	}
+
+	// The first page holds the element when it is deleted
+	<-orm.reading
+	resp := h.Delete(object.New(nil, recs[0]), nic)
+	close(orm.gate)
+	if resp.Error() != nil {
+		Log.Fail(t, "Delete ", resp.Error())
+		return
+	}
+
+	counts, done := waitForWarmUp(h, nic)
+	if !done {
+		Log.Fail(t, "Expected the warm-up to be done, counts ", counts)
+		return
+	}
+	if getByKey(h, recs[0].MyString, nic) != nil {
+		Log.Fail(t, "Expected the deleted element not to be cached by the warm-up")
+		return
+	}
+	if getByKey(h, recs[1].MyString, nic) == nil {
+		Log.Fail(t, "Expected the warm-up to load ", recs[1].MyString)
+		return
+	}
+}
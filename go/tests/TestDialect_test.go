@@ -0,0 +1,138 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tests
+
+import (
+	"testing"
+
+	"github.com/saichler/l8orm/go/orm/stmt"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+)
+
+// dialectSql is the SQL a dialect is expected to generate.
+type dialectSql struct {
+	name        string
+	dialect     stmt.IDialect
+	placeholder string // Placeholder(3)
+	quote       string // Quote("MyField")
+	upsert      string // Upsert([]string{"MyField", "b"})
+	upsertNone  string // Upsert(nil)
+	like        string // Like(false)
+	ilike       string // Like(true)
+	concat      string // Concat("a", "b")
+	escapeKey   string // EscapeKey("k_1%!a[*?")
+	keyMatch    string // KeyMatch("ParentKey", KeyWildcard())
+	tsdbFilter  string // TsdbPropertyFilter of an exact property ID and a pattern
+	tsdbPattern string // Bound argument of the pattern
+}
+
+var dialectSqls = []dialectSql{
+	{
+		name:        "postgres",
+		dialect:     stmt.Postgres,
+		placeholder: "$3",
+		quote:       `"myfield"`,
+		upsert:      ` ON CONFLICT (ParentKey,RecKey) DO UPDATE SET "myfield"=EXCLUDED."myfield","b"=EXCLUDED."b"`,
+		upsertNone:  " ON CONFLICT (ParentKey,RecKey) DO NOTHING",
+		like:        " LIKE ",
+		ilike:       " ILIKE ",
+		concat:      "(a||b)",
+		escapeKey:   "k!_1!%!!a[*?",
+		keyMatch:    "ParentKey LIKE % ESCAPE '!'",
+		tsdbFilter:  "(prop_id IN ($1) OR prop_id LIKE $2 ESCAPE '!')",
+		tsdbPattern: "dev<%>!_1.cpu",
+	},
+	{
+		name:        "sqlite",
+		dialect:     stmt.Sqlite,
+		placeholder: "$3",
+		quote:       `"MyField"`,
+		upsert:      ` ON CONFLICT (ParentKey,RecKey) DO UPDATE SET "MyField"=excluded."MyField","b"=excluded."b"`,
+		upsertNone:  " ON CONFLICT (ParentKey,RecKey) DO NOTHING",
+		like:        " LIKE ",
+		ilike:       " LIKE ",
+		concat:      "(a||b)",
+		escapeKey:   "k_1%!a[[][*][?]",
+		keyMatch:    "ParentKey GLOB *",
+		tsdbFilter:  "(prop_id IN ($1) OR prop_id GLOB $2)",
+		tsdbPattern: "dev<*>_1.cpu",
+	},
+	{
+		name:        "mysql",
+		dialect:     stmt.MySQL,
+		placeholder: "?",
+		quote:       "`MyField`",
+		upsert:      " ON DUPLICATE KEY UPDATE `MyField`=VALUES(`MyField`),`b`=VALUES(`b`)",
+		upsertNone:  " ON DUPLICATE KEY UPDATE RecKey=RecKey",
+		like:        " LIKE BINARY ",
+		ilike:       " LIKE ",
+		concat:      "CONCAT(a,b)",
+		escapeKey:   "k!_1!%!!a[*?",
+		keyMatch:    "ParentKey LIKE % ESCAPE '!'",
+		tsdbFilter:  "(prop_id IN (?) OR prop_id LIKE ? ESCAPE '!')",
+		tsdbPattern: "dev<%>!_1.cpu",
+	},
+}
+
+// TestDialect verifies the SQL each dialect generates.
+func TestDialect(t *testing.T) {
+	for _, expected := range dialectSqls {
+		d := expected.dialect
+		for _, check := range []struct {
+			what     string
+			got      string
+			expected string
+		}{
+			{"Placeholder", d.Placeholder(3), expected.placeholder},
+			{"Quote", d.Quote("MyField"), expected.quote},
+			{"Upsert", d.Upsert([]string{"MyField", "b"}), expected.upsert},
+			{"Upsert without fields", d.Upsert(nil), expected.upsertNone},
+			{"Like", d.Like(false), expected.like},
+			{"Like case-insensitive", d.Like(true), expected.ilike},
+			{"Concat", d.Concat("a", "b"), expected.concat},
+			{"EscapeKey", d.EscapeKey("k_1%!a[*?"), expected.escapeKey},
+			{"KeyMatch", d.KeyMatch("ParentKey", d.KeyWildcard()), expected.keyMatch},
+		} {
+			if check.got != check.expected {
+				Log.Fail(t, expected.name, check.what, "expected", check.expected, "got", check.got)
+				return
+			}
+		}
+
+		filter, args := stmt.TsdbPropertyFilter(d, []string{"dev<1>.cpu", "dev<*>_1.cpu"})
+		if filter != expected.tsdbFilter || len(args) != 2 || args[0] != "dev<1>.cpu" || args[1] != expected.tsdbPattern {
+			Log.Fail(t, expected.name, "TsdbPropertyFilter expected", expected.tsdbFilter, expected.tsdbPattern,
+				"got", filter, args)
+			return
+		}
+	}
+}
+
+// TestDialectLimitOffset verifies the page clause shared by the dialects.
+func TestDialectLimitOffset(t *testing.T) {
+	for _, d := range []stmt.IDialect{stmt.Postgres, stmt.Sqlite, stmt.MySQL} {
+		for _, page := range []struct {
+			limit    int32
+			offset   int32
+			expected string
+		}{{0, 0, ""}, {0, 20, ""}, {10, 0, " LIMIT 10"}, {10, 20, " LIMIT 10 OFFSET 20"}} {
+			got := d.LimitOffset(page.limit, page.offset)
+			if got != page.expected {
+				Log.Fail(t, "LimitOffset", page.limit, page.offset, "expected", page.expected, "got", got)
+				return
+			}
+		}
+	}
+}
@@ -88,7 +88,8 @@ func cleanup(db *sql.DB) {
 	clean(db)
 }
 
-// clean drops all test tables to reset the database state between tests.
+// clean drops all test tables, and their history tables, to reset the
+// database state between tests.
 func clean(db *sql.DB) {
 	_, e := db.Exec("drop table testproto;")
 	if e != nil {
@@ -102,11 +103,16 @@ func clean(db *sql.DB) {
 	if e != nil {
 		Log.Error(e)
 	}
+	db.Exec("drop table if exists testproto_history;")
+	db.Exec("drop table if exists testprotosub_history;")
+	db.Exec("drop table if exists testprotosubsub_history;")
 }
 
-// cleanTsdb drops the TSDB table to reset state before TSDB tests.
+// cleanTsdb drops the TSDB table and its aggregates to reset state before TSDB tests.
 func cleanTsdb(db *sql.DB) {
-	db.Exec("drop table if exists l8tsdb;")
+	db.Exec("drop materialized view if exists l8tsdb_daily cascade;")
+	db.Exec("drop materialized view if exists l8tsdb_hourly cascade;")
+	db.Exec("drop table if exists l8tsdb cascade;")
 }
 
 // cleanTargetTables drops target-related tables to reset state before target tests.
@@ -0,0 +1,270 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8orm/go/orm/persist"
+	"github.com/saichler/l8orm/go/orm/plugins/memory"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8pollaris/go/types/l8tpollaris"
+	"github.com/saichler/l8reflect/go/reflect/updating"
+	"github.com/saichler/l8reflect/go/tests/utils"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+	probcommon "github.com/saichler/probler/go/prob/common"
+	"github.com/saichler/probler/go/prob/common/creates"
+)
+
+// TestMemory tests criteria, sorting, paging, PATCH and delete against the
+// in-memory plugin without a database.
+func TestMemory(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	m := memory.NewMemory(res)
+	defer m.Close()
+
+	recs := make([]*testtypes.TestProto, 20)
+	for i := 0; i < len(recs); i++ {
+		recs[i] = utils.CreateTestModelInstance(i)
+	}
+	err := m.Write(ifs.POST, object.New(nil, recs), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto where mystring="+recs[7].MyString, res)
+	query, _ := q.Query(res)
+	elems := m.Read(query, res)
+	if elems.Error() != nil || len(elems.Elements()) != 1 {
+		Log.Fail(t, "Expected 1 element for criteria")
+		return
+	}
+	upd := updating.NewUpdater(res, true, true)
+	upd.Update(recs[7], elems.Element().(*testtypes.TestProto))
+	if len(upd.Changes()) > 0 {
+		Log.Fail(t, "Expected no changes, got:", len(upd.Changes()))
+		return
+	}
+
+	q, _ = object.NewQuery("select * from testproto limit 5 page 3", res)
+	query, _ = q.Query(res)
+	elems = m.Read(query, res)
+	if len(elems.Elements()) != 5 {
+		Log.Fail(t, "Expected 5 elements in last page, got:", len(elems.Elements()))
+		return
+	}
+	if elems.Metadata() == nil || elems.Metadata().KeyCount.Counts["Total"] != 20 {
+		Log.Fail(t, "Expected a total count of 20")
+		return
+	}
+
+	patch := &testtypes.TestProto{MyString: recs[7].MyString, MyInt64: 424242}
+	err = m.Write(ifs.PATCH, object.New(nil, patch), res)
+	if err != nil {
+		Log.Fail(t, "Error patching record", err)
+		return
+	}
+	q, _ = object.NewQuery("select * from testproto where mystring="+recs[7].MyString, res)
+	query, _ = q.Query(res)
+	patched := m.Read(query, res).Element().(*testtypes.TestProto)
+	if patched.MyInt64 != 424242 || patched.MyInt32 != recs[7].MyInt32 {
+		Log.Fail(t, "PATCH did not merge fields as expected")
+		return
+	}
+
+	err = m.Delete(query, res)
+	if err != nil {
+		Log.Fail(t, "Error deleting record", err)
+		return
+	}
+	q, _ = object.NewQuery("select * from testproto", res)
+	query, _ = q.Query(res)
+	if len(m.Read(query, res).Elements()) != 19 {
+		Log.Fail(t, "Expected 19 elements after delete")
+		return
+	}
+}
+
+// TestMemoryNestedCriteria verifies that the in-memory plugin applies criteria
+// on child table attributes like the SQL plugins.
+func TestMemoryNestedCriteria(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	m := memory.NewMemory(res)
+	defer m.Close()
+	checkNestedCriteria(t, m, res)
+}
+
+// TestMemoryWildcardKeys verifies that the in-memory plugin only replaces the
+// child rows of the PUT keys, like the SQL plugins.
+func TestMemoryWildcardKeys(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	m := memory.NewMemory(res)
+	defer m.Close()
+	checkWildcardKeys(t, m, res)
+}
+
+// TestMemoryPatchMaskedMap verifies that the in-memory plugin replaces a map
+// field listed by a PATCH field mask, like the SQL plugins.
+func TestMemoryPatchMaskedMap(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	m := memory.NewMemory(res)
+	defer m.Close()
+	checkMaskedMap(t, m, res)
+}
+
+// TestMemoryService tests an OrmService backed by the in-memory plugin.
+func TestMemoryService(t *testing.T) {
+	eg1 := topo.VnicByVnetNum(1, 2)
+	eg2 := topo.VnicByVnetNum(2, 2)
+
+	serviceName := "ormmem"
+	persist.Activate(serviceName, 0, &testtypes.TestProto{}, &testtypes.TestProtoList{}, eg2,
+		memory.NewMemory(eg2.Resources()), nil, false, "MyString")
+
+	time.Sleep(time.Second)
+
+	before := utils.CreateTestModelInstance(5)
+	eg1.Resources().Registry().Register(before)
+
+	elems := eg1.ProximityRequest(serviceName, 0, ifs.POST, before, 5)
+	if elems.Error() != nil {
+		Log.Fail(t, elems.Error())
+		return
+	}
+
+	elems = eg1.ProximityRequest(serviceName, 0, ifs.GET, "select * from TestProto where MyString="+before.MyString, 5)
+	checkResponse(elems, eg1.Resources(), before, t)
+}
+
+// TestMemoryOrderBy verifies that the in-memory plugin sorts by several keys,
+// including nested struct attributes, like the SQL plugins.
+func TestMemoryOrderBy(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	m := memory.NewMemory(res)
+	defer m.Close()
+	checkOrderBy(t, m, res)
+}
+
+// TestMemoryServiceFieldMask verifies that the field mask of a PATCH sent to a
+// remote service with NewFieldMaskRequest is applied by the service.
+func TestMemoryServiceFieldMask(t *testing.T) {
+	eg1 := topo.VnicByVnetNum(1, 2)
+	eg2 := topo.VnicByVnetNum(2, 2)
+
+	serviceName := "ormmask"
+	persist.Activate(serviceName, 0, &testtypes.TestProto{}, &testtypes.TestProtoList{}, eg2,
+		memory.NewMemory(eg2.Resources()), nil, false, "MyString")
+
+	time.Sleep(time.Second)
+
+	before := utils.CreateTestModelInstance(8)
+	eg1.Resources().Registry().Register(before)
+	eg1.Resources().Introspector().Decorators().AddPrimaryKeyDecorator(&testtypes.TestProto{}, "MyString")
+	eg1.Resources().Introspector().Inspect(&l8orms.L8OrmRData{})
+
+	elems := eg1.ProximityRequest(serviceName, 0, ifs.POST, before, 5)
+	if elems.Error() != nil {
+		Log.Fail(t, elems.Error())
+		return
+	}
+
+	patch := &testtypes.TestProto{MyString: before.MyString, MyInt32: 0, MyInt64: 424242}
+	request := convert.NewFieldMaskRequest(convert.NewFieldMask(object.New(nil, patch), "myint32"), eg1.Resources())
+	if request.Error() != nil {
+		Log.Fail(t, request.Error())
+		return
+	}
+	elems = eg1.ProximityRequest(serviceName, 0, ifs.PATCH, request.Element(), 5)
+	if elems.Error() != nil {
+		Log.Fail(t, elems.Error())
+		return
+	}
+
+	before.MyInt32 = 0
+	elems = eg1.ProximityRequest(serviceName, 0, ifs.GET, "select * from TestProto where MyString="+before.MyString, 5)
+	checkResponse(elems, eg1.Resources(), before, t)
+}
+
+// TestMemoryServiceTimeout verifies that the requests of a service whose
+// deadline passes before the database is reached fail with a timeout.
+func TestMemoryServiceTimeout(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	res := nic.Resources()
+
+	serviceName := "ormtimeout"
+	persist.ActivateWithOptions(serviceName, 0, &testtypes.TestProto{}, &testtypes.TestProtoList{}, nic,
+		memory.NewMemory(res), nil, persist.Options{Timeout: time.Nanosecond}, "MyString")
+	h, ok := res.Services().ServiceHandler(serviceName, 0)
+	if !ok {
+		Log.Fail(t, "Service not found")
+		return
+	}
+
+	rec := utils.CreateTestModelInstance(1)
+	res.Registry().Register(rec)
+	resp := h.Post(object.New(nil, rec), nic)
+	if !common.IsTimeout(resp.Error()) {
+		Log.Fail(t, "Expected the POST to time out, got:", resp.Error())
+		return
+	}
+
+	q, err := object.NewQuery("select * from TestProto where MyString="+rec.MyString, res)
+	if err != nil {
+		Log.Fail(t, "Query ", err)
+		return
+	}
+	resp = h.Get(q, nic)
+	if !common.IsTimeout(resp.Error()) {
+		Log.Fail(t, "Expected the GET to time out, got:", resp.Error())
+		return
+	}
+}
+
+// TestMemoryPutOtherType verifies that a PUT removes the child rows of the
+// written type only, keeping those of another type stored under the same key.
+func TestMemoryPutOtherType(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	res.Introspector().Decorators().AddPrimaryKeyDecorator(&l8tpollaris.L8PTarget{}, "TargetId")
+	res.Introspector().Decorators().AddPrimaryKeyDecorator(&testtypes.TestProto{}, "MyString")
+	m := memory.NewMemory(res)
+	defer m.Close()
+
+	device := creates.CreateDevice("60.50.42.1", probcommon.NetworkDevice_Links_ID, "sim")
+	if err := m.Write(ifs.POST, object.New(nil, device), res); err != nil {
+		Log.Fail(t, "Error writing target", err)
+		return
+	}
+	rec := utils.CreateTestModelInstance(1)
+	rec.MyString = device.TargetId
+	if err := m.Write(ifs.PUT, object.New(nil, rec), res); err != nil {
+		Log.Fail(t, "Error writing record", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from L8PTarget where targetid="+device.TargetId, res)
+	query, _ := q.Query(res)
+	target, ok := m.Read(query, res).Element().(*l8tpollaris.L8PTarget)
+	if !ok || len(target.Hosts) == 0 {
+		Log.Fail(t, "Expected the PUT of another type to keep the hosts of the target")
+		return
+	}
+}
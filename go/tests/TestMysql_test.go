@@ -0,0 +1,154 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tests
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/saichler/l8orm/go/orm/plugins/mysql"
+	"github.com/saichler/l8reflect/go/reflect/updating"
+	"github.com/saichler/l8reflect/go/tests/utils"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// openMysql connects to the MySQL test database given by L8ORM_MYSQL_DSN,
+// defaulting to a local admin/admin instance. The test is skipped when no
+// server is reachable.
+func openMysql(t *testing.T) *sql.DB {
+	dsn := os.Getenv("L8ORM_MYSQL_DSN")
+	if dsn == "" {
+		dsn = "admin:admin@tcp(127.0.0.1:3306)/admin"
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		panic(err)
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		t.Skip("MySQL is not available: ", err)
+	}
+	db.Exec("drop table if exists testproto;")
+	db.Exec("drop table if exists testprotosub;")
+	return db
+}
+
+// TestMysql tests POST, PATCH, paging and delete through the MySQL plugin.
+func TestMysql(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	m := mysql.NewMysql(openMysql(t), res)
+	defer m.Close()
+
+	before := make([]*testtypes.TestProto, 10)
+	for i := 0; i < len(before); i++ {
+		before[i] = utils.CreateTestModelInstance(i)
+	}
+
+	err := m.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	// Writing the same records again exercises ON DUPLICATE KEY UPDATE
+	err = m.Write(ifs.PUT, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error rewriting records", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto where mystring="+before[3].MyString, res)
+	query, _ := q.Query(res)
+	elems := m.Read(query, res)
+	if elems.Error() != nil || len(elems.Elements()) != 1 {
+		Log.Fail(t, "Expected 1 element for criteria")
+		return
+	}
+	upd := updating.NewUpdater(res, true, true)
+	upd.Update(before[3], elems.Element().(*testtypes.TestProto))
+	if len(upd.Changes()) > 0 {
+		Log.Fail(t, "Expected no changes, got:", len(upd.Changes()))
+		return
+	}
+
+	patch := &testtypes.TestProto{MyString: before[3].MyString, MyInt64: 424242}
+	err = m.Write(ifs.PATCH, object.New(nil, patch), res)
+	if err != nil {
+		Log.Fail(t, "Error patching record", err)
+		return
+	}
+	patched := m.Read(query, res).Element().(*testtypes.TestProto)
+	if patched.MyInt64 != 424242 || patched.MyInt32 != before[3].MyInt32 {
+		Log.Fail(t, "PATCH did not merge fields as expected")
+		return
+	}
+
+	q, _ = object.NewQuery("select * from testproto limit 4 page 1", res)
+	pageQuery, _ := q.Query(res)
+	elems = m.Read(pageQuery, res)
+	if len(elems.Elements()) != 4 {
+		Log.Fail(t, "Expected 4 elements in page, got:", len(elems.Elements()))
+		return
+	}
+
+	err = m.Delete(query, res)
+	if err != nil {
+		Log.Fail(t, "Error deleting record", err)
+		return
+	}
+	q, _ = object.NewQuery("select * from testproto", res)
+	allQuery, _ := q.Query(res)
+	if len(m.Read(allQuery, res).Elements()) != len(before)-1 {
+		Log.Fail(t, "Expected", len(before)-1, "elements after delete")
+		return
+	}
+}
+
+// TestMysqlWildcardKeys verifies that PUT only replaces the child rows of its
+// own keys when they contain LIKE wildcards, with the binary key collation.
+func TestMysqlWildcardKeys(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	m := mysql.NewMysql(openMysql(t), res)
+	defer m.Close()
+	checkWildcardKeys(t, m, res)
+}
+
+// TestMysqlLongKey verifies that a key longer than the key columns hold fails
+// the write with an error instead of being truncated, and writes nothing.
+func TestMysqlLongKey(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	m := mysql.NewMysql(openMysql(t), res)
+	defer m.Close()
+
+	elem := utils.CreateTestModelInstance(1)
+	elem.MyString = strings.Repeat("k", 400)
+	err := m.Write(ifs.POST, object.New(nil, elem), res)
+	if err == nil {
+		Log.Fail(t, "Expected the write of a 400 characters key to fail")
+		return
+	}
+	q, _ := object.NewQuery("select * from testproto", res)
+	query, _ := q.Query(res)
+	if n := len(m.Read(query, res).Elements()); n != 0 {
+		Log.Fail(t, "Expected nothing written, got:", n)
+		return
+	}
+}
@@ -0,0 +1,101 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tests
+
+import (
+	"github.com/saichler/l8orm/go/orm/plugins/postgres"
+	"github.com/saichler/l8reflect/go/reflect/updating"
+	"github.com/saichler/l8reflect/go/tests/utils"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+	"testing"
+)
+
+// TestPostgresBulkLoad verifies that POST and PUT writes above the bulk
+// threshold go through COPY and the staging merge with the same results as
+// row by row writes, including PUT replacing child rows.
+func TestPostgresBulkLoad(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	p.SetBulkThreshold(10)
+
+	before := make([]*testtypes.TestProto, 30)
+	for i := 0; i < len(before); i++ {
+		before[i] = utils.CreateTestModelInstance(i)
+	}
+	err := p.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error bulk writing records", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto", res)
+	all, _ := q.Query(res)
+	if n := len(p.Read(all, res).Elements()); n != len(before) {
+		Log.Fail(t, "Expected", len(before), "elements after bulk POST, got:", n)
+		return
+	}
+
+	after := make([]*testtypes.TestProto, len(before))
+	for i := 0; i < len(after); i++ {
+		after[i] = utils.CreateTestModelInstance(i)
+		for key := range after[i].MyString2ModelMap {
+			delete(after[i].MyString2ModelMap, key)
+			break
+		}
+	}
+	err = p.Write(ifs.PUT, object.New(nil, after), res)
+	if err != nil {
+		Log.Fail(t, "Error bulk replacing records", err)
+		return
+	}
+
+	q, _ = object.NewQuery("select * from testproto where mystring="+after[7].MyString, res)
+	query, _ := q.Query(res)
+	elems := p.Read(query, res)
+	if elems.Error() != nil || len(elems.Elements()) != 1 {
+		Log.Fail(t, "Expected 1 element after bulk PUT")
+		return
+	}
+	upd := updating.NewUpdater(res, true, true)
+	upd.Update(after[7], elems.Element().(*testtypes.TestProto))
+	if len(upd.Changes()) > 0 {
+		Log.Fail(t, "Expected no changes after bulk PUT, got:", len(upd.Changes()))
+		return
+	}
+}
+
+// TestPostgresWildcardKeys verifies that PUT only replaces the child rows of
+// its own keys when they contain LIKE wildcards, row by row and in bulk.
+func TestPostgresWildcardKeys(t *testing.T) {
+	for _, threshold := range []int{1000, 2} {
+		nic := topo.VnicByVnetNum(2, 2)
+		db := openDBConection(nic.Resources())
+		clean(db)
+
+		res, _ := CreateResources(25000, 1, ifs.Info_Level)
+		p := postgres.NewPostgres(db, res)
+		p.SetBulkThreshold(threshold)
+		checkWildcardKeys(t, p, res)
+		cleanup(db)
+	}
+}
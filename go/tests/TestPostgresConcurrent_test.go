@@ -0,0 +1,129 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/saichler/l8orm/go/orm/plugins/postgres"
+	"github.com/saichler/l8reflect/go/reflect/updating"
+	"github.com/saichler/l8reflect/go/tests/utils"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// readTestProto reads a TestProto by primary key, returning nil when it is
+// not found or the read fails.
+func readTestProto(p *postgres.Postgres, key string, res ifs.IResources) *testtypes.TestProto {
+	q, err := object.NewQuery("select * from testproto where mystring="+key, res)
+	if err != nil {
+		return nil
+	}
+	query, err := q.Query(res)
+	if err != nil {
+		return nil
+	}
+	elems := p.Read(query, res)
+	if elems.Error() != nil {
+		return nil
+	}
+	found, _ := elems.Element().(*testtypes.TestProto)
+	return found
+}
+
+// TestPostgresConcurrent runs concurrent clients, each replacing its own
+// elements and reading them back, while another client pages through all the
+// elements, and verifies every read and the stored elements at the end. Run
+// it with -race to also check the plugin for data races.
+func TestPostgresConcurrent(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	const clients = 8
+	const perClient = 2
+	const rounds = 10
+	recs := make([]*testtypes.TestProto, clients*perClient)
+	for i := range recs {
+		recs[i] = utils.CreateTestModelInstance(i)
+	}
+	if err := p.Write(ifs.POST, object.New(nil, recs), res); err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	errs := make(chan string, clients+1)
+	var wg sync.WaitGroup
+	for c := 0; c < clients; c++ {
+		wg.Add(1)
+		go func(own []*testtypes.TestProto) {
+			defer wg.Done()
+			for round := 1; round <= rounds; round++ {
+				for _, rec := range own {
+					rec.MyInt32 = int32(round)
+					if err := p.Write(ifs.PUT, object.New(nil, rec), res); err != nil {
+						errs <- "Error replacing " + rec.MyString + ": " + err.Error()
+						return
+					}
+					found := readTestProto(p, rec.MyString, res)
+					if found == nil || found.MyInt32 != rec.MyInt32 {
+						errs <- fmt.Sprint("Expected ", rec.MyString, " to read back round ", round)
+						return
+					}
+				}
+			}
+		}(recs[c*perClient : (c+1)*perClient])
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		q, _ := object.NewQuery("select * from testproto limit 4 page 0", res)
+		query, _ := q.Query(res)
+		for i := 0; i < clients*rounds; i++ {
+			elems := p.Read(query, res)
+			if elems.Error() != nil || len(elems.Elements()) != 4 {
+				errs <- fmt.Sprint("Expected a page of 4 elements, got ", len(elems.Elements()), " ", elems.Error())
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		Log.Fail(t, err)
+		return
+	}
+
+	for _, rec := range recs {
+		found := readTestProto(p, rec.MyString, res)
+		if found == nil {
+			Log.Fail(t, "Expected to read", rec.MyString)
+			return
+		}
+		upd := updating.NewUpdater(res, true, true)
+		upd.Update(rec, found)
+		if len(upd.Changes()) > 0 {
+			Log.Fail(t, "Expected", rec.MyString, "to be stored as last written, got changes:", len(upd.Changes()))
+			return
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tests
+
+import (
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/plugins/postgres"
+	"github.com/saichler/l8reflect/go/reflect/updating"
+	"github.com/saichler/l8reflect/go/tests/utils"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+	"testing"
+	"time"
+)
+
+// TestPostgresHistory verifies that a history type keeps the rows replaced by
+// a PUT and removed by a DELETE, and that AsOf reads rebuild the element, its
+// child rows included, as it stood at each point in time.
+func TestPostgresHistory(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	p.EnableHistory("TestProto")
+
+	before := utils.CreateTestModelInstance(5)
+	err := p.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing record", err)
+		return
+	}
+	// Stamps are in seconds, keep each point in time in its own second.
+	time.Sleep(1100 * time.Millisecond)
+	first := time.Now()
+	time.Sleep(1100 * time.Millisecond)
+
+	after := utils.CreateTestModelInstance(5)
+	after.MyInt32 = before.MyInt32 + 100
+	for key := range after.MyString2ModelMap {
+		delete(after.MyString2ModelMap, key)
+		break
+	}
+	err = p.Write(ifs.PUT, object.New(nil, after), res)
+	if err != nil {
+		Log.Fail(t, "Error replacing record", err)
+		return
+	}
+	time.Sleep(1100 * time.Millisecond)
+	second := time.Now()
+	time.Sleep(1100 * time.Millisecond)
+
+	q, _ := object.NewQuery("select * from testproto where mystring="+before.MyString, res)
+	query, _ := q.Query(res)
+	err = p.Delete(query, res)
+	if err != nil {
+		Log.Fail(t, "Error deleting record", err)
+		return
+	}
+
+	if n := len(p.Read(query, res).Elements()); n != 0 {
+		Log.Fail(t, "Expected no current element after delete, got:", n)
+		return
+	}
+
+	for _, point := range []struct {
+		at       time.Time
+		expected *testtypes.TestProto
+	}{{first, before}, {second, after}} {
+		elems := p.Read(common.AsOf(query, point.at), res)
+		if elems.Error() != nil || len(elems.Elements()) != 1 {
+			Log.Fail(t, "Expected 1 element as of", point.at, elems.Error())
+			return
+		}
+		upd := updating.NewUpdater(res, true, true)
+		upd.Update(point.expected, elems.Element().(*testtypes.TestProto))
+		if len(upd.Changes()) > 0 {
+			Log.Fail(t, "Expected no changes as of", point.at, "got:", len(upd.Changes()))
+			return
+		}
+	}
+}
+
+// TestPostgresHistoryNestedCriteria verifies that the criteria of an AsOf read
+// on child table attributes match the child rows as they stood at that time,
+// not the current ones.
+func TestPostgresHistoryNestedCriteria(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	p.EnableHistory("TestProto")
+
+	before := utils.CreateTestModelInstance(6)
+	before.MySingle.MyString = "up"
+	err := p.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing record", err)
+		return
+	}
+	time.Sleep(1100 * time.Millisecond)
+	first := time.Now()
+	time.Sleep(1100 * time.Millisecond)
+
+	after := utils.CreateTestModelInstance(6)
+	after.MySingle.MyString = "down"
+	err = p.Write(ifs.PUT, object.New(nil, after), res)
+	if err != nil {
+		Log.Fail(t, "Error replacing record", err)
+		return
+	}
+	time.Sleep(1100 * time.Millisecond)
+	second := time.Now()
+
+	for _, point := range []struct {
+		at       time.Time
+		status   string
+		expected int
+	}{{first, "up", 1}, {first, "down", 0}, {second, "up", 0}, {second, "down", 1}} {
+		q, _ := object.NewQuery("select * from testproto where mysingle.mystring="+point.status, res)
+		query, _ := q.Query(res)
+		elems := p.Read(common.AsOf(query, point.at), res)
+		if elems.Error() != nil || len(elems.Elements()) != point.expected {
+			Log.Fail(t, "Expected", point.expected, "elements", point.status, "as of", point.at, "got:",
+				len(elems.Elements()), elems.Error())
+			return
+		}
+	}
+}
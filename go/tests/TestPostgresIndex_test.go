@@ -0,0 +1,342 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/plugins/postgres"
+	"github.com/saichler/l8pollaris/go/types/l8tpollaris"
+	"github.com/saichler/l8reflect/go/tests/utils"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+	probcommon "github.com/saichler/probler/go/prob/common"
+	"github.com/saichler/probler/go/prob/common/creates"
+)
+
+// TestPostgresIndexStaleKey verifies that a page served from the pagination
+// index skips a cached key whose row was deleted behind the cache.
+func TestPostgresIndexStaleKey(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+
+	before := make([]*testtypes.TestProto, 10)
+	for i := 0; i < len(before); i++ {
+		before[i] = utils.CreateTestModelInstance(i)
+	}
+	err := p.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto limit 5 page 0", res)
+	query, _ := q.Query(res)
+	page := p.Read(query, res)
+	if page.Error() != nil || len(page.Elements()) != 5 {
+		Log.Fail(t, "Expected 5 elements in page")
+		return
+	}
+	// Another instance on the same database deletes without invalidating p's index
+	deleted := page.Elements()[0].(*testtypes.TestProto).MyString
+	other := postgres.NewPostgres(db, res)
+	dq, _ := object.NewQuery("select * from testproto where mystring="+deleted, res)
+	deleteQuery, _ := dq.Query(res)
+	err = other.Delete(deleteQuery, res)
+	if err != nil {
+		Log.Fail(t, "Error deleting record", err)
+		return
+	}
+
+	page = p.Read(query, res)
+	if page.Error() != nil || len(page.Elements()) != 4 {
+		Log.Fail(t, "Expected 4 elements in page after the delete")
+		return
+	}
+	for _, elem := range page.Elements() {
+		if elem.(*testtypes.TestProto).MyString == deleted {
+			Log.Fail(t, "Expected the deleted element not to be read")
+			return
+		}
+	}
+	if stats := p.IndexStats(); stats.Hits != 1 {
+		Log.Fail(t, "Expected the page to be served from the index, got hits:", stats.Hits)
+		return
+	}
+}
+
+// writeIndexElements creates the tables and writes the elements the index tests read.
+func writeIndexElements(t *testing.T, p *postgres.Postgres, res ifs.IResources, count int) bool {
+	before := make([]*testtypes.TestProto, count)
+	for i := 0; i < len(before); i++ {
+		before[i] = utils.CreateTestModelInstance(i)
+	}
+	err := p.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return false
+	}
+	return true
+}
+
+// readPage reads the first page of the given size through the index.
+func readPage(t *testing.T, p *postgres.Postgres, res ifs.IResources, limit string) bool {
+	q, _ := object.NewQuery("select * from testproto limit "+limit+" page 0", res)
+	query, _ := q.Query(res)
+	elems := p.Read(query, res)
+	if elems.Error() != nil || len(elems.Elements()) == 0 {
+		Log.Fail(t, "Expected elements in page of", limit, elems.Error())
+		return false
+	}
+	return true
+}
+
+// TestPostgresIndexBounds verifies that the pagination index evicts the least
+// recently used queries to stay within its byte budget, does not cache a query
+// larger than the budget, and removes queries unused for the configured TTL.
+func TestPostgresIndexBounds(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	if !writeIndexElements(t, p, res, 10) || !readPage(t, p, res, "5") {
+		return
+	}
+	// Every page size caches the same 10 keys, so each entry has this size
+	size := p.IndexStats().Bytes
+	if size <= 0 {
+		Log.Fail(t, "Expected the cached keys to have a size")
+		return
+	}
+
+	p = postgres.NewPostgres(db, res, postgres.IndexConfig{MaxBytes: 2*size + size/2})
+	for _, limit := range []string{"5", "4", "3"} {
+		if !readPage(t, p, res, limit) {
+			return
+		}
+	}
+	stats := p.IndexStats()
+	if stats.Entries != 2 || stats.Evictions != 1 || stats.Bytes != 2*size || stats.Misses != 3 {
+		Log.Fail(t, "Expected 2 entries of", 2*size, "bytes after 1 eviction and 3 misses, got:", stats)
+		return
+	}
+	// The page of 5 was the least recently used, so it was evicted
+	if !readPage(t, p, res, "3") || !readPage(t, p, res, "5") {
+		return
+	}
+	stats = p.IndexStats()
+	if stats.Hits != 1 || stats.Misses != 4 || stats.Evictions != 2 || stats.Entries != 2 {
+		Log.Fail(t, "Expected 1 hit, 4 misses and 2 evictions, got:", stats)
+		return
+	}
+
+	p = postgres.NewPostgres(db, res, postgres.IndexConfig{MaxBytes: size / 2})
+	if !readPage(t, p, res, "5") {
+		return
+	}
+	stats = p.IndexStats()
+	if stats.Entries != 0 || stats.Bytes != 0 || stats.Evictions != 1 {
+		Log.Fail(t, "Expected a query over the budget not to be cached, got:", stats)
+		return
+	}
+
+	p = postgres.NewPostgres(db, res, postgres.IndexConfig{TTL: time.Second, Tick: 200 * time.Millisecond})
+	if !readPage(t, p, res, "5") {
+		return
+	}
+	time.Sleep(2 * time.Second)
+	stats = p.IndexStats()
+	if stats.Entries != 0 || stats.Bytes != 0 || stats.Expired != 1 {
+		Log.Fail(t, "Expected the unused query to expire, got:", stats)
+		return
+	}
+	if !readPage(t, p, res, "5") || p.IndexStats().Misses != 2 {
+		Log.Fail(t, "Expected the expired query to be read again")
+		return
+	}
+}
+
+// checkMaintained reads the query from the index of p, expecting a hit, and
+// from a fresh instance without cached queries, and checks both return the
+// same elements in the same order.
+func checkMaintained(t *testing.T, step string, p, fresh *postgres.Postgres, query ifs.IQuery, res ifs.IResources) bool {
+	hits := p.IndexStats().Hits
+	cached := p.Read(query, res)
+	if p.IndexStats().Hits != hits+1 {
+		Log.Fail(t, step, ": expected the query to be served from the maintained index")
+		return false
+	}
+	expected := fresh.Read(query, res)
+	if cached.Error() != nil || expected.Error() != nil || len(cached.Elements()) != len(expected.Elements()) {
+		Log.Fail(t, step, ": expected", len(expected.Elements()), "elements, got:", len(cached.Elements()))
+		return false
+	}
+	for i, elem := range cached.Elements() {
+		if elem.(*testtypes.TestProto).MyString != expected.Elements()[i].(*testtypes.TestProto).MyString {
+			Log.Fail(t, step, ": unexpected element at position", i)
+			return false
+		}
+	}
+	return true
+}
+
+// TestPostgresIndexMaintain verifies that writes maintain a cached sorted
+// query in place: an insert lands at its sorted position, an update moves the
+// element, a delete removes it, and so does a write that no longer matches the
+// criteria. Each step is checked against a fresh read of the database.
+func TestPostgresIndexMaintain(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+
+	before := make([]*testtypes.TestProto, 10)
+	for i := 0; i < len(before); i++ {
+		before[i] = utils.CreateTestModelInstance(i)
+		before[i].MyInt32 = int32(100 + i*10)
+	}
+	err := p.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto where myint32>=100 limit 100 page 0", res)
+	query, _ := q.Query(res)
+	query = common.OrderBy(query, common.Asc("myint32"))
+	if n := len(p.Read(query, res).Elements()); n != len(before) {
+		Log.Fail(t, "Expected", len(before), "elements, got:", n)
+		return
+	}
+	fresh := func() *postgres.Postgres {
+		return postgres.NewPostgres(db, res)
+	}
+
+	added := utils.CreateTestModelInstance(10)
+	added.MyInt32 = 135
+	err = p.Write(ifs.POST, object.New(nil, added), res)
+	if err != nil {
+		Log.Fail(t, "Error on insert", err)
+		return
+	}
+	if !checkMaintained(t, "insert", p, fresh(), query, res) {
+		return
+	}
+
+	moved := utils.CreateTestModelInstance(0)
+	moved.MyInt32 = 195
+	err = p.Write(ifs.PUT, object.New(nil, moved), res)
+	if err != nil {
+		Log.Fail(t, "Error on update", err)
+		return
+	}
+	if !checkMaintained(t, "update", p, fresh(), query, res) {
+		return
+	}
+
+	dq, _ := object.NewQuery("select * from testproto where mystring="+before[5].MyString, res)
+	deleteQuery, _ := dq.Query(res)
+	err = p.Delete(deleteQuery, res)
+	if err != nil {
+		Log.Fail(t, "Error on delete", err)
+		return
+	}
+	if !checkMaintained(t, "delete", p, fresh(), query, res) {
+		return
+	}
+
+	unmatched := utils.CreateTestModelInstance(2)
+	unmatched.MyInt32 = 50
+	err = p.Write(ifs.PUT, object.New(nil, unmatched), res)
+	if err != nil {
+		Log.Fail(t, "Error on unmatched", err)
+		return
+	}
+	if !checkMaintained(t, "unmatched", p, fresh(), query, res) {
+		return
+	}
+	if n := len(p.Read(query, res).Elements()); n != len(before)-1 {
+		Log.Fail(t, "Expected", len(before)-1, "elements after the writes, got:", n)
+		return
+	}
+}
+
+// TestPostgresIndexInvalidation verifies that a write only invalidates the
+// cached queries that depend on its tables: a write to an unrelated type keeps
+// a cached query, while a write to one of its child tables invalidates it.
+func TestPostgresIndexInvalidation(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	res := nic.Resources()
+	res.Introspector().Decorators().AddPrimaryKeyDecorator(&l8tpollaris.L8PTarget{}, "TargetId")
+	res.Introspector().Decorators().AddPrimaryKeyDecorator(&testtypes.TestProto{}, "MyString")
+	res.Introspector().Decorators().AddPrimaryKeyDecorator(&testtypes.TestProtoSub{}, "MyString")
+	db := openDBConection(res)
+	clean(db)
+	cleanTargetTables(db)
+	defer cleanup(db)
+	defer cleanTargetTables(db)
+
+	p := postgres.NewPostgres(db, res)
+	if !writeIndexElements(t, p, res, 10) {
+		return
+	}
+	// Sorted by a string column, so writes invalidate the query instead of maintaining it
+	q, _ := object.NewQuery("select * from testproto limit 5 page 0", res)
+	query, _ := q.Query(res)
+	query = common.OrderBy(query, common.Asc("mystring"))
+	if n := len(p.Read(query, res).Elements()); n != 5 {
+		Log.Fail(t, "Expected 5 elements in page, got:", n)
+		return
+	}
+
+	device := creates.CreateDevice("60.50.41.1", probcommon.NetworkDevice_Links_ID, "sim")
+	err := p.Write(ifs.POST, object.New(nil, device), res)
+	if err != nil {
+		Log.Fail(t, "Error writing target", err)
+		return
+	}
+	p.Read(query, res)
+	if stats := p.IndexStats(); stats.Hits != 1 || stats.Misses != 1 {
+		Log.Fail(t, "Expected a write to an unrelated type to keep the query, got:", stats)
+		return
+	}
+
+	sub := &testtypes.TestProtoSub{MyString: "standalone-sub"}
+	err = p.Write(ifs.POST, object.New(nil, sub), res)
+	if err != nil {
+		Log.Fail(t, "Error writing child type", err)
+		return
+	}
+	p.Read(query, res)
+	if stats := p.IndexStats(); stats.Hits != 1 || stats.Misses != 2 {
+		Log.Fail(t, "Expected a write to a child table to invalidate the query, got:", stats)
+		return
+	}
+}
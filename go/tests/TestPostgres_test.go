@@ -194,3 +194,97 @@ func TestPostgresCriteria(t *testing.T) {
 		}
 	}
 }
+
+// TestPostgresPutReplace verifies that a PUT removes the child rows of
+// the map entries the element no longer has.
+func TestPostgresPutReplace(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	checkPutReplace(t, p, res)
+}
+
+// TestPostgresPatchMaskedMap verifies that a PATCH listing a map field in
+// its field mask replaces the stored map.
+func TestPostgresPatchMaskedMap(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	checkMaskedMap(t, p, res)
+}
+
+// TestPostgresVersionConflict verifies that writes increment the version
+// column and that a write carrying a stale version fails with a conflict.
+func TestPostgresVersionConflict(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	p.EnableVersioning("MyInt64")
+	checkVersionConflict(t, p, res)
+}
+
+// TestPostgresSoftDelete verifies that a soft delete type keeps deleted
+// rows as tombstones that reads skip, that WithDeleted and Restore bring them
+// back, and that Purge removes them for good.
+func TestPostgresSoftDelete(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	p.EnableSoftDelete("TestProto")
+	checkSoftDelete(t, p, res)
+}
+
+// TestPostgresPageChildren verifies that a paginated read loads the child
+// rows of the page's root elements only, and loads them completely.
+func TestPostgresPageChildren(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	checkPageChildren(t, p, res)
+}
+
+// TestPostgresNestedCriteria verifies that criteria on child table
+// attributes filter the root elements on read and on delete.
+func TestPostgresNestedCriteria(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	checkNestedCriteria(t, p, res)
+}
+
+// TestPostgresOrderBy verifies that reads sort by several keys,
+// including nested struct attributes.
+func TestPostgresOrderBy(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	clean(db)
+	defer cleanup(db)
+
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	p := postgres.NewPostgres(db, res)
+	checkOrderBy(t, p, res)
+}
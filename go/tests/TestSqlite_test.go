@@ -0,0 +1,805 @@
+/*
+© 2025 Sharon Aicler (saichler@gmail.com)
+
+Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+You may obtain a copy of the License at:
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/convert"
+	"github.com/saichler/l8orm/go/orm/plugins/sqlite"
+	"github.com/saichler/l8orm/go/types/l8orms"
+	"github.com/saichler/l8reflect/go/reflect/updating"
+	"github.com/saichler/l8reflect/go/tests/utils"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+	"github.com/saichler/l8types/go/types/l8api"
+	"github.com/saichler/l8types/go/types/l8notify"
+	_ "modernc.org/sqlite"
+)
+
+// openSqlite opens a fresh SQLite database file in a temporary directory.
+func openSqlite(t *testing.T) *sql.DB {
+	file := filepath.Join(t.TempDir(), "l8orm.db")
+	os.Remove(file)
+	db, err := sql.Open("sqlite", file)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// TestSqlite tests a POST/GET round trip through the SQLite plugin.
+// Verifies that nested structs, slices and maps survive the conversion.
+func TestSqlite(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+
+	before := make([]*testtypes.TestProto, 10)
+	for i := 0; i < len(before); i++ {
+		before[i] = utils.CreateTestModelInstance(i)
+	}
+
+	err := s.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	q, err := object.NewQuery("select * from testproto where mystring="+before[3].MyString, res)
+	if err != nil {
+		Log.Fail(t, "Error creating query", err)
+		return
+	}
+	query, _ := q.Query(res)
+	elems := s.Read(query, res)
+	if elems.Error() != nil {
+		Log.Fail(t, "Error reading records", elems.Error())
+		return
+	}
+	if len(elems.Elements()) != 1 {
+		Log.Fail(t, "Expected 1 element, got:", len(elems.Elements()))
+		return
+	}
+
+	upd := updating.NewUpdater(res, true, true)
+	upd.Update(before[3], elems.Element().(*testtypes.TestProto))
+	if len(upd.Changes()) > 0 {
+		Log.Fail(t, "Expected no changes, got:", len(upd.Changes()))
+		return
+	}
+
+	q, _ = object.NewQuery("select * from testproto limit 4 page 1", res)
+	query, _ = q.Query(res)
+	elems = s.Read(query, res)
+	if elems.Error() != nil {
+		Log.Fail(t, "Error reading page", elems.Error())
+		return
+	}
+	if len(elems.Elements()) != 4 {
+		Log.Fail(t, "Expected 4 elements in page, got:", len(elems.Elements()))
+		return
+	}
+
+	q, _ = object.NewQuery("select * from testproto where mystring="+before[3].MyString, res)
+	query, _ = q.Query(res)
+	err = s.Delete(query, res)
+	if err != nil {
+		Log.Fail(t, "Error deleting record", err)
+		return
+	}
+	q, _ = object.NewQuery("select * from testproto", res)
+	query, _ = q.Query(res)
+	elems = s.Read(query, res)
+	if len(elems.Elements()) != len(before)-1 {
+		Log.Fail(t, "Expected", len(before)-1, "elements after delete, got:", len(elems.Elements()))
+		return
+	}
+}
+
+// TestSqliteRelationalLayout verifies that relational data written through
+// the SQLite plugin reads back with the same ParentKey/RecKey layout.
+func TestSqliteRelationalLayout(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+
+	resp := convert.ConvertTo(ifs.POST, object.New(nil, utils.CreateTestModelInstance(1)), res)
+	if resp.Error() != nil {
+		Log.Fail(t, resp.Error())
+		return
+	}
+	written := resp.Element().(*l8orms.L8OrmRData)
+	err := s.WriteRelational(ifs.POST, written)
+	if err != nil {
+		Log.Fail(t, "Error writing relationship", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto", res)
+	query, _ := q.Query(res)
+	read, metadata, err := s.ReadRelational(query)
+	if err != nil {
+		Log.Fail(t, "Error reading relationship", err)
+		return
+	}
+	if metadata == nil || metadata.KeyCount.Counts["Total"] != 1 {
+		Log.Fail(t, "Expected a total count of 1")
+		return
+	}
+	for tableName, table := range written.Tables {
+		readTable, ok := read.Tables[tableName]
+		if !ok {
+			Log.Fail(t, "Missing table ", tableName)
+			return
+		}
+		for parentKey := range table.InstanceRows {
+			if _, ok := readTable.InstanceRows[parentKey]; !ok {
+				Log.Fail(t, "Missing parent key ", parentKey, " in table ", tableName)
+				return
+			}
+		}
+	}
+}
+
+// TestSqliteTSDB tests the SQLite time series store.
+func TestSqliteTSDB(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+
+	now := time.Now().Unix()
+	notifications := make([]*l8notify.L8TSDBNotification, 5)
+	for i := 0; i < 5; i++ {
+		notifications[i] = &l8notify.L8TSDBNotification{
+			PropertyId: "device-001.cpu",
+			Point:      &l8api.L8TimeSeriesPoint{Stamp: now + int64(i*60), Value: float64(i)},
+		}
+	}
+	err := s.AddTSDB(notifications)
+	if err != nil {
+		Log.Fail(t, "AddTSDB failed:", err)
+		return
+	}
+	points, err := s.GetTSDB("device-001.cpu", now+60, now+180)
+	if err != nil {
+		Log.Fail(t, "GetTSDB failed:", err)
+		return
+	}
+	if len(points) != 3 {
+		Log.Fail(t, "Expected 3 points in range, got:", len(points))
+		return
+	}
+}
+
+// TestSqliteTSDBBatch tests the range batch query of the SQLite time series store.
+func TestSqliteTSDBBatch(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+	checkRangeBatch(t, s)
+}
+
+// TestSqliteQuotedKeys verifies that keys containing quotes are bound as
+// parameters when paging by RecKey and when deleting child rows by ParentKey.
+func TestSqliteQuotedKeys(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+
+	before := make([]*testtypes.TestProto, 3)
+	for i := 0; i < len(before); i++ {
+		before[i] = utils.CreateTestModelInstance(i)
+		before[i].MyString = "o'neil-" + before[i].MyString
+	}
+	err := s.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto limit 2 page 0", res)
+	query, _ := q.Query(res)
+	elems := s.Read(query, res)
+	if elems.Error() != nil || len(elems.Elements()) != 2 {
+		Log.Fail(t, "Expected 2 elements in page")
+		return
+	}
+
+	q, _ = object.NewQuery("select * from testproto", res)
+	query, _ = q.Query(res)
+	err = s.Delete(query, res)
+	if err != nil {
+		Log.Fail(t, "Error deleting records", err)
+		return
+	}
+	q, _ = object.NewQuery("select * from testprotosub", res)
+	query, _ = q.Query(res)
+	data, _, err := s.ReadRelational(query)
+	if err != nil {
+		Log.Fail(t, "Error reading child table", err)
+		return
+	}
+	for _, table := range data.Tables {
+		if len(table.InstanceRows) != 0 {
+			Log.Fail(t, "Expected child rows to be deleted")
+			return
+		}
+	}
+}
+
+// TestSqlitePutReplace verifies that PUT removes the child rows of map
+// entries that are no longer part of the object.
+func TestSqlitePutReplace(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+	checkPutReplace(t, s, res)
+}
+
+// checkPutReplace PUTs an element with one map entry less than stored and
+// checks that the removed entry is gone and the rest is as written.
+func checkPutReplace(t *testing.T, orm common.IORM, res ifs.IResources) {
+	before := utils.CreateTestModelInstance(4)
+	err := orm.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing record", err)
+		return
+	}
+
+	after := utils.CreateTestModelInstance(4)
+	for key := range after.MyString2ModelMap {
+		delete(after.MyString2ModelMap, key)
+		break
+	}
+	err = orm.Write(ifs.PUT, object.New(nil, after), res)
+	if err != nil {
+		Log.Fail(t, "Error replacing record", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto where mystring="+after.MyString, res)
+	query, _ := q.Query(res)
+	elems := orm.Read(query, res)
+	if elems.Error() != nil || len(elems.Elements()) != 1 {
+		Log.Fail(t, "Expected 1 element after PUT")
+		return
+	}
+	replaced := elems.Element().(*testtypes.TestProto)
+	if len(replaced.MyString2ModelMap) != len(after.MyString2ModelMap) {
+		Log.Fail(t, "Expected", len(after.MyString2ModelMap), "map entries, got:", len(replaced.MyString2ModelMap))
+		return
+	}
+	upd := updating.NewUpdater(res, true, true)
+	upd.Update(after, replaced)
+	if len(upd.Changes()) > 0 {
+		Log.Fail(t, "Expected no changes, got:", len(upd.Changes()))
+		return
+	}
+}
+
+// TestSqliteWildcardKeys verifies that a PUT only replaces the child rows of
+// its own keys when they contain pattern wildcards.
+func TestSqliteWildcardKeys(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+	checkWildcardKeys(t, s, res)
+}
+
+// checkWildcardKeys stores an element whose key the LIKE or GLOB wildcards of
+// other keys would match, PUTs those other elements with fewer map entries
+// and checks that the first element keeps all its child rows.
+func checkWildcardKeys(t *testing.T, orm common.IORM, res ifs.IResources) {
+	keys := []string{"devX1", "dev_1", "dev%", "dev*", "dev?1", "dev[X]1"}
+	before := make([]*testtypes.TestProto, len(keys))
+	for i, key := range keys {
+		before[i] = utils.CreateTestModelInstance(i)
+		before[i].MyString = key
+	}
+	err := orm.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	after := make([]*testtypes.TestProto, 0, len(keys)-1)
+	for i, key := range keys[1:] {
+		elem := utils.CreateTestModelInstance(i + 1)
+		elem.MyString = key
+		for mapKey := range elem.MyString2ModelMap {
+			delete(elem.MyString2ModelMap, mapKey)
+			break
+		}
+		after = append(after, elem)
+	}
+	err = orm.Write(ifs.PUT, object.New(nil, after), res)
+	if err != nil {
+		Log.Fail(t, "Error replacing records", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto", res)
+	query, _ := q.Query(res)
+	elems := orm.Read(query, res)
+	if elems.Error() != nil || len(elems.Elements()) != len(keys) {
+		Log.Fail(t, "Expected", len(keys), "elements after PUT")
+		return
+	}
+	expected := map[string]*testtypes.TestProto{before[0].MyString: before[0]}
+	for _, elem := range after {
+		expected[elem.MyString] = elem
+	}
+	for _, elem := range elems.Elements() {
+		found := elem.(*testtypes.TestProto)
+		upd := updating.NewUpdater(res, true, true)
+		upd.Update(expected[found.MyString], found)
+		if len(upd.Changes()) > 0 {
+			Log.Fail(t, "Expected no changes for key", found.MyString, "got:", len(upd.Changes()))
+			return
+		}
+	}
+}
+
+// TestSqlitePatchFieldMask verifies that a PATCH with a field mask writes the
+// listed fields even when they are zero and leaves unlisted fields untouched.
+func TestSqlitePatchFieldMask(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+
+	before := utils.CreateTestModelInstance(6)
+	err := s.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing record", err)
+		return
+	}
+
+	patch := &testtypes.TestProto{MyString: before.MyString, MyInt32: 0, MyInt64: 424242, MyFloat32: 0}
+	err = s.Write(ifs.PATCH, convert.NewFieldMask(object.New(nil, patch), "myint32", "testproto.myfloat32"), res)
+	if err != nil {
+		Log.Fail(t, "Error patching record", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto where mystring="+before.MyString, res)
+	query, _ := q.Query(res)
+	elems := s.Read(query, res)
+	if elems.Error() != nil || len(elems.Elements()) != 1 {
+		Log.Fail(t, "Expected 1 element after PATCH")
+		return
+	}
+	patched := elems.Element().(*testtypes.TestProto)
+	if patched.MyInt32 != 0 || patched.MyFloat32 != 0 {
+		Log.Fail(t, "Expected listed fields to be set to zero, got:", patched.MyInt32, patched.MyFloat32)
+		return
+	}
+	if patched.MyInt64 != before.MyInt64 {
+		Log.Fail(t, "Expected unlisted MyInt64 to be", before.MyInt64, "got:", patched.MyInt64)
+		return
+	}
+	if len(patched.MyString2ModelMap) != len(before.MyString2ModelMap) {
+		Log.Fail(t, "Expected unlisted child tables to be untouched")
+		return
+	}
+}
+
+// TestSqlitePatchMaskedMap verifies that a PATCH listing a map field in its
+// field mask replaces the stored map.
+func TestSqlitePatchMaskedMap(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+	checkMaskedMap(t, s, res)
+}
+
+// checkMaskedMap PATCHes an element with a field mask listing its map field,
+// first with a single entry and then with none, and checks that the stored map
+// is shortened and then cleared while unlisted fields are untouched.
+func checkMaskedMap(t *testing.T, orm common.IORM, res ifs.IResources) {
+	before := utils.CreateTestModelInstance(7)
+	if len(before.MyString2ModelMap) < 2 {
+		Log.Fail(t, "Expected the test element to have several map entries")
+		return
+	}
+	err := orm.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing record", err)
+		return
+	}
+
+	patch := &testtypes.TestProto{MyString: before.MyString, MyString2ModelMap: map[string]*testtypes.TestProtoSub{}}
+	for key, value := range before.MyString2ModelMap {
+		patch.MyString2ModelMap[key] = value
+		break
+	}
+	for _, entries := range []int{1, 0} {
+		if entries == 0 {
+			patch.MyString2ModelMap = nil
+		}
+		err = orm.Write(ifs.PATCH, convert.NewFieldMask(object.New(nil, patch), "mystring2modelmap"), res)
+		if err != nil {
+			Log.Fail(t, "Error patching record", err)
+			return
+		}
+
+		q, _ := object.NewQuery("select * from testproto where mystring="+before.MyString, res)
+		query, _ := q.Query(res)
+		elems := orm.Read(query, res)
+		if elems.Error() != nil || len(elems.Elements()) != 1 {
+			Log.Fail(t, "Expected 1 element after PATCH")
+			return
+		}
+		patched := elems.Element().(*testtypes.TestProto)
+		if len(patched.MyString2ModelMap) != entries {
+			Log.Fail(t, "Expected", entries, "map entries, got:", len(patched.MyString2ModelMap))
+			return
+		}
+		for key, value := range patch.MyString2ModelMap {
+			found, ok := patched.MyString2ModelMap[key]
+			if !ok {
+				Log.Fail(t, "Expected the map entry", key, "to be kept")
+				return
+			}
+			upd := updating.NewUpdater(res, true, true)
+			upd.Update(value, found)
+			if len(upd.Changes()) > 0 {
+				Log.Fail(t, "Expected the kept map entry to be unchanged, got:", len(upd.Changes()))
+				return
+			}
+		}
+		if patched.MyInt64 != before.MyInt64 || patched.MyInt32 != before.MyInt32 {
+			Log.Fail(t, "Expected unlisted fields to be untouched")
+			return
+		}
+	}
+}
+
+// softDeleteOrm is a plugin supporting soft delete.
+type softDeleteOrm interface {
+	common.IORM
+	common.ISoftDelete
+}
+
+// TestSqliteVersionConflict verifies that writes increment the version column
+// and that a write carrying a stale version fails with a conflict.
+func TestSqliteVersionConflict(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+	s.EnableVersioning("MyInt64")
+	checkVersionConflict(t, s, res)
+}
+
+// checkVersionConflict writes an element of a plugin versioning MyInt64, and
+// checks that each write increments the version and that a write carrying a
+// stale version fails with a conflict and leaves the stored version as is.
+func checkVersionConflict(t *testing.T, orm common.IORM, res ifs.IResources) {
+	rec := utils.CreateTestModelInstance(2)
+	err := orm.Write(ifs.POST, object.New(nil, rec), res)
+	if err != nil {
+		Log.Fail(t, "Error writing record", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto where mystring="+rec.MyString, res)
+	query, _ := q.Query(res)
+	stored := orm.Read(query, res).Element().(*testtypes.TestProto)
+	if stored.MyInt64 != 1 {
+		Log.Fail(t, "Expected version 1 after insert, got:", stored.MyInt64)
+		return
+	}
+
+	err = orm.Write(ifs.PUT, object.New(nil, stored), res)
+	if err != nil {
+		Log.Fail(t, "Error replacing record with the current version", err)
+		return
+	}
+
+	err = orm.Write(ifs.PUT, object.New(nil, stored), res)
+	var conflict *common.VersionConflictError
+	if !errors.As(err, &conflict) || !common.IsVersionConflict(err) {
+		Log.Fail(t, "Expected a version conflict, got:", err)
+		return
+	}
+	if conflict.Version != 1 || conflict.Stored != 2 {
+		Log.Fail(t, "Unexpected conflict versions", conflict.Version, conflict.Stored)
+		return
+	}
+
+	stored = orm.Read(query, res).Element().(*testtypes.TestProto)
+	if stored.MyInt64 != 2 {
+		Log.Fail(t, "Expected version 2 after the rejected write, got:", stored.MyInt64)
+		return
+	}
+}
+
+// TestSqliteSoftDelete verifies that a soft delete type keeps deleted rows as
+// tombstones that reads skip, that WithDeleted and Restore bring them back,
+// and that Purge removes them for good.
+func TestSqliteSoftDelete(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+	s.EnableSoftDelete("TestProto")
+	checkSoftDelete(t, s, res)
+}
+
+// checkSoftDelete deletes an element of a plugin with TestProto in soft
+// delete mode, and checks that reads skip it unless WithDeleted, that Restore
+// brings it back with its children and that Purge removes it for good.
+func checkSoftDelete(t *testing.T, orm softDeleteOrm, res ifs.IResources) {
+	before := make([]*testtypes.TestProto, 5)
+	for i := 0; i < len(before); i++ {
+		before[i] = utils.CreateTestModelInstance(i)
+	}
+	err := orm.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto where mystring="+before[2].MyString, res)
+	deleted, _ := q.Query(res)
+	err = orm.Delete(deleted, res)
+	if err != nil {
+		Log.Fail(t, "Error deleting record", err)
+		return
+	}
+
+	q, _ = object.NewQuery("select * from testproto", res)
+	all, _ := q.Query(res)
+	if n := len(orm.Read(all, res).Elements()); n != len(before)-1 {
+		Log.Fail(t, "Expected", len(before)-1, "live elements, got:", n)
+		return
+	}
+	if n := len(orm.Read(common.WithDeleted(all), res).Elements()); n != len(before) {
+		Log.Fail(t, "Expected", len(before), "elements with deleted, got:", n)
+		return
+	}
+
+	err = orm.Restore(deleted)
+	if err != nil {
+		Log.Fail(t, "Error restoring record", err)
+		return
+	}
+	elems := orm.Read(deleted, res)
+	if len(elems.Elements()) != 1 {
+		Log.Fail(t, "Expected the restored element, got:", len(elems.Elements()))
+		return
+	}
+	upd := updating.NewUpdater(res, true, true)
+	upd.Update(before[2], elems.Element().(*testtypes.TestProto))
+	if len(upd.Changes()) > 0 {
+		Log.Fail(t, "Expected the restored element to keep its children, got changes:", len(upd.Changes()))
+		return
+	}
+
+	err = orm.Delete(deleted, res)
+	if err != nil {
+		Log.Fail(t, "Error deleting record", err)
+		return
+	}
+	err = orm.Purge("TestProto", 0)
+	if err != nil {
+		Log.Fail(t, "Error purging tombstones", err)
+		return
+	}
+	if n := len(orm.Read(common.WithDeleted(all), res).Elements()); n != len(before)-1 {
+		Log.Fail(t, "Expected", len(before)-1, "elements after purge, got:", n)
+		return
+	}
+}
+
+// TestSqliteContext verifies that the Context variants fail without touching
+// the database once their context is done, and that timeouts are recognized.
+func TestSqliteContext(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+
+	rec := utils.CreateTestModelInstance(1)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := s.WriteContext(cancelled, ifs.POST, object.New(nil, rec), res)
+	if !errors.Is(err, context.Canceled) {
+		Log.Fail(t, "Expected a cancelled write, got:", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto where mystring="+rec.MyString, res)
+	query, _ := q.Query(res)
+	if n := len(s.Read(query, res).Elements()); n != 0 {
+		Log.Fail(t, "Expected the cancelled write to store nothing, got:", n)
+		return
+	}
+
+	err = s.WriteContext(context.Background(), ifs.POST, object.New(nil, rec), res)
+	if err != nil {
+		Log.Fail(t, "Error writing record", err)
+		return
+	}
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	elems := s.ReadContext(expired, query, res)
+	if !common.IsTimeout(elems.Error()) {
+		Log.Fail(t, "Expected an expired read, got:", elems.Error())
+		return
+	}
+
+	timeout := &common.TimeoutError{Operation: "Read", TypeName: "TestProto", Timeout: time.Second}
+	if !common.IsTimeout(timeout) || !common.IsTimeout(errors.New(timeout.Error())) {
+		Log.Fail(t, "Expected a TimeoutError and its text to be recognized")
+		return
+	}
+}
+
+// TestSqlitePageChildren verifies that a paginated read loads the child rows
+// of the page's root elements only, and loads them completely.
+func TestSqlitePageChildren(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+	checkPageChildren(t, s, res)
+}
+
+// checkPageChildren reads a page of elements and checks that each one is
+// read with all its child rows.
+func checkPageChildren(t *testing.T, orm common.IORM, res ifs.IResources) {
+	before := make(map[string]*testtypes.TestProto)
+	list := make([]*testtypes.TestProto, 6)
+	for i := 0; i < len(list); i++ {
+		list[i] = utils.CreateTestModelInstance(i)
+		before[list[i].MyString] = list[i]
+	}
+	err := orm.Write(ifs.POST, object.New(nil, list), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto limit 2 page 1", res)
+	query, _ := q.Query(res)
+	elems := orm.Read(query, res)
+	if elems.Error() != nil || len(elems.Elements()) != 2 {
+		Log.Fail(t, "Expected 2 elements in page")
+		return
+	}
+	for _, elem := range elems.Elements() {
+		read := elem.(*testtypes.TestProto)
+		expected, ok := before[read.MyString]
+		if !ok {
+			Log.Fail(t, "Unexpected element in page ", read.MyString)
+			return
+		}
+		upd := updating.NewUpdater(res, true, true)
+		upd.Update(expected, read)
+		if len(upd.Changes()) > 0 {
+			Log.Fail(t, "Expected the page element to keep its children, got changes:", len(upd.Changes()))
+			return
+		}
+	}
+}
+
+// TestSqliteNestedCriteria verifies that criteria on child table attributes
+// filter the root elements on read and on delete.
+func TestSqliteNestedCriteria(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+	checkNestedCriteria(t, s, res)
+}
+
+// checkNestedCriteria writes elements whose single child differs in status
+// and checks that reads and deletes filtered by the child's status only see
+// the matching elements.
+func checkNestedCriteria(t *testing.T, orm common.IORM, res ifs.IResources) {
+	before := make([]*testtypes.TestProto, 6)
+	for i := 0; i < len(before); i++ {
+		before[i] = utils.CreateTestModelInstance(i)
+		before[i].MySingle.MyString = "up"
+		if i%2 == 1 {
+			before[i].MySingle.MyString = "down"
+		}
+	}
+	err := orm.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto where mysingle.mystring=down", res)
+	down, _ := q.Query(res)
+	elems := orm.Read(down, res)
+	if elems.Error() != nil || len(elems.Elements()) != len(before)/2 {
+		Log.Fail(t, "Expected", len(before)/2, "elements with a down child, got:", len(elems.Elements()))
+		return
+	}
+	for _, elem := range elems.Elements() {
+		if elem.(*testtypes.TestProto).MySingle.MyString != "down" {
+			Log.Fail(t, "Expected only elements with a down child")
+			return
+		}
+	}
+
+	err = orm.Delete(down, res)
+	if err != nil {
+		Log.Fail(t, "Error deleting records", err)
+		return
+	}
+	q, _ = object.NewQuery("select * from testproto", res)
+	all, _ := q.Query(res)
+	elems = orm.Read(all, res)
+	if len(elems.Elements()) != len(before)/2 {
+		Log.Fail(t, "Expected", len(before)/2, "elements after delete, got:", len(elems.Elements()))
+		return
+	}
+	for _, elem := range elems.Elements() {
+		if elem.(*testtypes.TestProto).MySingle.MyString != "up" {
+			Log.Fail(t, "Expected only elements with an up child after delete")
+			return
+		}
+	}
+}
+
+// TestSqliteOrderBy verifies paging by several sort keys, one of them on a
+// nested struct attribute.
+func TestSqliteOrderBy(t *testing.T) {
+	res, _ := CreateResources(25000, 1, ifs.Info_Level)
+	s := sqlite.NewSqlite(openSqlite(t), res)
+	defer s.Close()
+	checkOrderBy(t, s, res)
+}
+
+// checkOrderBy sorts elements by a root attribute ascending, then by their
+// single child's string descending, and checks the order of the page.
+func checkOrderBy(t *testing.T, orm common.IORM, res ifs.IResources) {
+	before := make([]*testtypes.TestProto, 6)
+	for i := 0; i < len(before); i++ {
+		before[i] = utils.CreateTestModelInstance(i)
+		before[i].MyInt32 = int32(i % 2)
+		before[i].MySingle.MyString = "sub-" + string(rune('a'+i))
+	}
+	err := orm.Write(ifs.POST, object.New(nil, before), res)
+	if err != nil {
+		Log.Fail(t, "Error writing records", err)
+		return
+	}
+
+	q, _ := object.NewQuery("select * from testproto limit 6 page 0", res)
+	query, _ := q.Query(res)
+	query = common.OrderBy(query, common.Asc("myint32"), common.Desc("mysingle.mystring"))
+	elems := orm.Read(query, res)
+	if elems.Error() != nil || len(elems.Elements()) != len(before) {
+		Log.Fail(t, "Expected", len(before), "sorted elements")
+		return
+	}
+	expected := []int{4, 2, 0, 5, 3, 1}
+	for i, elem := range elems.Elements() {
+		if elem.(*testtypes.TestProto).MyString != before[expected[i]].MyString {
+			Log.Fail(t, "Unexpected element at position", i)
+			return
+		}
+	}
+}
@@ -15,9 +15,12 @@ limitations under the License.
 package tests
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/saichler/l8orm/go/orm/common"
+	"github.com/saichler/l8orm/go/orm/plugins/memory"
 	"github.com/saichler/l8orm/go/orm/plugins/postgres"
 	. "github.com/saichler/l8test/go/infra/t_resources"
 	"github.com/saichler/l8types/go/types/l8api"
@@ -238,3 +241,370 @@ func TestTSDBNilNotifications(t *testing.T) {
 		return
 	}
 }
+
+// TestTSDBBuckets tests the bucketed query of each aggregate function.
+func TestTSDBBuckets(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	cleanTsdb(db)
+	defer cleanup(db)
+
+	tsdb := postgres.NewTsdb(db, false)
+	defer tsdb.Close()
+	checkBuckets(t, tsdb)
+}
+
+// TestMemoryTSDBBuckets tests the bucketed query of the in-memory TSDB.
+func TestMemoryTSDBBuckets(t *testing.T) {
+	tsdb := memory.NewTsdb()
+	defer tsdb.Close()
+	checkBuckets(t, tsdb)
+}
+
+// checkBuckets writes two minutes of points and checks every aggregate
+// function over one minute buckets.
+func checkBuckets(t *testing.T, tsdb common.ITSDB) {
+	base := time.Now().Unix() / 3600 * 3600
+	propertyId := "device-004.cpu"
+	stamps := []int64{0, 10, 20, 60, 70}
+	values := []float64{1, 2, 3, 10, 4}
+	notifications := make([]*l8notify.L8TSDBNotification, len(stamps))
+	for i := range stamps {
+		notifications[i] = &l8notify.L8TSDBNotification{
+			PropertyId: propertyId,
+			Point:      &l8api.L8TimeSeriesPoint{Stamp: base + stamps[i], Value: values[i]},
+		}
+	}
+	err := tsdb.AddTSDB(notifications)
+	if err != nil {
+		Log.Fail(t, "AddTSDB failed:", err)
+		return
+	}
+
+	expected := map[string][]float64{
+		common.TsdbAvg:   {2, 7},
+		common.TsdbMin:   {1, 4},
+		common.TsdbMax:   {3, 10},
+		common.TsdbLast:  {3, 4},
+		common.TsdbCount: {3, 2},
+	}
+	for function, want := range expected {
+		points, err := tsdb.GetTSDBBuckets(propertyId, base, base+119, 60, function)
+		if err != nil {
+			Log.Fail(t, "GetTSDBBuckets", function, "failed:", err)
+			return
+		}
+		if len(points) != len(want) {
+			Log.Fail(t, "Expected", len(want), function, "buckets, got:", len(points))
+			return
+		}
+		for i, p := range points {
+			if p.Stamp != base+int64(i*60) || p.Value != want[i] {
+				Log.Fail(t, function, "bucket", i, "expected", base+int64(i*60), want[i], "got", p.Stamp, p.Value)
+				return
+			}
+		}
+	}
+
+	// A range starting and ending inside buckets covers them whole
+	points, err := tsdb.GetTSDBBuckets(propertyId, base+15, base+65, 60, common.TsdbCount)
+	if err != nil || len(points) != 2 || points[0].Value != 3 || points[1].Value != 2 {
+		Log.Fail(t, "Expected the partial range to cover whole buckets, got:", points, err)
+		return
+	}
+
+	_, err = tsdb.GetTSDBBuckets(propertyId, base, base+119, 60, "median")
+	if err == nil {
+		Log.Fail(t, "Expected an unsupported function to fail")
+		return
+	}
+}
+
+// TestTSDBLifecycle tests that the TSDB sets up its aggregates and policies,
+// and answers hourly buckets from the hourly aggregate.
+func TestTSDBLifecycle(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	cleanTsdb(db)
+	defer cleanup(db)
+
+	tsdb := postgres.NewTsdb(db, false, postgres.TsdbConfig{RawRetention: 3 * 24 * time.Hour})
+	defer tsdb.Close()
+
+	base := time.Now().Unix()/3600*3600 - 3600
+	propertyId := "device-005.cpu"
+	notifications := []*l8notify.L8TSDBNotification{
+		{PropertyId: propertyId, Point: &l8api.L8TimeSeriesPoint{Stamp: base, Value: 10}},
+		{PropertyId: propertyId, Point: &l8api.L8TimeSeriesPoint{Stamp: base + 600, Value: 20}},
+		{PropertyId: propertyId, Point: &l8api.L8TimeSeriesPoint{Stamp: base + 3600, Value: 60}},
+	}
+	err := tsdb.AddTSDB(notifications)
+	if err != nil {
+		Log.Fail(t, "AddTSDB failed:", err)
+		return
+	}
+
+	for _, view := range []string{"l8tsdb_hourly", "l8tsdb_daily"} {
+		var count int
+		err = db.QueryRow("SELECT count(*) FROM timescaledb_information.continuous_aggregates "+
+			"WHERE view_name = $1", view).Scan(&count)
+		if err != nil || count != 1 {
+			Log.Fail(t, "Expected the continuous aggregate", view, err)
+			return
+		}
+	}
+
+	var jobs int
+	err = db.QueryRow("SELECT count(*) FROM timescaledb_information.jobs " +
+		"WHERE proc_name IN ('policy_retention', 'policy_compression', 'policy_refresh_continuous_aggregate')").Scan(&jobs)
+	if err != nil || jobs != 6 {
+		Log.Fail(t, "Expected 3 retention, 1 compression and 2 refresh policies, got:", jobs, err)
+		return
+	}
+
+	points, err := tsdb.GetTSDBBuckets(propertyId, base, base+7199, 3600, "avg")
+	if err != nil {
+		Log.Fail(t, "GetTSDBBuckets failed:", err)
+		return
+	}
+	if len(points) != 2 || points[0].Stamp != base || points[0].Value != 15 || points[1].Value != 60 {
+		Log.Fail(t, "Unexpected hourly buckets:", points)
+		return
+	}
+
+	// A range starting inside the first hour reads it whole from the aggregate
+	points, err = tsdb.GetTSDBBuckets(propertyId, base+1200, base+3700, 3600, "avg")
+	if err != nil || len(points) != 2 || points[0].Value != 15 || points[1].Value != 60 {
+		Log.Fail(t, "Expected the partial range to cover whole hours, got:", points, err)
+		return
+	}
+}
+
+// TestTSDBLifecycleKeepsPolicies tests that a policy already in the database
+// is not replaced by the configured one.
+func TestTSDBLifecycleKeepsPolicies(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	cleanTsdb(db)
+	defer cleanup(db)
+
+	tsdb := postgres.NewTsdb(db, false)
+	_, err := tsdb.GetTSDBBuckets("device-006.cpu", 0, 3600, 3600, "avg")
+	tsdb.Close()
+	if err != nil {
+		Log.Fail(t, "GetTSDBBuckets failed:", err)
+		return
+	}
+	_, err = db.Exec("SELECT remove_retention_policy('l8tsdb')")
+	if err == nil {
+		_, err = db.Exec("SELECT add_retention_policy('l8tsdb', INTERVAL '30 days')")
+	}
+	if err != nil {
+		Log.Fail(t, "Tuning the retention failed:", err)
+		return
+	}
+
+	tsdb = postgres.NewTsdb(db, false, postgres.TsdbConfig{RawRetention: 24 * time.Hour})
+	defer tsdb.Close()
+	_, err = tsdb.GetTSDBBuckets("device-006.cpu", 0, 3600, 3600, "avg")
+	if err != nil {
+		Log.Fail(t, "GetTSDBBuckets failed:", err)
+		return
+	}
+	var dropAfter string
+	err = db.QueryRow("SELECT config->>'drop_after' FROM timescaledb_information.jobs " +
+		"WHERE proc_name = 'policy_retention' AND hypertable_name = 'l8tsdb'").Scan(&dropAfter)
+	if err != nil || !strings.Contains(dropAfter, "30 days") {
+		Log.Fail(t, "Expected the tuned retention to be kept, got:", dropAfter, err)
+		return
+	}
+
+	// SetRetention still replaces the retention of the raw points
+	if err = tsdb.SetRetention("60 days"); err != nil {
+		Log.Fail(t, "SetRetention failed:", err)
+		return
+	}
+	err = db.QueryRow("SELECT config->>'drop_after' FROM timescaledb_information.jobs " +
+		"WHERE proc_name = 'policy_retention' AND hypertable_name = 'l8tsdb'").Scan(&dropAfter)
+	if err != nil || !strings.Contains(dropAfter, "60 days") {
+		Log.Fail(t, "Expected SetRetention to replace the retention, got:", dropAfter, err)
+		return
+	}
+}
+
+// TestPostgresTsdbConfig tests that the TSDB of the Postgres plugin applies the
+// lifecycle set with SetTsdbConfig.
+func TestPostgresTsdbConfig(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	cleanTsdb(db)
+	defer cleanup(db)
+
+	p := postgres.NewPostgres(db, nic.Resources())
+	p.SetTsdbConfig(postgres.TsdbConfig{RawRetention: -1, CompressAfter: -1, NoAggregates: true})
+	err := p.AddTSDB([]*l8notify.L8TSDBNotification{
+		{PropertyId: "device-007.cpu", Point: &l8api.L8TimeSeriesPoint{Stamp: time.Now().Unix(), Value: 1}},
+	})
+	if err != nil {
+		Log.Fail(t, "AddTSDB failed:", err)
+		return
+	}
+	var jobs int
+	err = db.QueryRow("SELECT count(*) FROM timescaledb_information.jobs " +
+		"WHERE proc_name IN ('policy_retention', 'policy_compression', 'policy_refresh_continuous_aggregate')").Scan(&jobs)
+	if err != nil || jobs != 0 {
+		Log.Fail(t, "Expected no policies, got:", jobs, err)
+		return
+	}
+}
+
+// TestTSDBLatestBatch tests the batch query of property IDs and patterns.
+func TestTSDBLatestBatch(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	cleanTsdb(db)
+	defer cleanup(db)
+
+	tsdb := postgres.NewTsdb(db, false)
+	defer tsdb.Close()
+	checkLatestBatch(t, tsdb)
+}
+
+// TestMemoryTSDBLatestBatch tests the batch query of the in-memory TSDB.
+func TestMemoryTSDBLatestBatch(t *testing.T) {
+	tsdb := memory.NewTsdb()
+	defer tsdb.Close()
+	checkLatestBatch(t, tsdb)
+}
+
+// checkLatestBatch writes three points of several properties and checks the
+// latest two of exact property IDs and of a pattern.
+func checkLatestBatch(t *testing.T, tsdb common.ITSDB) {
+	now := time.Now().Unix()
+	propertyIds := []string{"networkdevice<1>.cpu", "networkdevice<2_1>.cpu", "networkdevice<1>.mem", "networkdevice<2x1>.cpu"}
+	var notifications []*l8notify.L8TSDBNotification
+	for i, propertyId := range propertyIds {
+		for j := 0; j < 3; j++ {
+			notifications = append(notifications, &l8notify.L8TSDBNotification{
+				PropertyId: propertyId,
+				Point:      &l8api.L8TimeSeriesPoint{Stamp: now + int64(j*60), Value: float64(i*10 + j)},
+			})
+		}
+	}
+	err := tsdb.AddTSDB(notifications)
+	if err != nil {
+		Log.Fail(t, "AddTSDB failed:", err)
+		return
+	}
+
+	points, err := tsdb.GetTSDBLatestBatch([]string{"networkdevice<1>.cpu", "networkdevice<1>.mem", "missing.cpu"}, 2)
+	if err != nil {
+		Log.Fail(t, "GetTSDBLatestBatch failed:", err)
+		return
+	}
+	if len(points) != 2 || len(points["networkdevice<1>.mem"]) != 2 {
+		Log.Fail(t, "Expected 2 properties of 2 points, got:", points)
+		return
+	}
+	mem := points["networkdevice<1>.mem"]
+	if mem[0].Value != 21 || mem[1].Value != 22 || mem[0].Stamp > mem[1].Stamp {
+		Log.Fail(t, "Expected the latest mem points in order, got:", mem)
+		return
+	}
+
+	points, err = tsdb.GetTSDBLatestBatch([]string{"networkdevice<*>.cpu"}, 3)
+	if err != nil {
+		Log.Fail(t, "GetTSDBLatestBatch pattern failed:", err)
+		return
+	}
+	if len(points) != 3 || len(points["networkdevice<1>.cpu"]) != 3 || len(points["networkdevice<2_1>.cpu"]) != 3 {
+		Log.Fail(t, "Expected the 3 cpu properties of the pattern, got:", points)
+		return
+	}
+
+	points, err = tsdb.GetTSDBLatestBatch([]string{"networkdevice<2_*"}, 1)
+	if err != nil {
+		Log.Fail(t, "GetTSDBLatestBatch escaped pattern failed:", err)
+		return
+	}
+	if len(points) != 1 || len(points["networkdevice<2_1>.cpu"]) != 1 {
+		Log.Fail(t, "Expected _ to match itself only, got:", points)
+		return
+	}
+}
+
+// TestTSDBBatch tests the range batch query of property IDs and patterns.
+func TestTSDBBatch(t *testing.T) {
+	nic := topo.VnicByVnetNum(2, 2)
+	db := openDBConection(nic.Resources())
+	cleanTsdb(db)
+	defer cleanup(db)
+
+	tsdb := postgres.NewTsdb(db, false)
+	defer tsdb.Close()
+	checkRangeBatch(t, tsdb)
+}
+
+// TestMemoryTSDBBatch tests the range batch query of the in-memory TSDB.
+func TestMemoryTSDBBatch(t *testing.T) {
+	tsdb := memory.NewTsdb()
+	defer tsdb.Close()
+	checkRangeBatch(t, tsdb)
+}
+
+// checkRangeBatch writes two minutes of points of several properties and
+// checks the raw points of exact property IDs and the buckets of a pattern.
+func checkRangeBatch(t *testing.T, tsdb common.ITSDB) {
+	base := time.Now().Unix() / 3600 * 3600
+	propertyIds := []string{"router<1>.cpu", "router<2>.cpu", "router<1>.mem"}
+	var notifications []*l8notify.L8TSDBNotification
+	for i, propertyId := range propertyIds {
+		for j, stamp := range []int64{0, 10, 60, 70} {
+			notifications = append(notifications, &l8notify.L8TSDBNotification{
+				PropertyId: propertyId,
+				Point:      &l8api.L8TimeSeriesPoint{Stamp: base + stamp, Value: float64(i*10 + j)},
+			})
+		}
+	}
+	err := tsdb.AddTSDB(notifications)
+	if err != nil {
+		Log.Fail(t, "AddTSDB failed:", err)
+		return
+	}
+
+	points, err := tsdb.GetTSDBBatch([]string{"router<1>.cpu", "router<1>.mem", "missing.cpu"}, base, base+60, 0, "")
+	if err != nil {
+		Log.Fail(t, "GetTSDBBatch failed:", err)
+		return
+	}
+	if len(points) != 2 || len(points["router<1>.cpu"]) != 3 {
+		Log.Fail(t, "Expected 2 properties of 3 points, got:", points)
+		return
+	}
+	mem := points["router<1>.mem"]
+	if len(mem) != 3 || mem[0].Value != 20 || mem[2].Value != 22 || mem[0].Stamp > mem[2].Stamp {
+		Log.Fail(t, "Expected the mem points in range in order, got:", mem)
+		return
+	}
+
+	points, err = tsdb.GetTSDBBatch([]string{"router<*>.cpu"}, base, base+119, 60, common.TsdbMax)
+	if err != nil {
+		Log.Fail(t, "GetTSDBBatch pattern failed:", err)
+		return
+	}
+	if len(points) != 2 {
+		Log.Fail(t, "Expected the 2 cpu properties of the pattern, got:", points)
+		return
+	}
+	cpu := points["router<2>.cpu"]
+	if len(cpu) != 2 || cpu[0].Stamp != base || cpu[0].Value != 11 || cpu[1].Value != 13 {
+		Log.Fail(t, "Expected 2 max buckets of router<2>.cpu, got:", cpu)
+		return
+	}
+
+	_, err = tsdb.GetTSDBBatch([]string{"router<1>.cpu"}, base, base+119, 0, common.TsdbAvg)
+	if err == nil {
+		Log.Fail(t, "Expected a function without a bucket to fail")
+		return
+	}
+}